@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/auth"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/config"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/database"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/llm"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailsource"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
+	"gorm.io/gorm"
+)
+
+// runImport handles "import mail <email> <path>|backfill <email> <from> [to] [-dry-run] [flags]"
+func runImport(args []string) {
+	if len(args) == 0 || (args[0] != "mail" && args[0] != "backfill") {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	action := args[0]
+
+	// 1. -dry-run is ours, the other flags are settings
+	pos, args := positional(args[1:])
+	switch {
+	case action == "mail" && len(pos) != 2:
+		log.Fatal("import mail: expected <email> <path>")
+	case action == "backfill" && len(pos) != 2 && len(pos) != 3:
+		log.Fatal("import backfill: expected <email> <from> [to], dates as 2006-01-02")
+	}
+	dryRun := false
+	rest := args[:0]
+	for _, arg := range args {
+		if arg == "-dry-run" || arg == "--dry-run" {
+			dryRun = true
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+
+	cfg := loadConfig("import "+action, rest)
+	db := database.Connect(cfg.Database.Driver, cfg.Database.DSN, cfg.Database.MaxOpenConns)
+	if err := database.CheckSchema(db); err != nil {
+		log.Fatal("❌ ", err)
+	}
+
+	// 2. The same pipeline as the watcher
+	emailService := emailPipeline(cfg, db)
+	user, err := emailService.UserService.GetByEmail(pos[0])
+	if err != nil {
+		log.Fatalf("❌ %s: %v", pos[0], err)
+	}
+
+	// 3. Read the archive or the mailboxes, then process it (Ctrl-C stops after the current email)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var report *services.ImportReport
+	if action == "mail" {
+		imp := services.NewMailImport(dryRun)
+		if err := mailsource.ReadPath(pos[1], imp.Add); err != nil {
+			log.Fatal("❌ ", err)
+		}
+		report, err = emailService.ImportMail(ctx, user, imp)
+	} else {
+		after, before := backfillRange(pos[1:])
+		connectMailboxes(cfg, db, emailService)
+		report, err = emailService.Backfill(ctx, user, after, before, dryRun)
+	}
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+
+	// 4. Report
+	if report.DryRun {
+		fmt.Println("Dry run: nothing was written. These jobs would change:")
+	} else {
+		fmt.Println("Jobs changed:")
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB\tCOMPANY\tTITLE\tFROM\tTO\tEMAIL")
+	for _, ch := range report.Changes {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", *ch.JobID, ch.Company, ch.JobTitle, ch.FromStatus, ch.ToStatus, ch.Subject)
+	}
+	w.Flush()
+	fmt.Printf("\n%d read, %d unreadable, %d not job related, %d duplicates, %d processed\n",
+		report.Read, report.Unreadable, report.Unrelated, report.Duplicates, report.Processed)
+	for _, action := range []string{services.OutcomeStatusChanged, services.OutcomeQueued, services.OutcomeNoChange, services.OutcomeSkipped, services.OutcomeFailed} {
+		if n := report.Counts[action]; n > 0 {
+			fmt.Printf("  %-18s %d\n", action, n)
+		}
+	}
+}
+
+// emailPipeline builds the email service the way serve does, without mailboxes
+func emailPipeline(cfg *config.Config, db *gorm.DB) *services.EmailService {
+	provider, err := llm.New(context.Background(), cfg.LLMProvider())
+	if err != nil {
+		log.Fatal("Failed to initialize LLM provider: ", err)
+	}
+	llmService := services.NewLLMService(provider)
+	llmService.MaxAttempts = cfg.LLM.MaxAttempts
+	jobService := services.NewJobService(db)
+	reviewService := services.NewReviewService(db, jobService)
+	reviewService.ConfidenceThreshold = cfg.Review.ConfidenceThreshold
+	userService := services.NewUserService(db, nil)
+	emailService := services.NewEmailService(db, llmService, nil, userService, services.NewMatcherService(db), jobService, reviewService)
+	emailService.Workers = cfg.Watcher.Workers
+	emailService.GmailQuota = cfg.Gmail.QuotaPerSecond
+	return emailService
+}
+
+// backfillRange parses "<from> [to]", both days inclusive
+func backfillRange(args []string) (time.Time, time.Time) {
+	after, err := time.ParseInLocation(time.DateOnly, args[0], time.Local)
+	if err != nil {
+		log.Fatalf("❌ from: %v", err)
+	}
+	var before time.Time
+	if len(args) > 1 {
+		to, err := time.ParseInLocation(time.DateOnly, args[1], time.Local)
+		if err != nil {
+			log.Fatalf("❌ to: %v", err)
+		}
+		before = to.AddDate(0, 0, 1)
+		if !before.After(after) {
+			log.Fatal("❌ to must not be before from")
+		}
+	}
+	return after, before
+}
+
+// connectMailboxes gives the email service the users' Gmail tokens and IMAP accounts, as serve does
+func connectMailboxes(cfg *config.Config, db *gorm.DB, emailService *services.EmailService) {
+	cipher := mailCipher(cfg)
+	if tokens, err := tokenStore(cfg, db, cipher); err == nil {
+		emailService.UserService.Tokens = tokens
+		if emailService.OAuthConfig, err = auth.OAuthConfig(cfg.Gmail.CredentialsFile, cfg.Gmail.RedirectURL); err != nil {
+			log.Printf("⚠️  Gmail disabled: %v", err)
+		}
+	} else {
+		log.Printf("⚠️  Gmail disabled: %v", err)
+	}
+	if cipher != nil {
+		emailService.IMAPAccounts = services.NewIMAPAccountService(db, cipher)
+	}
+}
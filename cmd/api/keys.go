@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/database"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
+)
+
+// runKeys handles "keys list <email>|create <email> <name> [scopes]|revoke <email> <id> [flags]".
+// It is how the first key of a user is made, later ones can come from POST /keys.
+func runKeys(args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	action, args := args[0], args[1:]
+	pos, args := positional(args)
+	if len(pos) == 0 {
+		log.Fatalf("keys %s: missing <email>", action)
+	}
+
+	cfg := loadConfig("keys "+action, args)
+	db := database.Connect(cfg.Database.Driver, cfg.Database.DSN, cfg.Database.MaxOpenConns)
+	if err := database.CheckSchema(db); err != nil {
+		log.Fatal("❌ ", err)
+	}
+	user, err := services.NewUserService(db, nil).GetByEmail(pos[0])
+	if err != nil {
+		log.Fatalf("❌ %s: %v", pos[0], err)
+	}
+	authService, err := services.NewAuthService(db, cfg.Auth.SessionSecret, cfg.Auth.SessionTTL)
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+
+	switch action {
+	case "list":
+		keys, err := authService.ListAPIKeys(user.ID)
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tSTATE")
+		for _, k := range keys {
+			state := "active"
+			if k.RevokedAt != nil {
+				state = "revoked"
+			} else if k.ExpiresAt != nil {
+				state = "expires " + k.ExpiresAt.Format("2006-01-02")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s…\t%s\t%s\n", k.ID, k.Name, k.Prefix, k.Scopes, state)
+		}
+		w.Flush()
+	case "create":
+		if len(pos) < 2 || len(pos) > 3 {
+			log.Fatal("keys create: expected <email> <name> [scopes]")
+		}
+		var scopes []string
+		if len(pos) == 3 {
+			scopes = strings.Split(pos[2], ",")
+		}
+		plaintext, key, err := authService.CreateAPIKey(user.ID, pos[1], scopes, nil, nil)
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		log.Printf("✅ Created key %d (%s) with scopes: %s", key.ID, key.Name, key.Scopes)
+		fmt.Println("Store it now, it won't be shown again:")
+		fmt.Println(plaintext)
+	case "revoke":
+		if len(pos) != 2 {
+			log.Fatal("keys revoke: expected <email> <id>")
+		}
+		id, err := strconv.ParseUint(pos[1], 10, 64)
+		if err != nil {
+			log.Fatalf("keys revoke: invalid id %q", pos[1])
+		}
+		if err := authService.RevokeAPIKey(user.ID, uint(id)); err != nil {
+			log.Fatal("❌ ", err)
+		}
+		log.Printf("✅ Revoked key %d", id)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown keys action %q\n\n%s", action, usage)
+		os.Exit(2)
+	}
+}
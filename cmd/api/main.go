@@ -1,81 +1,110 @@
 package main
 
 import (
-	"context"
+	"fmt"
 	"log"
+	"os"
+	"strings"
 
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 	"github.com/justsurfingit/Agentic-Job-Tracker/internal/auth"
-	"github.com/justsurfingit/Agentic-Job-Tracker/internal/database"
-	"github.com/justsurfingit/Agentic-Job-Tracker/internal/handlers"
-	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
-	"google.golang.org/api/gmail/v1"
-	"google.golang.org/api/option"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/config"
+	"gorm.io/gorm"
 )
 
-func main() {
-	// 1. Load Environment Variables
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
+const usage = `Usage: api [command] [flags]
 
-	// 2. Database Connection
-	db := database.Connect()
+Commands:
+  serve                                Run the API server and the email watcher (default)
+  config print                         Show the effective configuration with secrets redacted
+  migrate up                           Apply all pending schema migrations
+  migrate down [N]                     Roll back the last N migrations (default 1)
+  migrate status                       List migrations and whether they are applied
+  users list                           List users and whether their Gmail is connected
+  users add <email>                    Register a user
+  users connect <email>                Import gmail.token_file for the user (new mailboxes connect in the browser)
+  users imap <email> <server> <login>  Sync the user's IMAP mailbox, password from $IMAP_PASSWORD or stdin
+  users imap-remove <email>            Stop syncing the user's IMAP mailbox
+  import mail <email> <path>           Process an .mbox archive, an .eml file or a directory of them (-dry-run: report only)
+  import backfill <email> <from> [to]  Process the user's mail of those days (YYYY-MM-DD) from their mailboxes (-dry-run: report only)
+  reprocess <email> [from] [to]        Classify the user's stored emails again (-company <name>, -job <id>, -dry-run: report only)
+  keys list <email>                    List a user's API keys
+  keys create <email> <name> [scopes]  Create an API key, scopes comma separated (default: all)
+  keys revoke <email> <id>             Revoke an API key and its sessions
+  push fake <address> <history> [url]  Send the server a Gmail push like Pub/Sub would (needs gmail.push_auth=token)
 
-	// 3. Initialize Core Services (Dependencies)
-	llmService := services.NewLLMService()
-	jobService := services.NewJobService(db)
-	matcherService := services.NewMatcherService(db)
+Every command accepts -config <file> and one flag per setting, run "api serve -h" to list them.
+`
 
-	// 4. Initialize Gmail Integration
-	log.Println("Initializing Gmail Client...")
-
-	// Use the auth helper we created to get the authenticated HTTP client
-	// This handles the token.json / credentials.json logic automatically
-	httpClient := auth.GetGmailClient()
+func main() {
+	// 1. Pick the command, "serve" when none is given
+	cmd, args := "serve", os.Args[1:]
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		cmd, args = args[0], args[1:]
+	}
 
-	var gmailService *gmail.Service
-	if httpClient != nil {
-		ctx := context.Background()
-		// Upgrade the HTTP client to a full Gmail Service
-		gmailService, err = gmail.NewService(ctx, option.WithHTTPClient(httpClient))
-		if err != nil {
-			log.Printf("⚠️  Failed to create Gmail Service: %v", err)
-		} else {
-			log.Println("✅ Gmail Service connected successfully.")
+	switch cmd {
+	case "serve":
+		cfg := loadConfig("serve", args)
+		serve(cfg)
+	case "config":
+		if len(args) == 0 || args[0] != "print" {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
 		}
+		cfg := loadConfig("config print", args[1:])
+		cfg.Print(os.Stdout)
+	case "migrate":
+		runMigrate(args)
+	case "users":
+		runUsers(args)
+	case "keys":
+		runKeys(args)
+	case "import":
+		runImport(args)
+	case "reprocess":
+		runReprocess(args)
+	case "push":
+		runPush(args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
 	}
+}
 
-	// 5. Initialize Email Watcher
-	// We pass the gmailService (even if nil, the service handles it gracefully)
-	emailService := services.NewEmailService(db, llmService, gmailService, matcherService)
-	emailService.StartWatcher()
-
-	// 6. Initialize Handlers
-	jobHandler := handlers.NewJobHandler(llmService, jobService)
-
-	// 7. Setup Router & CORS
-	r := gin.Default()
-	config := cors.DefaultConfig()
-	config.AllowAllOrigins = true // For development only
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
-	r.Use(cors.New(config))
-
-	// 8. Define Routes
-	api := r.Group("/api/v1")
-	{
-		api.GET("/health", handlers.HealthCheck)
+// loadConfig loads and validates the config or exits with the reason
+func loadConfig(name string, args []string) *config.Config {
+	cfg, err := config.Load(name, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cfg
+}
 
-		// Job Routes
-		api.POST("/jobs/extract", jobHandler.ParseJob)
-		api.POST("/jobs", jobHandler.CreateJob) 
+// mailCipher seals mailbox credentials in the database (nil when gmail.token_key isn't set)
+func mailCipher(cfg *config.Config) *auth.Cipher {
+	if cfg.Gmail.TokenKey == "" {
+		return nil
+	}
+	c, err := auth.NewCipher(cfg.Gmail.TokenKey)
+	if err != nil {
+		log.Fatal("❌ ", err)
 	}
+	return c
+}
+
+// tokenStore opens the Gmail token store selected by gmail.token_store
+func tokenStore(cfg *config.Config, db *gorm.DB, c *auth.Cipher) (auth.TokenStore, error) {
+	return auth.NewTokenStore(cfg.Gmail.TokenStore, db, c, cfg.Gmail.TokenDir)
+}
 
-	log.Println("🚀 Server starting on port 8080...")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal("Server failed to start:", err)
+// positional splits "a b -flag x" into the leading positional args and the flags
+func positional(args []string) ([]string, []string) {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			return args[:i], args[i:]
+		}
 	}
+	return args, nil
 }
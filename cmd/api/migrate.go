@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/database"
+)
+
+// runMigrate handles "migrate up|down [N]|status [flags]"
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	action, args := args[0], args[1:]
+
+	// 1. "down" takes an optional number of steps before the flags
+	steps := 1
+	if action == "down" && len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			if n < 1 {
+				log.Fatal("migrate down: steps must be at least 1")
+			}
+			steps, args = n, args[1:]
+		}
+	}
+
+	cfg := loadConfig("migrate "+action, args)
+	db := database.Connect(cfg.Database.Driver, cfg.Database.DSN, cfg.Database.MaxOpenConns)
+
+	// 2. Run the action
+	switch action {
+	case "up":
+		ran, err := database.MigrateUp(db)
+		for _, m := range ran {
+			log.Printf("✅ Applied %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		if len(ran) == 0 {
+			log.Println("Schema is up to date.")
+		}
+	case "down":
+		rolledBack, err := database.MigrateDown(db, steps)
+		for _, m := range rolledBack {
+			log.Printf("↩️  Rolled back %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		if len(rolledBack) == 0 {
+			log.Println("Nothing to roll back.")
+		}
+	case "status":
+		statuses, err := database.Status(db)
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		w.Flush()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate action %q\n\n%s", action, usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/auth"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
+)
+
+// runPush handles "push fake <gmail address> <history id> [url] [flags]". It posts what
+// Pub/Sub would for a mailbox change, to try the push endpoint without Google Cloud.
+func runPush(args []string) {
+	if len(args) == 0 || args[0] != "fake" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	pos, args := positional(args[1:])
+	if len(pos) < 2 || len(pos) > 3 {
+		log.Fatal("push fake: expected <gmail address> <history id> [url]")
+	}
+	historyID, err := strconv.ParseUint(pos[1], 10, 64)
+	if err != nil {
+		log.Fatalf("push fake: invalid history id %q", pos[1])
+	}
+
+	cfg := loadConfig("push fake", args)
+	// Only the shared token can be faked, OIDC pushes are signed by Google
+	if cfg.Gmail.PushAuth != auth.PushAuthToken || cfg.Gmail.PushToken == "" {
+		log.Fatal("push fake: needs gmail.push_auth=token and gmail.push_token, the server must use the same")
+	}
+	target := pushURL(cfg.Server.Addr)
+	if len(pos) == 3 {
+		target = pos[2]
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		log.Fatalf("push fake: invalid url %q: %v", target, err)
+	}
+	q := u.Query()
+	q.Set("token", cfg.Gmail.PushToken)
+	u.RawQuery = q.Encode()
+
+	// 1. The same envelope as a Pub/Sub push subscription, data is base64 JSON
+	data, err := json.Marshal(services.PushNotification{EmailAddress: pos[0], HistoryID: historyID})
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"message": map[string]interface{}{
+			"data":        data,
+			"messageId":   strconv.FormatInt(time.Now().UnixNano(), 10),
+			"publishTime": time.Now().UTC().Format(time.RFC3339),
+		},
+		"subscription": "projects/local/subscriptions/fake",
+	})
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+
+	// 2. Send it
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(u.String(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		log.Fatalf("❌ Push rejected: %s", resp.Status)
+	}
+	fmt.Printf("✅ Pushed history %d of %s: %s\n", historyID, pos[0], resp.Status)
+}
+
+// pushURL is the push endpoint of a server listening on addr, e.g. ":8080"
+func pushURL(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	return "http://" + addr + "/api/v1/gmail/push"
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/database"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/dtos"
+)
+
+// runReprocess handles "reprocess <email> [from] [to] [-company <name>] [-job <id>] [-dry-run] [flags]".
+// It classifies the user's stored emails again with the configured LLM, e.g. to try a new
+// prompt or provider with -dry-run before letting it touch the jobs.
+func runReprocess(args []string) {
+	// 1. The filters and -dry-run are ours, the other flags are settings
+	pos, args := positional(args)
+	if len(pos) < 1 || len(pos) > 3 {
+		log.Fatal("reprocess: expected <email> [from] [to], dates as 2006-01-02")
+	}
+	q := &dtos.ReprocessQuery{}
+	var err error
+	if len(pos) > 1 {
+		if q.From, err = time.ParseInLocation(time.DateOnly, pos[1], time.Local); err != nil {
+			log.Fatalf("❌ from: %v", err)
+		}
+	}
+	if len(pos) > 2 {
+		if q.To, err = time.ParseInLocation(time.DateOnly, pos[2], time.Local); err != nil {
+			log.Fatalf("❌ to: %v", err)
+		}
+		if q.To.Before(q.From) {
+			log.Fatal("❌ to must not be before from")
+		}
+	}
+	rest := args[:0]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-dry-run", "--dry-run":
+			q.DryRun = true
+		case "-company", "--company", "-job", "--job":
+			if i+1 == len(args) {
+				log.Fatalf("reprocess: %s needs a value", args[i])
+			}
+			if args[i] == "-company" || args[i] == "--company" {
+				q.Company = args[i+1]
+			} else {
+				id, err := strconv.ParseUint(args[i+1], 10, 64)
+				if err != nil || id == 0 {
+					log.Fatalf("reprocess: invalid job id %q", args[i+1])
+				}
+				q.JobID = uint(id)
+			}
+			i++
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+
+	cfg := loadConfig("reprocess", rest)
+	db := database.Connect(cfg.Database.Driver, cfg.Database.DSN, cfg.Database.MaxOpenConns)
+	if err := database.CheckSchema(db); err != nil {
+		log.Fatal("❌ ", err)
+	}
+	emailService := emailPipeline(cfg, db)
+	user, err := emailService.UserService.GetByEmail(pos[0])
+	if err != nil {
+		log.Fatalf("❌ %s: %v", pos[0], err)
+	}
+
+	// 2. Classify again (Ctrl-C stops after the current email)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	report, err := emailService.Reprocess(ctx, user, q)
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+
+	// 3. Report
+	if report.DryRun {
+		fmt.Printf("Dry run with %s: nothing was written. These emails would be read differently:\n", report.Model)
+	} else {
+		fmt.Printf("Reprocessed with %s. These emails were read differently:\n", report.Model)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EMAIL\tSUBJECT\tBEFORE\tAFTER\tJOB BEFORE\tJOB AFTER")
+	for _, d := range report.Diffs {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", d.EmailID, d.Subject, d.Before.Status, d.After.Status, jobRef(d.Before.JobID), jobRef(d.After.JobID))
+	}
+	w.Flush()
+
+	if report.DryRun {
+		fmt.Println("\nJob statuses that would change:")
+	} else {
+		fmt.Println("\nJob statuses changed:")
+	}
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB\tCOMPANY\tTITLE\tFROM\tTO\tEMAIL")
+	for _, ch := range report.Changes {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", *ch.JobID, ch.Company, ch.JobTitle, ch.FromStatus, ch.ToStatus, ch.Subject)
+	}
+	w.Flush()
+	fmt.Printf("\n%d emails, %d read differently, %d job status changes, %d failed\n",
+		report.Emails, report.Changed, len(report.Changes), report.Failed)
+}
+
+// jobRef prints an optional job ID, "-" for none
+func jobRef(id *uint) string {
+	if id == nil {
+		return "-"
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/auth"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/config"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/database"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/handlers"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/llm"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
+)
+
+func serve(cfg *config.Config) {
+	// Ctrl-C and SIGTERM stop the server, a second one kills it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 1. Database Connection
+	db := database.Connect(cfg.Database.Driver, cfg.Database.DSN, cfg.Database.MaxOpenConns)
+	if err := database.CheckSchema(db); err != nil {
+		log.Fatal("❌ ", err)
+	}
+
+	// 2. Initialize Core Services (Dependencies)
+	provider, err := llm.New(context.Background(), cfg.LLMProvider())
+	if err != nil {
+		log.Fatal("Failed to initialize LLM provider: ", err)
+	}
+	llmService := services.NewLLMService(provider)
+	llmService.MaxAttempts = cfg.LLM.MaxAttempts
+	jobService := services.NewJobService(db)
+	matcherService := services.NewMatcherService(db)
+	reviewService := services.NewReviewService(db, jobService)
+	reviewService.ConfidenceThreshold = cfg.Review.ConfidenceThreshold
+
+	// 3. Initialize Gmail Integration
+	// The client secret is shared, each user connects their own mailbox from the browser
+	log.Println("Initializing Gmail OAuth client...")
+	oauthConfig, err := auth.OAuthConfig(cfg.Gmail.CredentialsFile, cfg.Gmail.RedirectURL)
+	if err != nil {
+		log.Printf("⚠️  Gmail disabled: %v", err)
+	}
+	cipher := mailCipher(cfg)
+	tokens, err := tokenStore(cfg, db, cipher)
+	if err != nil {
+		log.Printf("⚠️  Gmail disabled: %v", err)
+		oauthConfig = nil
+	} else if cfg.Gmail.TokenStore == auth.TokenStoreFile {
+		log.Printf("⚠️  Gmail tokens are stored unencrypted in %s (gmail.token_store=file)", cfg.Gmail.TokenDir)
+	}
+	userService := services.NewUserService(db, tokens)
+
+	// IMAP passwords are sealed with the same key as the tokens
+	var imapAccounts *services.IMAPAccountService
+	if cipher != nil {
+		imapAccounts = services.NewIMAPAccountService(db, cipher)
+	} else {
+		log.Println("⚠️  IMAP disabled: set gmail.token_key to store IMAP passwords.")
+	}
+
+	// 4. Initialize Email Watcher
+	// We pass the oauthConfig (even if nil, the service handles it gracefully)
+	emailService := services.NewEmailService(db, llmService, oauthConfig, userService, matcherService, jobService, reviewService)
+	emailService.PollInterval = cfg.Watcher.Interval
+	emailService.SyncTimeout = cfg.Watcher.SyncTimeout
+	emailService.Workers = cfg.Watcher.Workers
+	emailService.Users = cfg.Watcher.Users
+	emailService.GmailQuota = cfg.Gmail.QuotaPerSecond
+	emailService.IMAPAccounts = imapAccounts
+	emailService.PushTopic = cfg.Gmail.PushTopic
+	emailService.PushFallback = cfg.Watcher.PushFallback
+	emailService.Queue.MaxAttempts = cfg.Watcher.MaxAttempts
+	emailService.Queue.RetryBackoff = cfg.Watcher.RetryBackoff
+	emailService.Queue.Retention = cfg.Watcher.QueueRetention
+	watcherDone := emailService.StartWatcher(ctx)
+
+	// 5. Initialize Handlers
+	authService, err := services.NewAuthService(db, cfg.Auth.SessionSecret, cfg.Auth.SessionTTL)
+	if err != nil {
+		log.Fatal("Failed to initialize auth: ", err)
+	}
+	if cfg.Auth.SessionSecret == "" {
+		log.Println("⚠️  No auth.session_secret set. Web sessions will end when the server restarts.")
+	}
+	authHandler := handlers.NewAuthHandler(authService, cfg.Auth.CookieSecure)
+	jobHandler := handlers.NewJobHandler(llmService, jobService)
+	reviewHandler := handlers.NewReviewHandler(reviewService)
+	userHandler := handlers.NewUserHandler(userService)
+	importHandler := handlers.NewImportHandler(emailService, int64(cfg.Server.MaxUploadMB)<<20)
+	queueHandler := handlers.NewQueueHandler(emailService.Queue)
+	gmailConnectService := services.NewGmailConnectService(db, oauthConfig, userService)
+	gmailHandler := handlers.NewGmailHandler(gmailConnectService, cfg.Auth.CookieSecure, cfg.Gmail.ConnectedURL)
+	var pushHandler *handlers.PushHandler
+	if cfg.Gmail.PushTopic != "" {
+		verifier, err := auth.NewPushVerifier(cfg.Gmail.PushAuth, cfg.Gmail.PushAudience, cfg.Gmail.PushServiceAccount, cfg.Gmail.PushToken)
+		if err != nil {
+			log.Fatal("Failed to initialize Gmail push: ", err)
+		}
+		pushHandler = handlers.NewPushHandler(emailService, verifier)
+		log.Printf("📡 Gmail push notifications on %s, polling Gmail every %s as a fallback", cfg.Gmail.PushTopic, cfg.Watcher.PushFallback)
+	}
+
+	// 6. Setup Router & CORS
+	// Only the configured origins may call the API from a browser, with the session cookie
+	r := gin.Default()
+	if len(cfg.Server.CORSOrigins) > 0 {
+		corsConfig := cors.DefaultConfig()
+		corsConfig.AllowOrigins = cfg.Server.CORSOrigins
+		corsConfig.AllowCredentials = true
+		corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
+		r.Use(cors.New(corsConfig))
+	}
+
+	// 7. Define Routes
+	api := r.Group("/api/v1")
+	{
+		api.GET("/health", handlers.HealthCheck)
+		api.GET("/statuses", handlers.ListStatuses)
+
+		// Web UI login with an API key, logout
+		api.POST("/auth/session", authHandler.Login)
+		api.DELETE("/auth/session", authHandler.Logout)
+
+		// Google sends the browser back here, the state identifies the user
+		api.GET("/auth/gmail/callback", gmailHandler.Callback)
+
+		// Pub/Sub pushes Gmail's mailbox changes here, the verifier checks it's our subscription
+		if pushHandler != nil {
+			api.POST("/gmail/push", pushHandler.Push)
+		}
+	}
+
+	// Everything below needs an API key or a session, acts as its user and only sees that user's data
+	api = api.Group("", authHandler.Authenticate())
+	{
+		api.GET("/me", userHandler.GetMe)
+
+		// API Key Routes
+		keys := api.Group("", handlers.RequireScope(services.ScopeKeysManage))
+		keys.GET("/keys", authHandler.ListKeys)
+		keys.POST("/keys", authHandler.CreateKey)
+		keys.DELETE("/keys/:id", authHandler.RevokeKey)
+
+		// Gmail Connection Routes
+		gmail := api.Group("", handlers.RequireScope(services.ScopeGmailManage))
+		gmail.GET("/auth/gmail/connect", gmailHandler.Connect)
+		gmail.GET("/auth/gmail/status", gmailHandler.Status)
+		gmail.DELETE("/auth/gmail", gmailHandler.Disconnect)
+		gmail.GET("/sync/settings", userHandler.GetSyncSettings)
+		gmail.PUT("/sync/settings", userHandler.UpdateSyncSettings)
+		gmail.GET("/sync/failed", queueHandler.ListFailed)
+		gmail.POST("/sync/failed/requeue", queueHandler.RequeueAll)
+		gmail.POST("/sync/failed/:id/requeue", queueHandler.Requeue)
+
+		// Job Routes
+		jobsRead := api.Group("", handlers.RequireScope(services.ScopeJobsRead))
+		jobsRead.GET("/jobs", jobHandler.ListJobs)
+		jobsRead.GET("/jobs/:id", jobHandler.GetJob)
+		jobsRead.GET("/jobs/:id/timeline", jobHandler.GetTimeline)
+		jobsRead.GET("/jobs/:id/emails", jobHandler.GetJobEmails)
+		jobsRead.GET("/emails/:id", jobHandler.GetEmail)
+		jobsRead.GET("/technologies", jobHandler.ListTechnologies)
+
+		jobsWrite := api.Group("", handlers.RequireScope(services.ScopeJobsWrite))
+		jobsWrite.POST("/jobs/extract", jobHandler.ParseJob)
+		jobsWrite.POST("/jobs", jobHandler.CreateJob)
+		jobsWrite.PATCH("/jobs/:id", jobHandler.UpdateJob)
+		jobsWrite.DELETE("/jobs/:id", jobHandler.DeleteJob)
+		jobsWrite.POST("/jobs/:id/status", jobHandler.ChangeStatus)
+		jobsWrite.POST("/jobs/:id/notes", jobHandler.AddNote)
+		jobsWrite.POST("/import/mail", importHandler.ImportMail)
+		jobsWrite.POST("/sync/backfill", importHandler.Backfill)
+
+		// Classifying stored emails again can move many jobs, it has its own scope
+		reprocess := api.Group("", handlers.RequireScope(services.ScopeEmailsReprocess))
+		reprocess.POST("/emails/reprocess", importHandler.Reprocess)
+
+		// Review Queue Routes
+		reviewsRead := api.Group("", handlers.RequireScope(services.ScopeReviewsRead))
+		reviewsRead.GET("/reviews", reviewHandler.ListReviews)
+
+		reviewsWrite := api.Group("", handlers.RequireScope(services.ScopeReviewsWrite))
+		reviewsWrite.POST("/reviews/:id/approve", reviewHandler.ApproveReview)
+		reviewsWrite.POST("/reviews/:id/dismiss", reviewHandler.DismissReview)
+	}
+
+	srv := &http.Server{Addr: cfg.Server.Addr, Handler: r}
+	go func() {
+		log.Printf("🚀 Server starting on %s...", cfg.Server.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Server failed to start:", err)
+		}
+	}()
+
+	// 8. Graceful Shutdown
+	// The signal has cancelled the syncs already, requests in flight may finish
+	<-ctx.Done()
+	stop()
+	log.Printf("🛑 Shutting down, waiting up to %s for requests and syncs...", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️  Requests still running, closing them: %v", err)
+		srv.Close()
+	}
+	select {
+	case <-watcherDone:
+	case <-shutdownCtx.Done():
+		log.Println("⚠️  Email syncs still running, stopping anyway.")
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+	log.Println("👋 Server stopped.")
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/auth"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/database"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
+	"golang.org/x/oauth2"
+)
+
+// runUsers handles "users list|add <email>|connect <email>|imap <email> <server> <login> [mailbox]|imap-remove <email> [flags]"
+func runUsers(args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	action, args := args[0], args[1:]
+
+	// 1. Everything but list takes the email (and imap its server) before the flags
+	pos, args := positional(args)
+	email := ""
+	switch {
+	case action == "list":
+	case action == "imap" && (len(pos) == 3 || len(pos) == 4):
+		email = pos[0]
+	case action == "imap":
+		log.Fatal("users imap: expected <email> <server> <login> [mailbox]")
+	case len(pos) != 1:
+		log.Fatalf("users %s: expected <email>", action)
+	default:
+		email = pos[0]
+	}
+
+	cfg := loadConfig("users "+action, args)
+	db := database.Connect(cfg.Database.Driver, cfg.Database.DSN, cfg.Database.MaxOpenConns)
+	if err := database.CheckSchema(db); err != nil {
+		log.Fatal("❌ ", err)
+	}
+	cipher := mailCipher(cfg)
+	tokens, err := tokenStore(cfg, db, cipher)
+	if err != nil && action == "connect" {
+		log.Fatal("❌ ", err)
+	}
+	users := services.NewUserService(db, tokens)
+
+	// 2. Run the action
+	switch action {
+	case "list":
+		list, err := users.List()
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tEMAIL\tGMAIL")
+		for _, u := range list {
+			connected := "not connected"
+			if users.GmailConnected(context.Background(), u.ID) {
+				connected = "connected"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", u.ID, u.Email, connected)
+		}
+		w.Flush()
+	case "add":
+		user, err := users.Create(email)
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		log.Printf("✅ User %s has ID %d", user.Email, user.ID)
+	case "connect":
+		user, err := users.GetByEmail(email)
+		if err != nil {
+			log.Fatalf("❌ %s: %v (add it with `api users add %s`)", email, err, email)
+		}
+		tok, err := connectToken(cfg.Gmail.TokenFile)
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		if err := users.SaveToken(context.Background(), user.ID, tok); err != nil {
+			log.Fatal("❌ ", err)
+		}
+		log.Printf("✅ Gmail connected for %s", user.Email)
+	case "imap":
+		user, err := users.GetByEmail(email)
+		if err != nil {
+			log.Fatalf("❌ %s: %v (add it with `api users add %s`)", email, err, email)
+		}
+		if cipher == nil {
+			log.Fatal("❌ ", auth.ErrNoTokenKey)
+		}
+		account := imapAccount(pos[1:])
+		password, err := imapPassword()
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := services.NewIMAPAccountService(db, cipher).Set(ctx, user.ID, account, password); err != nil {
+			log.Fatal("❌ ", err)
+		}
+		log.Printf("✅ IMAP mailbox %s on %s connected for %s", account.Mailbox, account.Addr, user.Email)
+	case "imap-remove":
+		user, err := users.GetByEmail(email)
+		if err != nil {
+			log.Fatalf("❌ %s: %v", email, err)
+		}
+		if err := services.NewIMAPAccountService(db, cipher).Delete(user.ID); err != nil {
+			log.Fatal("❌ ", err)
+		}
+		log.Printf("✅ IMAP mailbox removed for %s", user.Email)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown users action %q\n\n%s", action, usage)
+		os.Exit(2)
+	}
+}
+
+// connectToken imports the token file of a single-user install. New mailboxes are
+// connected in the browser through /api/v1/auth/gmail/connect.
+func connectToken(tokenFile string) (*oauth2.Token, error) {
+	tok, err := auth.TokenFromFile(tokenFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no token file at %s: connect Gmail from the browser with GET /api/v1/auth/gmail/connect instead", tokenFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", tokenFile, err)
+	}
+	log.Printf("Importing existing token from %s", tokenFile)
+	return tok, nil
+}
+
+// imapAccount reads "<server> <login> [mailbox]". The server is host[:port] (implicit TLS,
+// port 993 by default), or imap://host:port for a plaintext local server.
+func imapAccount(args []string) *models.IMAPAccount {
+	account := &models.IMAPAccount{TLS: true, Username: args[1], Mailbox: "INBOX"}
+	if len(args) == 3 {
+		account.Mailbox = args[2]
+	}
+
+	server := args[0]
+	if rest, ok := strings.CutPrefix(server, "imap://"); ok {
+		server, account.TLS = rest, false
+	} else {
+		server = strings.TrimPrefix(server, "imaps://")
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		port := "993"
+		if !account.TLS {
+			port = "143"
+		}
+		server = net.JoinHostPort(server, port)
+	}
+	account.Addr = server
+	return account
+}
+
+// imapPassword takes the password from $IMAP_PASSWORD, so it stays out of the shell history,
+// or reads it from stdin
+func imapPassword() (string, error) {
+	if password := os.Getenv("IMAP_PASSWORD"); password != "" {
+		return password, nil
+	}
+	fmt.Fprint(os.Stderr, "IMAP password (or app password): ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("reading password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// sealedPrefix marks a value sealed by Cipher. Values without it are plaintext
+// from before secrets were encrypted.
+const sealedPrefix = "enc:v1:"
+
+var (
+	ErrNoTokenKey = errors.New("gmail.token_key must be set (at least 32 characters) to store mailbox credentials in the database")
+	ErrNotSealed  = errors.New("value is not encrypted")
+)
+
+// Cipher encrypts the mailbox credentials kept in the database (Gmail tokens, IMAP passwords)
+// with AES-256-GCM. The key is derived from gmail.token_key.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+func NewCipher(key string) (*Cipher, error) {
+	if len(key) < 32 {
+		return nil, ErrNoTokenKey
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Seal encrypts plain. The additional data binds it to its owner (e.g. "user:1"),
+// so sealed values can't be swapped between rows.
+func (c *Cipher) Seal(plain []byte, additionalData string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, plain, []byte(additionalData))
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value from Seal. It returns ErrNotSealed for plaintext values.
+func (c *Cipher) Open(value, additionalData string) ([]byte, error) {
+	sealed, ok := strings.CutPrefix(value, sealedPrefix)
+	if !ok {
+		return nil, ErrNotSealed
+	}
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < c.aead.NonceSize() {
+		return nil, errors.New("encrypted value is corrupt")
+	}
+	nonce, ciphertext := raw[:c.aead.NonceSize()], raw[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, ciphertext, []byte(additionalData))
+	if err != nil {
+		return nil, errors.New("encrypted value can't be decrypted, was gmail.token_key changed?")
+	}
+	return plain, nil
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/api/idtoken"
+)
+
+// Ways a Pub/Sub push request can prove it comes from our subscription (gmail.push_auth)
+const (
+	// PushAuthOIDC checks the Google-signed JWT Pub/Sub sends when the subscription has
+	// authentication enabled: signature, audience and, if set, the service account
+	PushAuthOIDC = "oidc"
+	// PushAuthToken checks a shared secret in the push URL (?token=...), for local testing
+	// with a fake sender or setups without an authenticated subscription
+	PushAuthToken = "token"
+)
+
+var (
+	// ErrPushUnauthorized is a push without credentials, or with ones that don't check out
+	ErrPushUnauthorized = errors.New("push request is not from our Pub/Sub subscription")
+	// ErrPushForbidden is a push Google signed for another audience or service account
+	ErrPushForbidden = errors.New("push request is signed for another subscription")
+)
+
+// PushVerifier authenticates a Pub/Sub push request
+type PushVerifier interface {
+	Verify(r *http.Request) error
+}
+
+// NewPushVerifier builds the verifier for gmail.push_auth
+func NewPushVerifier(mode, audience, serviceAccount, token string) (PushVerifier, error) {
+	switch mode {
+	case PushAuthOIDC:
+		if audience == "" {
+			return nil, errors.New("gmail.push_audience is required to verify push JWTs")
+		}
+		return &OIDCPushVerifier{Audience: audience, ServiceAccount: serviceAccount}, nil
+	case PushAuthToken:
+		if token == "" {
+			return nil, errors.New("gmail.push_token is required for token push auth")
+		}
+		return &TokenPushVerifier{Token: token}, nil
+	default:
+		return nil, fmt.Errorf("unknown push auth %q (want %s or %s)", mode, PushAuthOIDC, PushAuthToken)
+	}
+}
+
+// OIDCPushVerifier validates the "Authorization: Bearer <JWT>" of an authenticated push subscription
+type OIDCPushVerifier struct {
+	Audience       string // The audience set on the subscription, usually the push URL
+	ServiceAccount string // Email of the subscription's service account ("" = any)
+
+	// Validate checks the JWT's signature and expiry, and its audience unless that's "".
+	// nil = idtoken.Validate against Google's keys.
+	Validate func(ctx context.Context, token, audience string) (*idtoken.Payload, error)
+}
+
+func (v *OIDCPushVerifier) Verify(r *http.Request) error {
+	raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ErrPushUnauthorized
+	}
+	validate := v.Validate
+	if validate == nil {
+		validate = idtoken.Validate
+	}
+
+	// 1. Google's signature and expiry: is it a token at all
+	payload, err := validate(r.Context(), raw, "")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPushUnauthorized, err)
+	}
+
+	// 2. Audience and signer: is it a token for us
+	if payload.Audience != v.Audience {
+		return fmt.Errorf("%w: audience %q", ErrPushForbidden, payload.Audience)
+	}
+	if v.ServiceAccount != "" {
+		email, _ := payload.Claims["email"].(string)
+		verified, _ := payload.Claims["email_verified"].(bool)
+		if !verified || email != v.ServiceAccount {
+			return fmt.Errorf("%w: signed by %q", ErrPushForbidden, email)
+		}
+	}
+	return nil
+}
+
+// TokenPushVerifier compares the ?token= of the push URL with a shared secret
+type TokenPushVerifier struct {
+	Token string
+}
+
+func (v *TokenPushVerifier) Verify(r *http.Request) error {
+	if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(v.Token)) != 1 {
+		return ErrPushUnauthorized
+	}
+	return nil
+}
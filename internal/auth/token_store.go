@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+// Token stores understood by NewTokenStore
+const (
+	TokenStoreDB   = "db"
+	TokenStoreFile = "file"
+)
+
+var ErrNoToken = errors.New("no Gmail token stored for this user")
+
+// TokenStore keeps each user's Gmail OAuth token
+type TokenStore interface {
+	// Load returns ErrNoToken when the user hasn't connected a mailbox
+	Load(ctx context.Context, userID uint) (*oauth2.Token, error)
+	Save(ctx context.Context, userID uint, tok *oauth2.Token) error
+	Delete(ctx context.Context, userID uint) error
+	// UserIDs lists the users that have a token
+	UserIDs(ctx context.Context) ([]uint, error)
+}
+
+// NewTokenStore builds the store selected by gmail.token_store.
+// The db store needs the cipher, nil means no gmail.token_key is set.
+func NewTokenStore(kind string, db *gorm.DB, c *Cipher, dir string) (TokenStore, error) {
+	switch kind {
+	case TokenStoreDB:
+		if c == nil {
+			return nil, ErrNoTokenKey
+		}
+		return NewDBTokenStore(db, c), nil
+	case TokenStoreFile:
+		return NewFileTokenStore(dir), nil
+	default:
+		return nil, fmt.Errorf("unknown token store %q", kind)
+	}
+}
+
+// TokenSource loads the user's token and returns a source that refreshes it when it expires.
+// Refreshed tokens are written back to the store, so a rotated refresh token is never lost.
+func TokenSource(ctx context.Context, config *oauth2.Config, store TokenStore, userID uint) (oauth2.TokenSource, error) {
+	tok, err := store.Load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &persistingSource{
+		ctx:    ctx,
+		base:   config.TokenSource(ctx, tok),
+		store:  store,
+		userID: userID,
+		last:   tok,
+	}, nil
+}
+
+// Client is an HTTP client authorized as the user, see TokenSource
+func Client(ctx context.Context, config *oauth2.Config, store TokenStore, userID uint) (*http.Client, error) {
+	ts, err := TokenSource(ctx, config, store, userID)
+	if err != nil {
+		return nil, err
+	}
+	return oauth2.NewClient(ctx, ts), nil
+}
+
+// persistingSource saves every token its base source hands out that it hasn't seen yet
+type persistingSource struct {
+	ctx    context.Context
+	base   oauth2.TokenSource
+	store  TokenStore
+	userID uint
+
+	mu   sync.Mutex
+	last *oauth2.Token
+}
+
+func (p *persistingSource) Token() (*oauth2.Token, error) {
+	tok, err := p.base.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.last != nil && p.last.AccessToken == tok.AccessToken {
+		return tok, nil
+	}
+	// The request can go ahead with the new token even if saving it failed,
+	// the next refresh tries again
+	if err := p.store.Save(p.ctx, p.userID, tok); err != nil {
+		log.Printf("⚠️ Could not save refreshed Gmail token for user %d: %v", p.userID, err)
+		return tok, nil
+	}
+	p.last = tok
+	return tok, nil
+}
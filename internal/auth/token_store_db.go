@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+// DBTokenStore keeps tokens encrypted in users.gmail_token
+type DBTokenStore struct {
+	DB     *gorm.DB
+	Cipher *Cipher
+}
+
+func NewDBTokenStore(db *gorm.DB, c *Cipher) *DBTokenStore {
+	return &DBTokenStore{DB: db, Cipher: c}
+}
+
+func (s *DBTokenStore) Load(ctx context.Context, userID uint) (*oauth2.Token, error) {
+	var user models.User
+	err := s.DB.WithContext(ctx).Select("id", "gmail_token").First(&user, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.GmailToken == "" {
+		return nil, ErrNoToken
+	}
+
+	// 1. Decrypt, tokens saved before encryption are upgraded the first time they're read
+	plain, err := s.Cipher.Open(user.GmailToken, tokenOwner(userID))
+	legacy := errors.Is(err, ErrNotSealed)
+	if legacy {
+		plain = []byte(user.GmailToken)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var tok oauth2.Token
+	if err := json.Unmarshal(plain, &tok); err != nil {
+		return nil, fmt.Errorf("decoding stored token: %w", err)
+	}
+	if legacy {
+		if err := s.Save(ctx, userID, &tok); err != nil {
+			log.Printf("⚠️ Could not encrypt plaintext Gmail token of user %d: %v", userID, err)
+		}
+	}
+	return &tok, nil
+}
+
+func (s *DBTokenStore) Save(ctx context.Context, userID uint, tok *oauth2.Token) error {
+	plain, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	value, err := s.Cipher.Seal(plain, tokenOwner(userID))
+	if err != nil {
+		return err
+	}
+	return s.DB.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("gmail_token", value).Error
+}
+
+func (s *DBTokenStore) Delete(ctx context.Context, userID uint) error {
+	return s.DB.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("gmail_token", "").Error
+}
+
+func (s *DBTokenStore) UserIDs(ctx context.Context) ([]uint, error) {
+	ids := []uint{}
+	err := s.DB.WithContext(ctx).Model(&models.User{}).Where("gmail_token <> ''").Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+func tokenOwner(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
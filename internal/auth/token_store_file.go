@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// FileTokenStore keeps one plaintext <user id>.json per user in a directory.
+// It's meant for development, use DBTokenStore in production.
+type FileTokenStore struct {
+	Dir string
+}
+
+func NewFileTokenStore(dir string) *FileTokenStore {
+	return &FileTokenStore{Dir: dir}
+}
+
+func (s *FileTokenStore) path(userID uint) string {
+	return filepath.Join(s.Dir, fmt.Sprintf("%d.json", userID))
+}
+
+func (s *FileTokenStore) Load(_ context.Context, userID uint) (*oauth2.Token, error) {
+	tok, err := TokenFromFile(s.path(userID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNoToken
+	}
+	return tok, err
+}
+
+// Save writes through a temp file so a crash never leaves half a token behind
+func (s *FileTokenStore) Save(_ context.Context, userID uint, tok *oauth2.Token) error {
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.Dir, ".token-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path(userID))
+}
+
+func (s *FileTokenStore) Delete(_ context.Context, userID uint) error {
+	err := os.Remove(s.path(userID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *FileTokenStore) UserIDs(_ context.Context) ([]uint, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []uint{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ids := []uint{}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		if id, err := strconv.ParseUint(name, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
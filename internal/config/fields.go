@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const redacted = "********"
+
+// field is one setting: its file key, env var, doc string and where it lives in Config.
+// Keeping them in one table means the file, env, flags and `config print` can't drift apart.
+type field struct {
+	key    string // "server.addr" in the config file, -server.addr on the command line
+	env    string
+	usage  string
+	secret bool
+	ptr    interface{} // *string, *[]string, *int, *bool, *float64 or *time.Duration
+}
+
+func (c *Config) fields() []field {
+	return []field{
+		{"server.addr", "SERVER_ADDR", "HTTP listen address", false, &c.Server.Addr},
+		{"server.cors_origins", "SERVER_CORS_ORIGINS", "Comma-separated browser origins allowed to call the API, e.g. http://localhost:3000", false, &c.Server.CORSOrigins},
+		{"server.shutdown_timeout", "SERVER_SHUTDOWN_TIMEOUT", "How long SIGTERM waits for requests and syncs in flight before stopping", false, &c.Server.ShutdownTimeout},
+		{"server.max_upload_mb", "SERVER_MAX_UPLOAD_MB", "Largest mail archive upload /import/mail accepts, in MB", false, &c.Server.MaxUploadMB},
+
+		{"database.driver", "DATABASE_DRIVER", "postgres | sqlite", false, &c.Database.Driver},
+		{"database.dsn", "DATABASE_DSN", "Postgres connection string or SQLite file path (empty = local default for the driver)", true, &c.Database.DSN},
+		{"database.max_open_conns", "DATABASE_MAX_OPEN_CONNS", "Connections the Postgres pool may open (0 = unlimited)", false, &c.Database.MaxOpenConns},
+
+		{"llm.provider", "LLM_PROVIDER", "gemini | openai | ollama | rules | replay (empty = gemini if a key is set, else rules)", false, &c.LLM.Provider},
+		{"llm.model", "LLM_MODEL", "Model name (empty = provider default)", false, &c.LLM.Model},
+		{"llm.api_key", "LLM_API_KEY", "API key (falls back to GEMINI_API_KEY / OPENAI_API_KEY)", true, &c.LLM.APIKey},
+		{"llm.base_url", "LLM_BASE_URL", "Base URL for OpenAI-compatible servers and Ollama", false, &c.LLM.BaseURL},
+		{"llm.fixtures_dir", "LLM_FIXTURES_DIR", "Directory of recorded responses for the replay provider", false, &c.LLM.FixturesDir},
+		{"llm.record_fixtures", "LLM_RECORD_FIXTURES", "Record live responses into llm.fixtures_dir", false, &c.LLM.RecordFixtures},
+		{"llm.max_attempts", "LLM_MAX_ATTEMPTS", "Attempts per task before giving up on invalid output", false, &c.LLM.MaxAttempts},
+		{"llm.requests_per_minute", "LLM_REQUESTS_PER_MINUTE", "Requests per minute the provider allows (0 = unlimited)", false, &c.LLM.RequestsPerMinute},
+		{"llm.tokens_per_minute", "LLM_TOKENS_PER_MINUTE", "Tokens per minute the provider allows, estimated from text length (0 = unlimited)", false, &c.LLM.TokensPerMinute},
+
+		{"gmail.credentials_file", "GMAIL_CREDENTIALS_FILE", "OAuth client secret downloaded from Google Cloud", false, &c.Gmail.CredentialsFile},
+		{"gmail.token_file", "GMAIL_TOKEN_FILE", "Token of a single-user install, imported by `users connect`", false, &c.Gmail.TokenFile},
+		{"gmail.redirect_url", "GMAIL_REDIRECT_URL", "OAuth callback URL, must be registered on the client", false, &c.Gmail.RedirectURL},
+		{"gmail.connected_url", "GMAIL_CONNECTED_URL", "Page the browser returns to after connecting Gmail (empty = JSON)", false, &c.Gmail.ConnectedURL},
+		{"gmail.token_store", "GMAIL_TOKEN_STORE", "db (encrypted) | file (plaintext, development only)", false, &c.Gmail.TokenStore},
+		{"gmail.token_key", "GMAIL_TOKEN_KEY", "Key that encrypts Gmail tokens and IMAP passwords in the database, at least 32 characters", true, &c.Gmail.TokenKey},
+		{"gmail.token_dir", "GMAIL_TOKEN_DIR", "Directory of the file token store", false, &c.Gmail.TokenDir},
+		{"gmail.quota_per_second", "GMAIL_QUOTA_PER_SECOND", "Gmail API quota units one user's sync may use per second (Gmail allows 250)", false, &c.Gmail.QuotaPerSecond},
+		{"gmail.push_topic", "GMAIL_PUSH_TOPIC", "Pub/Sub topic for Gmail push notifications, projects/<project>/topics/<topic> (empty = polling only)", false, &c.Gmail.PushTopic},
+		{"gmail.push_auth", "GMAIL_PUSH_AUTH", "How push requests are verified: oidc (JWT of an authenticated subscription) | token (?token= in the push URL)", false, &c.Gmail.PushAuth},
+		{"gmail.push_audience", "GMAIL_PUSH_AUDIENCE", "Audience of the push subscription's JWTs, usually the push URL", false, &c.Gmail.PushAudience},
+		{"gmail.push_service_account", "GMAIL_PUSH_SERVICE_ACCOUNT", "Service account the push subscription signs with (empty = any)", false, &c.Gmail.PushServiceAccount},
+		{"gmail.push_token", "GMAIL_PUSH_TOKEN", "Shared secret of token push auth, the subscription pushes to .../gmail/push?token=<it>", true, &c.Gmail.PushToken},
+
+		{"watcher.interval", "WATCHER_INTERVAL", "How often the inbox is polled", false, &c.Watcher.Interval},
+		{"watcher.sync_timeout", "WATCHER_SYNC_TIMEOUT", "Upper bound for one sync cycle, unfinished mail is resumed in the next one", false, &c.Watcher.SyncTimeout},
+		{"watcher.workers", "WATCHER_WORKERS", "Emails of one mailbox fetched and processed in parallel", false, &c.Watcher.Workers},
+		{"watcher.users", "WATCHER_USERS", "Users whose mail is synced in parallel, below database.max_open_conns on Postgres", false, &c.Watcher.Users},
+		{"watcher.push_fallback_interval", "WATCHER_PUSH_FALLBACK_INTERVAL", "How often Gmail is still polled when push notifications are on", false, &c.Watcher.PushFallback},
+		{"watcher.max_attempts", "WATCHER_MAX_ATTEMPTS", "Tries at an email before it's given up on and listed under /sync/failed", false, &c.Watcher.MaxAttempts},
+		{"watcher.retry_backoff", "WATCHER_RETRY_BACKOFF", "Wait before retrying a failed email, doubling with every attempt (up to 6h)", false, &c.Watcher.RetryBackoff},
+		{"watcher.queue_retention", "WATCHER_QUEUE_RETENTION", "How long processed emails are kept in the queue (0 = forever)", false, &c.Watcher.QueueRetention},
+
+		{"review.confidence_threshold", "REVIEW_CONFIDENCE_THRESHOLD", "LLM verdicts below this confidence go to the review queue", false, &c.Review.ConfidenceThreshold},
+
+		{"auth.session_secret", "AUTH_SESSION_SECRET", "Key that signs session cookies, at least 32 characters (empty = random, sessions end on restart)", true, &c.Auth.SessionSecret},
+		{"auth.session_ttl", "AUTH_SESSION_TTL", "How long a web session lasts", false, &c.Auth.SessionTTL},
+		{"auth.cookie_secure", "AUTH_COOKIE_SECURE", "Send the session cookie over HTTPS only", false, &c.Auth.CookieSecure},
+	}
+}
+
+// set parses a raw string (from a file, env var or flag) into the field
+func (f field) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+	var err error
+	switch p := f.ptr.(type) {
+	case *string:
+		*p = raw
+	case *[]string:
+		*p = splitList(raw)
+	case *int:
+		*p, err = strconv.Atoi(raw)
+	case *bool:
+		*p, err = strconv.ParseBool(raw)
+	case *float64:
+		*p, err = strconv.ParseFloat(raw, 64)
+	case *time.Duration:
+		*p, err = time.ParseDuration(raw)
+	default:
+		err = fmt.Errorf("unsupported type %T", f.ptr)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: invalid value %q: %w", f.key, raw, err)
+	}
+	return nil
+}
+
+// display renders the current value, hiding secrets
+func (f field) display() string {
+	var v string
+	switch p := f.ptr.(type) {
+	case *string:
+		v = *p
+	case *[]string:
+		v = strings.Join(*p, ",")
+	case *int:
+		v = strconv.Itoa(*p)
+	case *bool:
+		v = strconv.FormatBool(*p)
+	case *float64:
+		v = strconv.FormatFloat(*p, 'f', -1, 64)
+	case *time.Duration:
+		v = p.String()
+	}
+	if f.secret && v != "" {
+		if f.key == "database.dsn" {
+			return redactDSN(v)
+		}
+		return redacted
+	}
+	return v
+}
+
+// splitList parses "a, b,,c" into ["a", "b", "c"]
+func splitList(raw string) []string {
+	list := []string{}
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
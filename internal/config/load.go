@@ -0,0 +1,185 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/joho/godotenv"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/llm"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Load builds the effective config for a command.
+// args are the command's flags, e.g. ["-config", "tracker.yaml", "-server.addr", ":9090"].
+// The config file can also be given with CONFIG_FILE.
+func Load(name string, args []string) (*Config, error) {
+	// 1. .env is optional: real deployments set the environment directly
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("⚠️  Could not read .env file: %v", err)
+	}
+
+	cfg := Default()
+	fields := cfg.fields()
+
+	// 2. Parse flags first (to find -config) but apply them last so they win
+	overrides := map[string]string{}
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "Path to a YAML or TOML config file")
+	for _, f := range fields {
+		f := f
+		flags.Func(f.key, fmt.Sprintf("%s (env %s, default %q)", f.usage, f.env, f.display()), func(v string) error {
+			overrides[f.key] = v
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	// 3. Config file
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	// 4. Environment
+	for _, f := range fields {
+		if v, ok := os.LookupEnv(f.env); ok {
+			if err := f.set(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 5. Flags
+	for _, f := range fields {
+		if v, ok := overrides[f.key]; ok {
+			if err := f.set(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	cfg.resolveDatabase()
+	cfg.resolveLLM()
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// loadFile reads a YAML (.yaml/.yml) or TOML (.toml) file with sections matching the keys, e.g.
+//
+//	server:
+//	  addr: ":9090"
+func (c *Config) loadFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	doc := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &doc)
+	case ".toml":
+		err = toml.Unmarshal(b, &doc)
+	default:
+		return fmt.Errorf("config file %s: unsupported extension (use .yaml, .yml or .toml)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	// Flatten {"server": {"addr": ":9090"}} into {"server.addr": ":9090"}
+	flat := map[string]string{}
+	flatten("", doc, flat)
+
+	byKey := map[string]field{}
+	for _, f := range c.fields() {
+		byKey[f.key] = f
+	}
+	for key, v := range flat {
+		f, ok := byKey[key]
+		if !ok {
+			return fmt.Errorf("config file %s: unknown setting %q", path, key)
+		}
+		if err := f.set(v); err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// flatten skips empty values ("dsn:" in YAML is nil): they leave the setting to the
+// default, the environment and the flags instead of setting it to "<nil>"
+func flatten(prefix string, v interface{}, out map[string]string) {
+	switch m := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, child := range m {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flatten(key, child, out)
+		}
+	case []interface{}:
+		// Lists become "a,b,c", the same syntax as env vars and flags
+		items := make([]string, 0, len(m))
+		for _, item := range m {
+			if item != nil {
+				items = append(items, fmt.Sprint(item))
+			}
+		}
+		out[prefix] = strings.Join(items, ",")
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+}
+
+// resolveLLM applies the LLM defaults that depend on other values:
+// the provider is picked from the available key, and the legacy per-vendor key names still work
+func (c *Config) resolveLLM() {
+	c.LLM.Provider = strings.ToLower(c.LLM.Provider)
+	if c.LLM.APIKey == "" {
+		switch c.LLM.Provider {
+		case llm.ProviderGemini, "":
+			c.LLM.APIKey = os.Getenv("GEMINI_API_KEY")
+		case llm.ProviderOpenAI:
+			c.LLM.APIKey = os.Getenv("OPENAI_API_KEY")
+		}
+	}
+	if c.LLM.Provider == "" {
+		c.LLM.Provider = llm.ProviderGemini
+		if c.LLM.APIKey == "" {
+			log.Println("⚠️  No LLM provider and no GEMINI_API_KEY set. Falling back to the offline rules provider.")
+			c.LLM.Provider = llm.ProviderRules
+		}
+	}
+}
+
+// Print writes the effective config, one "key = value  # ENV" line per setting, secrets redacted
+func (c *Config) Print(w io.Writer) {
+	fmt.Fprintln(w, "# Effective configuration (secrets redacted)")
+	section := ""
+	for _, f := range c.fields() {
+		if s := strings.SplitN(f.key, ".", 2)[0]; s != section {
+			section = s
+			fmt.Fprintf(w, "\n[%s]\n", section)
+		}
+		fmt.Fprintf(w, "%-28s = %-40q # %s\n", f.key, f.display(), f.env)
+	}
+}
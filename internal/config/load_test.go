@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// isolate unsets every variable Load reads, so the test sees only what it sets
+func isolate(t *testing.T) {
+	t.Helper()
+	vars := []string{"CONFIG_FILE", "GEMINI_API_KEY", "OPENAI_API_KEY"}
+	for _, f := range Default().fields() {
+		vars = append(vars, f.env)
+	}
+	for _, v := range vars {
+		t.Setenv(v, "") // Restored after the test
+		os.Unsetenv(v)
+	}
+	t.Chdir(t.TempDir()) // No .env
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := "server:\n  addr: \":7001\"\nwatcher:\n  workers: 2\n"
+	tests := []struct {
+		name     string
+		file     bool
+		env      map[string]string
+		args     []string
+		wantAddr string
+	}{
+		{name: "defaults", wantAddr: ":8080"},
+		{name: "file over defaults", file: true, wantAddr: ":7001"},
+		{name: "env over file", file: true, env: map[string]string{"SERVER_ADDR": ":7002"}, wantAddr: ":7002"},
+		{name: "flags over env", file: true, env: map[string]string{"SERVER_ADDR": ":7002"}, args: []string{"-server.addr", ":7003"}, wantAddr: ":7003"},
+		{name: "file from the env", env: map[string]string{"CONFIG_FILE": "tracker.yaml"}, wantAddr: ":7001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			path := writeConfig(t, "tracker.yaml", file)
+			t.Chdir(filepath.Dir(path))
+			args := tt.args
+			if tt.file {
+				args = append([]string{"-config", path}, args...)
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := Load("test", args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Server.Addr != tt.wantAddr {
+				t.Errorf("server.addr %q, want %q", cfg.Server.Addr, tt.wantAddr)
+			}
+			// Settings no layer mentions keep their default
+			if cfg.Watcher.Interval != Default().Watcher.Interval {
+				t.Errorf("watcher.interval %v", cfg.Watcher.Interval)
+			}
+		})
+	}
+}
+
+func TestLoadEmptyKeys(t *testing.T) {
+	yamlFile := "gmail:\ndatabase:\n  driver: sqlite\n  dsn:\n  max_open_conns: ~\nserver:\n  cors_origins: [\"https://a.example\", ~]\n"
+	tomlFile := "[server]\n[database]\ndriver = \"sqlite\"\ndsn = \"\"\n"
+
+	t.Run("yaml", func(t *testing.T) {
+		isolate(t)
+		cfg, err := Load("test", []string{"-config", writeConfig(t, "tracker.yaml", yamlFile)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Database.DSN != defaultSQLitePath || cfg.Database.MaxOpenConns != Default().Database.MaxOpenConns {
+			t.Errorf("got dsn %q and %d connections, want the defaults", cfg.Database.DSN, cfg.Database.MaxOpenConns)
+		}
+		if !reflect.DeepEqual(cfg.Server.CORSOrigins, []string{"https://a.example"}) {
+			t.Errorf("cors origins %q", cfg.Server.CORSOrigins)
+		}
+	})
+
+	t.Run("yaml under env", func(t *testing.T) {
+		isolate(t)
+		t.Setenv("DATABASE_DSN", "jobs.db")
+		cfg, err := Load("test", []string{"-config", writeConfig(t, "tracker.yaml", yamlFile)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Database.DSN != "jobs.db" {
+			t.Errorf("dsn %q, want the env's", cfg.Database.DSN)
+		}
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		isolate(t)
+		cfg, err := Load("test", []string{"-config", writeConfig(t, "tracker.toml", tomlFile)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Database.Driver != "sqlite" || cfg.Database.DSN != defaultSQLitePath {
+			t.Errorf("got %q %q", cfg.Database.Driver, cfg.Database.DSN)
+		}
+	})
+}
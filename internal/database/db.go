@@ -1,30 +1,71 @@
 package database
 
 import (
+	"fmt"
 	"log"
+	"strings"
 
-	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// Supported database drivers
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// sqlitePragmas are applied to every SQLite connection unless the DSN sets its own options.
+// Foreign keys are off by default in SQLite, and the busy timeout lets the watcher and the API
+// wait for each other instead of failing with "database is locked".
+const sqlitePragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
 var DB *gorm.DB
 
-func Connect() *gorm.DB {
-	// In a real company, these values come from os.Getenv() (Environment Variables)
-	// For now, we hardcode for local dev, but we will fix this in Sprint 2.
-	dsn := "host=localhost user=postgres password=password dbname=jobtracker port=5432 sslmode=disable"
+// Connect opens the database described by driver and dsn (see config.DatabaseConfig).
+// For SQLite the dsn is a file path, e.g. "jobtracker.db". maxOpenConns caps the Postgres
+// pool, 0 = unlimited.
+func Connect(driver, dsn string, maxOpenConns int) *gorm.DB {
+	dialector, err := Dialector(driver, dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	DB, err = gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	log.Println("Database connection established")
+	sqlDB, err := DB.DB()
+	if err != nil {
+		log.Fatal("Failed to configure database:", err)
+	}
+	if driver == DriverSQLite {
+		// SQLite allows a single writer. One connection serializes writes in Go instead of
+		// surfacing SQLITE_BUSY from inside transactions.
+		sqlDB.SetMaxOpenConns(1)
+	} else {
+		sqlDB.SetMaxOpenConns(maxOpenConns)
+	}
+
+	log.Printf("Database connection established (%s)", driver)
 
-	// Migration: This creates the tables in Postgres automatically
-	log.Println("Running Migrations...")
-	DB.AutoMigrate(&models.Company{}, &models.Job{}, &models.JobEvent{}, &models.User{}, &models.ProcessedEmail{})
+	// The schema is owned by the versioned migrations (see migrate.go), not AutoMigrate
 	return DB
 }
+
+// Dialector returns the GORM dialector for a driver name
+func Dialector(driver, dsn string) (gorm.Dialector, error) {
+	switch driver {
+	case DriverPostgres:
+		return postgres.Open(dsn), nil
+	case DriverSQLite:
+		if !strings.Contains(dsn, "?") {
+			dsn += "?" + sqlitePragmas
+		}
+		return sqlite.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q (use %s or %s)", driver, DriverPostgres, DriverSQLite)
+	}
+}
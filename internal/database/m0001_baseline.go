@@ -0,0 +1,127 @@
+package database
+
+import (
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+// m0001Baseline creates the schema that AutoMigrate used to build at startup.
+// It is idempotent, so databases created before migrations existed are adopted as they are.
+var m0001Baseline = Migration{
+	Version: 1,
+	Name:    "baseline",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&userV1{}, &companyV1{}, &technologyV1{}, &jobV1{}, &jobTechnologyV1{}, &jobEventV1{}, &processedEmailV1{}, &pendingReviewV1{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("pending_reviews", "processed_emails", "job_events", "job_technologies", "jobs", "technologies", "companies", "users")
+	},
+}
+
+type userV1 struct {
+	ID            uint `gorm:"primaryKey"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+	Email         string         `gorm:"uniqueIndex;not null"`
+	LastHistoryID uint64
+}
+
+func (userV1) TableName() string { return "users" }
+
+type companyV1 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Name      string         `gorm:"uniqueIndex;not null"`
+	Jobs      []jobV1        `gorm:"foreignKey:CompanyID"`
+}
+
+func (companyV1) TableName() string { return "companies" }
+
+type technologyV1 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	Name      string `gorm:"not null"`
+	Slug      string `gorm:"uniqueIndex;not null"`
+}
+
+func (technologyV1) TableName() string { return "technologies" }
+
+type jobV1 struct {
+	ID             uint `gorm:"primaryKey"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+	CompanyID      uint
+	Title          string `gorm:"not null"`
+	Description    string `gorm:"type:text"`
+	JobLink        string
+	Status         string `gorm:"default:'APPLIED'"`
+	ResumeLink     string
+	InterviewRound int `gorm:"default:0"`
+	Location       string
+	WorkMode       string `gorm:"index"`
+	SalaryMin      int64  `gorm:"index:idx_jobs_min"`
+	SalaryMax      int64  `gorm:"index:idx_jobs_max"`
+	SalaryCurrency string
+	SalaryPeriod   string
+	SalaryRaw      string
+}
+
+func (jobV1) TableName() string { return "jobs" }
+
+// jobTechnologyV1 is the many2many join table between jobs and technologies
+type jobTechnologyV1 struct {
+	JobID        uint         `gorm:"primaryKey"`
+	TechnologyID uint         `gorm:"primaryKey"`
+	Job          jobV1        `gorm:"foreignKey:JobID"`
+	Technology   technologyV1 `gorm:"foreignKey:TechnologyID"`
+}
+
+func (jobTechnologyV1) TableName() string { return "job_technologies" }
+
+type jobEventV1 struct {
+	ID        uint      `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"index"`
+	JobID     uint      `gorm:"index"`
+	Kind      string    `gorm:"index"`
+	Source    string
+	Payload   models.JSON
+}
+
+func (jobEventV1) TableName() string { return "job_events" }
+
+type processedEmailV1 struct {
+	ID        string `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+func (processedEmailV1) TableName() string { return "processed_emails" }
+
+type pendingReviewV1 struct {
+	ID              uint `gorm:"primaryKey"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	State           string `gorm:"index;default:'PENDING'"`
+	Reason          string
+	MessageID       string `gorm:"index"`
+	Subject         string
+	Sender          string
+	Snippet         string `gorm:"type:text"`
+	CompanyID       *uint
+	CandidateJobIDs models.JSON
+	ProposedJobID   *uint
+	ProposedStatus  string
+	Summary         string
+	Confidence      float64
+	RawLLMOutput    string `gorm:"type:text"`
+	ResolvedJobID   *uint
+	ResolvedStatus  string
+	ResolvedAt      *time.Time
+}
+
+func (pendingReviewV1) TableName() string { return "pending_reviews" }
@@ -0,0 +1,197 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// m0002Users gives every row an owner. Data from before multi-user support goes to the
+// oldest user (the "default" user the watcher used to create), created here if needed.
+var m0002Users = Migration{
+	Version: 2,
+	Name:    "users",
+	Up: func(tx *gorm.DB) error {
+		m := tx.Migrator()
+
+		// 1. Owner columns
+		for _, model := range []interface{}{&companyV2{}, &jobV2{}, &jobEventV2{}, &pendingReviewV2{}} {
+			if err := m.AddColumn(model, "UserID"); err != nil {
+				return err
+			}
+		}
+		if err := m.AddColumn(&userV2{}, "GmailToken"); err != nil {
+			return err
+		}
+
+		// 2. Hand the existing data to the first user
+		ownerID, err := legacyOwner(tx)
+		if err != nil {
+			return err
+		}
+		if ownerID != 0 {
+			for _, table := range []string{"companies", "jobs", "job_events", "pending_reviews"} {
+				if err := tx.Table(table).Where("user_id = ?", 0).Update("user_id", ownerID).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		// 3. Indexes: company names are now unique per user
+		if err := m.DropIndex(&companyV1{}, "idx_companies_name"); err != nil {
+			return err
+		}
+		for _, idx := range []struct {
+			model interface{}
+			name  string
+		}{
+			{&companyV2{}, "idx_companies_user_name"},
+			{&jobV2{}, "idx_jobs_user_id"},
+			{&jobEventV2{}, "idx_job_events_user_id"},
+			{&pendingReviewV2{}, "idx_pending_reviews_user_id"},
+		} {
+			if err := m.CreateIndex(idx.model, idx.name); err != nil {
+				return err
+			}
+		}
+
+		// 4. Gmail message IDs are per mailbox: rebuild processed_emails keyed by (user_id, message_id)
+		if err := m.CreateTable(&processedEmailV2Staging{}); err != nil {
+			return err
+		}
+		err = tx.Exec("INSERT INTO processed_emails_v2 (user_id, message_id, created_at) SELECT ?, id, created_at FROM processed_emails", ownerID).Error
+		if err != nil {
+			return err
+		}
+		if err := m.DropTable("processed_emails"); err != nil {
+			return err
+		}
+		return m.RenameTable("processed_emails_v2", "processed_emails")
+	},
+	Down: func(tx *gorm.DB) error {
+		m := tx.Migrator()
+
+		// 1. Back to one processed_emails row per message ID
+		if err := m.CreateTable(&processedEmailV1Staging{}); err != nil {
+			return err
+		}
+		err := tx.Exec("INSERT INTO processed_emails_v1 (id, created_at) SELECT message_id, MIN(created_at) FROM processed_emails GROUP BY message_id").Error
+		if err != nil {
+			return err
+		}
+		if err := m.DropTable("processed_emails"); err != nil {
+			return err
+		}
+		if err := m.RenameTable("processed_emails_v1", "processed_emails"); err != nil {
+			return err
+		}
+
+		// 2. Indexes (fails if two users tracked a company with the same name)
+		for _, idx := range []struct {
+			model interface{}
+			name  string
+		}{
+			{&companyV2{}, "idx_companies_user_name"},
+			{&jobV2{}, "idx_jobs_user_id"},
+			{&jobEventV2{}, "idx_job_events_user_id"},
+			{&pendingReviewV2{}, "idx_pending_reviews_user_id"},
+		} {
+			if err := m.DropIndex(idx.model, idx.name); err != nil {
+				return err
+			}
+		}
+		if err := m.CreateIndex(&companyV1{}, "idx_companies_name"); err != nil {
+			return err
+		}
+
+		// 3. Columns
+		for _, table := range []string{"companies", "jobs", "job_events", "pending_reviews"} {
+			if err := dropColumn(tx, table, "user_id"); err != nil {
+				return err
+			}
+		}
+		return dropColumn(tx, "users", "gmail_token")
+	},
+}
+
+// dropColumn uses plain ALTER TABLE ... DROP COLUMN, which Postgres and SQLite (3.35+) both support.
+// GORM's SQLite migrator rebuilds the table instead, which foreign keys pointing at it forbid.
+func dropColumn(tx *gorm.DB, table, column string) error {
+	return tx.Exec("ALTER TABLE " + table + " DROP COLUMN " + column).Error
+}
+
+// legacyOwner returns the user that owns pre-existing data, creating a "default" user
+// when there is data but nobody to own it. 0 means the database is empty.
+func legacyOwner(tx *gorm.DB) (uint, error) {
+	var user userV1
+	err := tx.Order("id").Limit(1).Find(&user).Error
+	if err != nil || user.ID != 0 {
+		return user.ID, err
+	}
+
+	var rows int64
+	for _, table := range []string{"companies", "jobs", "pending_reviews", "processed_emails"} {
+		var n int64
+		if err := tx.Table(table).Count(&n).Error; err != nil {
+			return 0, err
+		}
+		rows += n
+	}
+	if rows == 0 {
+		return 0, nil
+	}
+
+	user = userV1{Email: "default"}
+	if err := tx.Create(&user).Error; err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+type userV2 struct {
+	GmailToken string `gorm:"type:text"`
+}
+
+func (userV2) TableName() string { return "users" }
+
+type companyV2 struct {
+	UserID uint   `gorm:"uniqueIndex:idx_companies_user_name;not null;default:0"`
+	Name   string `gorm:"uniqueIndex:idx_companies_user_name;not null"`
+}
+
+func (companyV2) TableName() string { return "companies" }
+
+type jobV2 struct {
+	UserID uint `gorm:"index:idx_jobs_user_id;not null;default:0"`
+}
+
+func (jobV2) TableName() string { return "jobs" }
+
+type jobEventV2 struct {
+	UserID uint `gorm:"index:idx_job_events_user_id;not null;default:0"`
+}
+
+func (jobEventV2) TableName() string { return "job_events" }
+
+type pendingReviewV2 struct {
+	UserID uint `gorm:"index:idx_pending_reviews_user_id;not null;default:0"`
+}
+
+func (pendingReviewV2) TableName() string { return "pending_reviews" }
+
+// processedEmailV2Staging is the new processed_emails, built under a temporary name
+type processedEmailV2Staging struct {
+	UserID    uint   `gorm:"primaryKey;autoIncrement:false"`
+	MessageID string `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+func (processedEmailV2Staging) TableName() string { return "processed_emails_v2" }
+
+// processedEmailV1Staging rebuilds the old single-key table on rollback
+type processedEmailV1Staging struct {
+	ID        string `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+func (processedEmailV1Staging) TableName() string { return "processed_emails_v1" }
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// m0003APIKeys stores hashed personal API keys
+var m0003APIKeys = Migration{
+	Version: 3,
+	Name:    "api_keys",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&apiKeyV3{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("api_keys")
+	},
+}
+
+type apiKeyV3 struct {
+	ID         uint `gorm:"primaryKey"`
+	CreatedAt  time.Time
+	UserID     uint   `gorm:"index;not null"`
+	Name       string `gorm:"not null"`
+	Prefix     string `gorm:"not null"`
+	Hash       string `gorm:"uniqueIndex;not null"`
+	Scopes     string `gorm:"not null"`
+	LastUsedAt *time.Time
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time
+}
+
+func (apiKeyV3) TableName() string { return "api_keys" }
@@ -0,0 +1,29 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// m0004OAuthStates tracks Gmail connect flows between /connect and /callback
+var m0004OAuthStates = Migration{
+	Version: 4,
+	Name:    "oauth_states",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&oauthStateV4{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("oauth_states")
+	},
+}
+
+type oauthStateV4 struct {
+	State     string `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UserID    uint      `gorm:"not null"`
+	Verifier  string    `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+func (oauthStateV4) TableName() string { return "oauth_states" }
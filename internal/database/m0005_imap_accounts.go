@@ -0,0 +1,35 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// m0005IMAPAccounts lets users sync a mailbox over IMAP
+var m0005IMAPAccounts = Migration{
+	Version: 5,
+	Name:    "imap_accounts",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&imapAccountV5{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("imap_accounts")
+	},
+}
+
+type imapAccountV5 struct {
+	ID          uint `gorm:"primaryKey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uint   `gorm:"uniqueIndex;not null"`
+	Addr        string `gorm:"not null"`
+	TLS         bool   `gorm:"not null"`
+	Username    string `gorm:"not null"`
+	Password    string `gorm:"not null"`
+	Mailbox     string `gorm:"not null"`
+	UIDValidity uint32
+	LastUID     uint32
+}
+
+func (imapAccountV5) TableName() string { return "imap_accounts" }
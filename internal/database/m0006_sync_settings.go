@@ -0,0 +1,37 @@
+package database
+
+import (
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+// m0006SyncSettings stores which mail each user's sync looks at
+var m0006SyncSettings = Migration{
+	Version: 6,
+	Name:    "sync_settings",
+	Up: func(tx *gorm.DB) error {
+		m := tx.Migrator()
+		for _, field := range []string{"SyncKeywords", "SyncLabels", "SyncWindowDays"} {
+			if err := m.AddColumn(&userV6{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		for _, column := range []string{"sync_keywords", "sync_labels", "sync_window_days"} {
+			if err := dropColumn(tx, "users", column); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+type userV6 struct {
+	SyncKeywords   models.JSON
+	SyncLabels     models.JSON
+	SyncWindowDays int `gorm:"not null;default:0"`
+}
+
+func (userV6) TableName() string { return "users" }
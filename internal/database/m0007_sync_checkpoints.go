@@ -0,0 +1,29 @@
+package database
+
+import (
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+// m0007SyncCheckpoints lets a sync cycle that ran out of time resume where it stopped
+var m0007SyncCheckpoints = Migration{
+	Version: 7,
+	Name:    "sync_checkpoints",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&syncCheckpointV7{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("sync_checkpoints")
+	},
+}
+
+type syncCheckpointV7 struct {
+	UserID    uint   `gorm:"primaryKey;autoIncrement:false"`
+	Mailbox   string `gorm:"primaryKey"`
+	Pending   models.JSON
+	UpdatedAt time.Time
+}
+
+func (syncCheckpointV7) TableName() string { return "sync_checkpoints" }
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// m0008GmailWatches keeps the Gmail push subscriptions
+var m0008GmailWatches = Migration{
+	Version: 8,
+	Name:    "gmail_watches",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&gmailWatchV8{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("gmail_watches")
+	},
+}
+
+type gmailWatchV8 struct {
+	UserID       uint   `gorm:"primaryKey;autoIncrement:false"`
+	EmailAddress string `gorm:"index;not null"`
+	Topic        string `gorm:"not null"`
+	HistoryID    uint64
+	Expiration   time.Time
+	UpdatedAt    time.Time
+}
+
+func (gmailWatchV8) TableName() string { return "gmail_watches" }
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+// m0009EmailQueue keeps fetched emails until they're processed, with retries and dead letters
+var m0009EmailQueue = Migration{
+	Version: 9,
+	Name:    "email_queue",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&queuedEmailV9{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("email_queue")
+	},
+}
+
+type queuedEmailV9 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint `gorm:"uniqueIndex:idx_email_queue_message;index:idx_email_queue_due,priority:1;not null"`
+
+	Mailbox   string `gorm:"uniqueIndex:idx_email_queue_message;not null"`
+	MessageID string `gorm:"uniqueIndex:idx_email_queue_message;not null"`
+	Subject   string
+	Sender    string
+	Message   models.JSON
+
+	State         string    `gorm:"index:idx_email_queue_due,priority:2;not null"`
+	Attempts      int       `gorm:"not null;default:0"`
+	LastError     string    `gorm:"type:text"`
+	NextAttemptAt time.Time `gorm:"index:idx_email_queue_due,priority:3"`
+}
+
+func (queuedEmailV9) TableName() string { return "email_queue" }
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+// m0010Emails keeps the processed emails and their classification
+var m0010Emails = Migration{
+	Version: 10,
+	Name:    "emails",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&emailV10{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("emails")
+	},
+}
+
+type emailV10 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint  `gorm:"uniqueIndex:idx_emails_user_message;not null"`
+	JobID     *uint `gorm:"index"`
+
+	MessageID  string `gorm:"uniqueIndex:idx_emails_user_message;not null"`
+	ThreadID   string `gorm:"index"`
+	Subject    string
+	Sender     string
+	Headers    models.JSON
+	Body       string `gorm:"type:text"`
+	ReceivedAt *time.Time
+
+	ClassificationAction     string
+	ClassificationReason     string
+	ClassificationStatus     string
+	ClassificationSummary    string `gorm:"type:text"`
+	ClassificationConfidence float64
+	ClassificationModel      string
+	ClassificationAt         time.Time
+}
+
+func (emailV10) TableName() string { return "emails" }
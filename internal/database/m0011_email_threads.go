@@ -0,0 +1,29 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// m0011EmailThreads remembers which job a conversation is about
+var m0011EmailThreads = Migration{
+	Version: 11,
+	Name:    "email_threads",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&emailThreadV11{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("email_threads")
+	},
+}
+
+type emailThreadV11 struct {
+	UserID    uint   `gorm:"primaryKey;autoIncrement:false"`
+	ThreadID  string `gorm:"primaryKey"`
+	JobID     uint   `gorm:"index;not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (emailThreadV11) TableName() string { return "email_threads" }
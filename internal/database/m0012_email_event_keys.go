@@ -0,0 +1,33 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// m0012EmailEventKeys keys email_received events on the email's message ID, so processing
+// an email again can't put it on a job's timeline twice. Other events and the email
+// events from before have no message ID.
+var m0012EmailEventKeys = Migration{
+	Version: 12,
+	Name:    "email_event_keys",
+	Up: func(tx *gorm.DB) error {
+		m := tx.Migrator()
+		if err := m.AddColumn(&jobEventV12{}, "MessageID"); err != nil {
+			return err
+		}
+		return m.CreateIndex(&jobEventV12{}, "idx_job_events_job_message")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(&jobEventV12{}, "idx_job_events_job_message"); err != nil {
+			return err
+		}
+		return dropColumn(tx, "job_events", "message_id")
+	},
+}
+
+type jobEventV12 struct {
+	JobID     uint    `gorm:"uniqueIndex:idx_job_events_job_message"`
+	MessageID *string `gorm:"uniqueIndex:idx_job_events_job_message"`
+}
+
+func (jobEventV12) TableName() string { return "job_events" }
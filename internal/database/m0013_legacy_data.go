@@ -0,0 +1,156 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+// m0013LegacyData converts the rows the baseline adopted from before migrations existed.
+// Job events then had a free-text event_type and details instead of kind and payload,
+// and jobs took whatever status the LLM or the user wrote. Both are mapped onto the
+// typed events and the status workflow. Databases created by the baseline have nothing to do.
+var m0013LegacyData = Migration{
+	Version: 13,
+	Name:    "legacy_data",
+	Up: func(tx *gorm.DB) error {
+		// 1. Statuses outside the workflow. Unknown ones restart at APPLIED with a note saying so.
+		if err := tx.Table("jobs").Where("status IS NULL").Update("status", "APPLIED").Error; err != nil {
+			return err
+		}
+		var values []string
+		if err := tx.Table("jobs").Distinct("status").Pluck("status", &values).Error; err != nil {
+			return err
+		}
+		for _, value := range values {
+			to, known := legacyStatusV13(value)
+			if to == value {
+				continue
+			}
+			if !known {
+				err := tx.Exec("INSERT INTO job_events (created_at, user_id, job_id, kind, source, payload) "+
+					"SELECT ?, user_id, id, ?, ?, ? FROM jobs WHERE status = ?",
+					time.Now(), "note_added", legacyEventSourceV13,
+					noteV13(fmt.Sprintf("The status was %q before the status workflow, it was reset to %s.", value, to)), value).Error
+				if err != nil {
+					return err
+				}
+			}
+			if err := tx.Table("jobs").Where("status = ?", value).Update("status", to).Error; err != nil {
+				return err
+			}
+		}
+		// An interview counts as round 1
+		err := tx.Table("jobs").Where("status = ? AND interview_round = 0", "INTERVIEW").Update("interview_round", 1).Error
+		if err != nil {
+			return err
+		}
+
+		// 2. Events from before the typed timeline
+		if !tx.Migrator().HasColumn("job_events", "event_type") {
+			return nil
+		}
+		var events []legacyEventV13
+		err = tx.Table("job_events").
+			Select("id, event_type, COALESCE(details, '') AS details").
+			Where("(kind = '' OR kind IS NULL) AND event_type IS NOT NULL AND event_type <> ''").
+			Find(&events).Error
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			updates := map[string]interface{}{"kind": "note_added", "source": "manual", "payload": noteV13(e.Details)}
+			if m := legacyEmailUpdateV13.FindStringSubmatch(e.Details); e.EventType == "EMAIL_UPDATE" && m != nil {
+				to, _ := legacyStatusV13(m[1])
+				updates = map[string]interface{}{"kind": "status_changed", "source": "email", "payload": mustJSONV13(statusChangedV13{To: to, Note: m[2]})}
+			} else if strings.TrimSpace(e.Details) == "" {
+				updates["payload"] = noteV13(e.EventType)
+			}
+			if err := tx.Table("job_events").Where("id = ?", e.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		// The old event columns are still there, the converted events go back to them.
+		// Statuses stay mapped: the code from before took any string.
+		if err := tx.Exec("DELETE FROM job_events WHERE source = ?", legacyEventSourceV13).Error; err != nil {
+			return err
+		}
+		if !tx.Migrator().HasColumn("job_events", "event_type") {
+			return nil
+		}
+		return tx.Table("job_events").
+			Where("event_type IS NOT NULL AND event_type <> ''").
+			Updates(map[string]interface{}{"kind": "", "source": "", "payload": nil}).Error
+	},
+}
+
+// legacyEventSourceV13 marks the notes this migration adds, so Down can find them
+const legacyEventSourceV13 = "migration"
+
+// legacyEmailUpdateV13 is the details the email watcher wrote for a status change
+var legacyEmailUpdateV13 = regexp.MustCompile(`(?s)^Status changed to (\S+)\. Summary: (.*)$`)
+
+type legacyEventV13 struct {
+	ID        uint
+	EventType string
+	Details   string
+}
+
+type statusChangedV13 struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Note string `json:"note,omitempty"`
+}
+
+type noteAddedV13 struct {
+	Text string `json:"text"`
+}
+
+func noteV13(text string) models.JSON {
+	return mustJSONV13(noteAddedV13{Text: text})
+}
+
+func mustJSONV13(v interface{}) models.JSON {
+	data, err := models.NewJSON(v)
+	if err != nil {
+		panic(err) // Plain structs of strings always marshal
+	}
+	return data
+}
+
+// legacyStatusV13 maps a free-text status onto the workflow of the time. known is false
+// for values it had to guess, those become APPLIED.
+func legacyStatusV13(value string) (to string, known bool) {
+	key := strings.ToUpper(strings.TrimSpace(value))
+	key = strings.NewReplacer(" ", "_", "-", "_").Replace(key)
+	switch key {
+	case "WISHLIST", "APPLIED", "SCREEN", "INTERVIEW", "OFFER", "ACCEPTED", "DECLINED", "REJECTED", "WITHDRAWN", "GHOSTED":
+		return key, true
+	case "SAVED", "INTERESTED", "WISHLISTED", "BOOKMARKED":
+		return "WISHLIST", true
+	case "", "SUBMITTED", "APPLICATION_SUBMITTED", "PENDING", "IN_PROGRESS", "NO_CHANGE", "UNKNOWN":
+		return "APPLIED", true
+	case "SCREENING", "PHONE_SCREEN", "PHONE_SCREENING", "RECRUITER_SCREEN":
+		return "SCREEN", true
+	case "INTERVIEWING", "INTERVIEW_SCHEDULED", "ONSITE", "TECHNICAL_INTERVIEW":
+		return "INTERVIEW", true
+	case "OFFERED", "OFFER_RECEIVED":
+		return "OFFER", true
+	case "HIRED", "OFFER_ACCEPTED":
+		return "ACCEPTED", true
+	case "REJECT", "NOT_SELECTED":
+		return "REJECTED", true
+	case "WITHDREW":
+		return "WITHDRAWN", true
+	case "NO_RESPONSE", "GHOST":
+		return "GHOSTED", true
+	}
+	return "APPLIED", false
+}
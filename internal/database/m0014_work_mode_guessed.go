@@ -0,0 +1,33 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// m0014WorkModeGuessed remembers whether a job's work mode was guessed from its location,
+// so a new location only replaces a guess and never what the user chose. Existing jobs
+// count as guessed when their work mode is what the location says, the same rules as
+// services.ParseWorkMode at the time.
+var m0014WorkModeGuessed = Migration{
+	Version: 14,
+	Name:    "work_mode_guessed",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.Migrator().AddColumn(&jobV14{}, "WorkModeGuessed"); err != nil {
+			return err
+		}
+		return tx.Exec(`UPDATE jobs SET work_mode_guessed = ? WHERE work_mode <> '' AND work_mode = CASE
+			WHEN LOWER(location) LIKE '%hybrid%' THEN 'HYBRID'
+			WHEN LOWER(location) LIKE '%remote%' OR LOWER(location) LIKE '%anywhere%' OR LOWER(location) LIKE '%wfh%' THEN 'REMOTE'
+			WHEN TRIM(COALESCE(location, '')) = '' THEN ''
+			ELSE 'ONSITE' END`, true).Error
+	},
+	Down: func(tx *gorm.DB) error {
+		return dropColumn(tx, "jobs", "work_mode_guessed")
+	},
+}
+
+type jobV14 struct {
+	WorkModeGuessed bool `gorm:"not null;default:false"`
+}
+
+func (jobV14) TableName() string { return "jobs" }
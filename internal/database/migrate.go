@@ -0,0 +1,185 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrSchemaBehind is returned by CheckSchema when migrations are pending
+var ErrSchemaBehind = errors.New("database schema is behind, run `api migrate up`")
+
+// Migration is one ordered schema change. Up and Down run inside a transaction.
+// Migrations must never change once released: add a new one instead.
+// They use their own snapshot structs, not internal/models, so later model changes don't rewrite history.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// migrations is the full history, in order. Append new ones at the end.
+var migrations = []Migration{
+	m0001Baseline,
+	m0002Users,
+	m0003APIKeys,
+	m0004OAuthStates,
+	m0005IMAPAccounts,
+	m0006SyncSettings,
+	m0007SyncCheckpoints,
+	m0008GmailWatches,
+	m0009EmailQueue,
+	m0010Emails,
+	m0011EmailThreads,
+	m0012EmailEventKeys,
+	m0013LegacyData,
+	m0014WorkModeGuessed,
+}
+
+// SchemaMigration is a row of the migrations table: one per applied migration
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+func (SchemaMigration) TableName() string { return "schema_migrations" }
+
+// MigrationStatus tells whether a known migration has been applied
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"` // nil = pending
+}
+
+// Migrations returns the known migrations sorted by version
+func Migrations() []Migration {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return sorted
+}
+
+// applied loads the migrations table, creating it on first use
+func applied(db *gorm.DB) (map[int]SchemaMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("creating migrations table: %w", err)
+	}
+	var rows []SchemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	done := make(map[int]SchemaMigration, len(rows))
+	for _, r := range rows {
+		done[r.Version] = r
+	}
+	return done, nil
+}
+
+// MigrateUp applies every pending migration in order and returns the ones it ran
+func MigrateUp(db *gorm.DB) ([]Migration, error) {
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for _, m := range Migrations() {
+		if _, ok := done[m.Version]; ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return ran, fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		ran = append(ran, m)
+	}
+	return ran, nil
+}
+
+// MigrateDown rolls back the last `steps` applied migrations, newest first
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	all := Migrations()
+	var rolledBack []Migration
+	for i := len(all) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		m := all[i]
+		if _, ok := done[m.Version]; !ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := deferForeignKeys(tx); err != nil {
+				return err
+			}
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, m.Version).Error
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("rolling back %04d_%s: %w", m.Version, m.Name, err)
+		}
+		rolledBack = append(rolledBack, m)
+	}
+	return rolledBack, nil
+}
+
+// deferForeignKeys checks SQLite's foreign keys when the transaction commits instead of at
+// every statement. A rollback may drop a parent table before its children, like GORM does
+// with the tables of a DropTable batch; by the commit the children are gone too.
+// Postgres drops with CASCADE and needs nothing.
+func deferForeignKeys(tx *gorm.DB) error {
+	if tx.Dialector.Name() != DriverSQLite {
+		return nil
+	}
+	return tx.Exec("PRAGMA defer_foreign_keys = ON").Error
+}
+
+// Status lists every known migration with when it was applied
+func Status(db *gorm.DB) ([]MigrationStatus, error) {
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	statuses := []MigrationStatus{}
+	for _, m := range Migrations() {
+		s := MigrationStatus{Version: m.Version, Name: m.Name}
+		if row, ok := done[m.Version]; ok {
+			appliedAt := row.AppliedAt
+			s.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// CheckSchema returns ErrSchemaBehind when a migration is pending, so the server never runs
+// against tables it doesn't understand
+func CheckSchema(db *gorm.DB) error {
+	statuses, err := Status(db)
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%w (%d pending)", ErrSchemaBehind, pending)
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sqliteDB opens an empty SQLite database file with the app's pragmas (foreign keys on)
+func sqliteDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dialector, err := Dialector(DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func exec(t *testing.T, db *gorm.DB, sql string, values ...interface{}) {
+	t.Helper()
+	if err := db.Exec(sql, values...).Error; err != nil {
+		t.Fatalf("%s: %v", sql, err)
+	}
+}
+
+// Rolling everything back must work on a database that has data in it, with SQLite
+// checking foreign keys: the baseline drops parents and children in one batch.
+func TestMigrateDownWithData(t *testing.T) {
+	db := sqliteDB(t)
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	exec(t, db, "INSERT INTO users (email) VALUES ('me@example.com')")
+	exec(t, db, "INSERT INTO companies (user_id, name) VALUES (1, 'Acme')")
+	exec(t, db, "INSERT INTO jobs (user_id, company_id, title) VALUES (1, 1, 'Engineer')")
+	exec(t, db, "INSERT INTO technologies (name, slug) VALUES ('Go', 'go')")
+	exec(t, db, "INSERT INTO job_technologies (job_id, technology_id) VALUES (1, 1)")
+
+	rolledBack, err := MigrateDown(db, len(Migrations()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rolledBack) != len(Migrations()) {
+		t.Errorf("rolled back %d migrations, want %d", len(rolledBack), len(Migrations()))
+	}
+	if db.Migrator().HasTable("users") || db.Migrator().HasTable("jobs") {
+		t.Error("tables are left after rolling everything back")
+	}
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatalf("migrating up again: %v", err)
+	}
+}
+
+// The schema AutoMigrate built before migrations existed, as adopted by the baseline
+type legacyUser struct {
+	ID            uint `gorm:"primaryKey"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+	Email         string         `gorm:"uniqueIndex;not null"`
+	LastHistoryID uint64
+}
+
+func (legacyUser) TableName() string { return "users" }
+
+type legacyCompany struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Name      string         `gorm:"uniqueIndex;not null"`
+}
+
+func (legacyCompany) TableName() string { return "companies" }
+
+type legacyJob struct {
+	ID          uint `gorm:"primaryKey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	CompanyID   uint
+	Title       string `gorm:"not null"`
+	Description string `gorm:"type:text"`
+	JobLink     string
+	Status      string `gorm:"default:'APPLIED'"`
+	ResumeLink  string
+}
+
+func (legacyJob) TableName() string { return "jobs" }
+
+type legacyJobEvent struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	JobID     uint
+	EventType string
+	Details   string `gorm:"type:text"`
+}
+
+func (legacyJobEvent) TableName() string { return "job_events" }
+
+type legacyProcessedEmail struct {
+	ID        string `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+func (legacyProcessedEmail) TableName() string { return "processed_emails" }
+
+type migratedEvent struct {
+	ID      uint
+	JobID   uint
+	Kind    string
+	Source  string
+	Payload string
+}
+
+func TestMigrateLegacyData(t *testing.T) {
+	db := sqliteDB(t)
+	err := db.AutoMigrate(&legacyUser{}, &legacyCompany{}, &legacyJob{}, &legacyJobEvent{}, &legacyProcessedEmail{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := []interface{}{
+		&legacyUser{Email: "default@local"},
+		&legacyCompany{Name: "Acme"},
+		&legacyJob{CompanyID: 1, Title: "Backend", Status: "Interviewing"},
+		&legacyJob{CompanyID: 1, Title: "Frontend", Status: "REJECTED"},
+		&legacyJob{CompanyID: 1, Title: "Platform", Status: "waiting on referral"},
+		&legacyJobEvent{JobID: 1, EventType: "EMAIL_UPDATE", Details: "Status changed to INTERVIEW. Summary: Onsite on Friday"},
+		&legacyJobEvent{JobID: 2, EventType: "MANUAL_NOTE", Details: "Recruiter called"},
+		&legacyJobEvent{JobID: 2, EventType: "APPLIED"},
+		&legacyProcessedEmail{ID: "gmail-1"},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	jobs := func() map[uint]string {
+		var list []struct {
+			ID             uint
+			Status         string
+			InterviewRound int
+		}
+		if err := db.Table("jobs").Order("id").Find(&list).Error; err != nil {
+			t.Fatal(err)
+		}
+		res := map[uint]string{}
+		for _, j := range list {
+			res[j.ID] = fmt.Sprintf("%s/%d", j.Status, j.InterviewRound)
+		}
+		return res
+	}
+	events := func() []migratedEvent {
+		var list []migratedEvent
+		err := db.Table("job_events").Select("id, job_id, kind, source, COALESCE(payload, '') AS payload").Order("id").Find(&list).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		return list
+	}
+
+	// 1. Up from the legacy schema
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	want := map[uint]string{1: "INTERVIEW/1", 2: "REJECTED/0", 3: "APPLIED/0"}
+	if got := jobs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("jobs %v, want %v", got, want)
+	}
+	wantEvents := []migratedEvent{
+		{ID: 1, JobID: 1, Kind: "status_changed", Source: "email", Payload: `{"from":"","to":"INTERVIEW","note":"Onsite on Friday"}`},
+		{ID: 2, JobID: 2, Kind: "note_added", Source: "manual", Payload: `{"text":"Recruiter called"}`},
+		{ID: 3, JobID: 2, Kind: "note_added", Source: "manual", Payload: `{"text":"APPLIED"}`},
+		{ID: 4, JobID: 3, Kind: "note_added", Source: "migration", Payload: `{"text":"The status was \"waiting on referral\" before the status workflow, it was reset to APPLIED."}`},
+	}
+	if got := events(); !reflect.DeepEqual(got, wantEvents) {
+		t.Errorf("events\n%+v\nwant\n%+v", got, wantEvents)
+	}
+	var owners int64
+	db.Table("job_events").Where("user_id = ?", 1).Count(&owners)
+	if owners != 4 {
+		t.Errorf("%d of 4 events belong to the legacy user", owners)
+	}
+
+	// 2. Down puts the events back the way the baseline adopted them, statuses stay mapped
+	if _, err := MigrateDown(db, len(Migrations())-m0013LegacyData.Version+1); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events() {
+		if e.ID > 3 || e.Kind != "" || e.Source != "" || e.Payload != "" {
+			t.Errorf("event %+v after Down", e)
+		}
+	}
+	if got := jobs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("jobs %v after Down, want %v", got, want)
+	}
+
+	// 3. Up again converts the same way, minus the note on a status it no longer sees
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	if got := events(); !reflect.DeepEqual(got, wantEvents[:3]) {
+		t.Errorf("events after Up again\n%+v\nwant\n%+v", got, wantEvents[:3])
+	}
+}
+
+func TestMigrateWorkModeGuessed(t *testing.T) {
+	db := sqliteDB(t)
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MigrateDown(db, len(Migrations())-m0014WorkModeGuessed.Version+1); err != nil {
+		t.Fatal(err)
+	}
+	exec(t, db, "INSERT INTO users (email) VALUES ('me@example.com')")
+	exec(t, db, "INSERT INTO companies (user_id, name) VALUES (1, 'Acme')")
+	jobs := []struct {
+		location, workMode string
+		guessed            bool
+	}{
+		{"Remote - EU", "REMOTE", true},
+		{"Berlin (hybrid)", "HYBRID", true},
+		{"Berlin", "ONSITE", true},
+		{"Berlin", "REMOTE", false},
+		{"", "ONSITE", false},
+		{"", "", false},
+	}
+	for _, j := range jobs {
+		exec(t, db, "INSERT INTO jobs (user_id, company_id, title, location, work_mode) VALUES (1, 1, 'Engineer', ?, ?)", j.location, j.workMode)
+	}
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	var guessed []bool
+	if err := db.Table("jobs").Order("id").Pluck("work_mode_guessed", &guessed).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i, j := range jobs {
+		if guessed[i] != j.guessed {
+			t.Errorf("%q at %q: guessed %v, want %v", j.workMode, j.location, guessed[i], j.guessed)
+		}
+	}
+}
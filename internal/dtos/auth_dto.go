@@ -0,0 +1,21 @@
+package dtos
+
+import "github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+
+// LoginRequest trades an API key for a session cookie (web UI login)
+type LoginRequest struct {
+	APIKey string `json:"api_key" binding:"required"`
+}
+
+// APIKeyCreateRequest creates a personal API key. No scopes = the caller's scopes.
+type APIKeyCreateRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"min=0"` // 0 = never
+}
+
+// APIKeyCreatedResponse is the only time the plaintext key is returned
+type APIKeyCreatedResponse struct {
+	Key    string         `json:"key"`
+	APIKey *models.APIKey `json:"api_key"`
+}
@@ -1,5 +1,11 @@
 package dtos
 
+import (
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+)
+
 type JobExtractionRequest struct {
 	RawHTML string `json:"raw_html" binding:"required"`
 	URL     string `json:"url"`
@@ -13,8 +19,95 @@ type JobCreationRequest struct {
 
 	// Optional Fields
 	Location    string   `json:"location"`
+	WorkMode    string   `json:"work_mode"` // REMOTE | HYBRID | ONSITE, guessed from Location if empty
 	SalaryRange string   `json:"salary_range"`
 	TechStack   []string `json:"tech_stack"`
 	ResumeLink  string   `json:"resume_link"`
 	Status      string   `json:"status"` // Defaults to "APPLIED" if empty
 }
+
+// JobUpdateRequest is the body of PATCH /jobs/:id.
+// Every field is a pointer so we can tell "not sent" apart from "set to empty".
+type JobUpdateRequest struct {
+	CompanyName *string `json:"company_name"`
+	Title       *string `json:"role_title"`
+	JobLink     *string `json:"job_link"`
+	Description *string `json:"description"`
+	ResumeLink  *string `json:"resume_link"`
+	Status      *string `json:"status"`
+
+	Location    *string   `json:"location"`
+	WorkMode    *string   `json:"work_mode"`
+	SalaryRange *string   `json:"salary_range"`
+	TechStack   *[]string `json:"tech_stack"` // Replaces the whole stack when sent
+}
+
+// JobListQuery holds the query-string filters of GET /jobs
+// e.g. /jobs?status=INTERVIEW&company=stripe&from=2025-01-01&q=backend&page=2
+type JobListQuery struct {
+	Page     int `form:"page"`
+	PageSize int `form:"page_size"`
+
+	Status  string    `form:"status"`
+	Company string    `form:"company"`                       // Partial, case-insensitive match on company name
+	From    time.Time `form:"from" time_format:"2006-01-02"` // Jobs created on or after this day
+	To      time.Time `form:"to" time_format:"2006-01-02"`   // Jobs created on or before this day
+	Query   string    `form:"q"`                             // Free text over title and description
+
+	Location  string `form:"location"`   // Partial, case-insensitive match on location
+	WorkMode  string `form:"work_mode"`  // REMOTE | HYBRID | ONSITE
+	Tech      string `form:"tech"`       // Technology name, e.g. "go"
+	SalaryMin int64  `form:"salary_min"` // Jobs whose range reaches at least this amount
+	Currency  string `form:"currency"`   // Restrict salary filtering to one currency, e.g. "USD"
+}
+
+// TechnologyStat is one row of GET /technologies
+type TechnologyStat struct {
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+	JobCount int64  `json:"job_count"`
+}
+
+// JobListResponse is one page of GET /jobs
+type JobListResponse struct {
+	Jobs     []models.Job `json:"jobs"`
+	Total    int64        `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+}
+
+// StatusChangeRequest is the body of POST /jobs/:id/status.
+// Posting INTERVIEW while already in INTERVIEW records the next interview round.
+type StatusChangeRequest struct {
+	Status string `json:"status" binding:"required"`
+	Note   string `json:"note"`
+}
+
+// StatusInfo describes one node of the status graph for GET /statuses
+type StatusInfo struct {
+	Status   string   `json:"status"`
+	Terminal bool     `json:"terminal"`
+	Next     []string `json:"next"`
+}
+
+// NoteRequest is the body of POST /jobs/:id/notes
+type NoteRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// TimelineResponse is returned by GET /jobs/:id/timeline (events oldest first)
+type TimelineResponse struct {
+	JobID  uint              `json:"job_id"`
+	Events []models.JobEvent `json:"events"`
+}
+
+// JobExtractionResult is what POST /jobs/extract returns.
+// The keys match JobCreationRequest so the client can post it straight back to /jobs.
+type JobExtractionResult struct {
+	CompanyName string   `json:"company_name"`
+	Title       string   `json:"role_title"`
+	Location    string   `json:"location"`
+	Description string   `json:"description"`
+	TechStack   []string `json:"tech_stack"`
+	SalaryRange string   `json:"salary_range"`
+}
@@ -0,0 +1,8 @@
+package dtos
+
+// ReviewApprovalRequest is the optional body of POST /reviews/:id/approve.
+// Leave both empty to apply what the LLM proposed.
+type ReviewApprovalRequest struct {
+	JobID  *uint  `json:"job_id"`
+	Status string `json:"status"`
+}
@@ -0,0 +1,28 @@
+package dtos
+
+import "time"
+
+// SyncSettings are which mail the first sync of a mailbox and backfills look at
+// (GET/PUT /sync/settings). Empty lists and 0 days mean the defaults.
+type SyncSettings struct {
+	Keywords   []string `json:"keywords"`                             // Any of them in the subject
+	Labels     []string `json:"labels"`                               // Any of these Gmail labels, none = all mail
+	WindowDays int      `json:"window_days" binding:"min=0,max=3650"` // How far back the first sync looks
+}
+
+// BackfillQuery is the query of POST /sync/backfill
+type BackfillQuery struct {
+	From   time.Time `form:"from" time_format:"2006-01-02" binding:"required"` // Mail received on or after this day
+	To     time.Time `form:"to" time_format:"2006-01-02"`                      // Mail received on or before this day (default: today)
+	DryRun bool      `form:"dry_run"`
+}
+
+// ReprocessQuery is the query of POST /emails/reprocess, it picks the stored emails to classify
+// again. Empty fields don't narrow anything.
+type ReprocessQuery struct {
+	From    time.Time `form:"from" time_format:"2006-01-02"` // Received on or after this day
+	To      time.Time `form:"to" time_format:"2006-01-02"`   // Received on or before this day
+	Company string    `form:"company"`                       // Linked to a job at this company (by name)
+	JobID   uint      `form:"job_id"`                        // Linked to this job
+	DryRun  bool      `form:"dry_run"`                       // Only report what would change
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/dtos"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
+)
+
+// SessionCookie holds the signed session of the web UI
+const SessionCookie = "jt_session"
+
+type AuthHandler struct {
+	AuthService  *services.AuthService
+	CookieSecure bool
+}
+
+func NewAuthHandler(a *services.AuthService, cookieSecure bool) *AuthHandler {
+	return &AuthHandler{AuthService: a, CookieSecure: cookieSecure}
+}
+
+// Authenticate accepts "Authorization: Bearer <api key>" (extension, scripts) or the
+// session cookie (web UI). Unauthenticated requests stop here with a 401.
+func (h *AuthHandler) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var principal *services.Principal
+		var err error
+		if header := c.GetHeader("Authorization"); header != "" {
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header must be \"Bearer <api key>\""})
+				return
+			}
+			principal, err = h.AuthService.AuthenticateAPIKey(strings.TrimSpace(token))
+		} else if cookie, cerr := c.Cookie(SessionCookie); cerr == nil {
+			principal, err = h.AuthService.AuthenticateSession(cookie)
+		} else {
+			err = services.ErrUnauthenticated
+		}
+
+		if errors.Is(err, services.ErrUnauthenticated) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to authenticate: " + err.Error()})
+			return
+		}
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// RequireScope rejects principals that lack the scope with a 403
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !currentPrincipal(c).Can(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Missing scope " + scope})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Login is the POST /auth/session endpoint: it trades an API key for a session cookie
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req dtos.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format: " + err.Error()})
+		return
+	}
+	principal, err := h.AuthService.AuthenticateAPIKey(strings.TrimSpace(req.APIKey))
+	if errors.Is(err, services.ErrUnauthenticated) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in: " + err.Error()})
+		return
+	}
+	value, expiresAt, err := h.AuthService.NewSession(principal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session: " + err.Error()})
+		return
+	}
+	h.setCookie(c, value, int(time.Until(expiresAt).Seconds()))
+	c.JSON(http.StatusOK, gin.H{"user": principal.User, "scopes": principal.Scopes, "expires_at": expiresAt})
+}
+
+// Logout is the DELETE /auth/session endpoint
+func (h *AuthHandler) Logout(c *gin.Context) {
+	h.setCookie(c, "", -1)
+	c.Status(http.StatusNoContent)
+}
+
+// setCookie writes the session cookie. SameSite=Strict keeps other sites from riding on it.
+func (h *AuthHandler) setCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(SessionCookie, value, maxAge, "/", "", h.CookieSecure, true)
+}
+
+// ListKeys is the GET /keys endpoint
+func (h *AuthHandler) ListKeys(c *gin.Context) {
+	keys, err := h.AuthService.ListAPIKeys(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list keys: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// CreateKey is the POST /keys endpoint. The plaintext key is only in this response.
+func (h *AuthHandler) CreateKey(c *gin.Context) {
+	var req dtos.APIKeyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format: " + err.Error()})
+		return
+	}
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+	principal := currentPrincipal(c)
+	plaintext, key, err := h.AuthService.CreateAPIKey(principal.User.ID, req.Name, req.Scopes, expiresAt, principal)
+	switch {
+	case errors.Is(err, services.ErrUnknownScope):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrScopeEscalation):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create key: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, dtos.APIKeyCreatedResponse{Key: plaintext, APIKey: key})
+}
+
+// RevokeKey is the DELETE /keys/:id endpoint
+func (h *AuthHandler) RevokeKey(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	err := h.AuthService.RevokeAPIKey(currentUserID(c), id)
+	if errors.Is(err, services.ErrAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke key: " + err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
+)
+
+// gmailStateCookie ties the OAuth callback to the browser that started the flow
+const gmailStateCookie = "jt_gmail_state"
+
+// gmailCookiePath limits the state cookie to the connect/callback endpoints
+const gmailCookiePath = "/api/v1/auth/gmail"
+
+type GmailHandler struct {
+	ConnectService *services.GmailConnectService
+	CookieSecure   bool
+	ConnectedURL   string // Where to send the browser after the callback (empty = JSON)
+}
+
+func NewGmailHandler(s *services.GmailConnectService, cookieSecure bool, connectedURL string) *GmailHandler {
+	return &GmailHandler{ConnectService: s, CookieSecure: cookieSecure, ConnectedURL: connectedURL}
+}
+
+// Connect is the GET /auth/gmail/connect endpoint: it sends the browser to Google's consent screen
+func (h *GmailHandler) Connect(c *gin.Context) {
+	authURL, state, err := h.ConnectService.Begin(currentUserID(c))
+	if errors.Is(err, services.ErrGmailDisabled) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start Gmail connection: " + err.Error()})
+		return
+	}
+
+	// Lax, not Strict: the callback is a top-level navigation coming back from Google
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(gmailStateCookie, state, int(h.ConnectService.StateTTL.Seconds()), gmailCookiePath, "", h.CookieSecure, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback is the GET /auth/gmail/callback endpoint Google redirects to.
+// It is public: the state (and the cookie that must match it) identifies the user.
+func (h *GmailHandler) Callback(c *gin.Context) {
+	state := c.Query("state")
+	cookie, _ := c.Cookie(gmailStateCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(gmailStateCookie, "", -1, gmailCookiePath, "", h.CookieSecure, true)
+
+	// 1. The user said no on the consent screen (or Google refused)
+	if reason := c.Query("error"); reason != "" {
+		h.finish(c, http.StatusBadRequest, "Google declined the connection: "+reason)
+		return
+	}
+
+	// 2. Only the browser that started the flow may finish it, so nobody can
+	// get their mailbox linked to someone else's account
+	if state == "" || cookie != state {
+		h.finish(c, http.StatusBadRequest, services.ErrInvalidState.Error())
+		return
+	}
+
+	user, err := h.ConnectService.Complete(c.Request.Context(), state, c.Query("code"))
+	switch {
+	case errors.Is(err, services.ErrInvalidState):
+		h.finish(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrGmailDisabled):
+		h.finish(c, http.StatusServiceUnavailable, err.Error())
+	case err != nil:
+		h.finish(c, http.StatusBadGateway, "Failed to connect Gmail: "+err.Error())
+	default:
+		if h.ConnectedURL != "" {
+			c.Redirect(http.StatusFound, withQuery(h.ConnectedURL, url.Values{"gmail": {"connected"}}))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"user": user, "gmail_connected": true})
+	}
+}
+
+// finish reports a failed callback to the web UI, or as JSON when there is none
+func (h *GmailHandler) finish(c *gin.Context, status int, reason string) {
+	if h.ConnectedURL != "" {
+		c.Redirect(http.StatusFound, withQuery(h.ConnectedURL, url.Values{"gmail": {"error"}, "reason": {reason}}))
+		return
+	}
+	c.JSON(status, gin.H{"error": reason})
+}
+
+// Disconnect is the DELETE /auth/gmail endpoint
+func (h *GmailHandler) Disconnect(c *gin.Context) {
+	revoked, err := h.ConnectService.Disconnect(c.Request.Context(), currentUser(c))
+	if errors.Is(err, services.ErrGmailNotConnected) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disconnect Gmail: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"gmail_connected": false, "revoked_at_google": revoked})
+}
+
+// Status is the GET /auth/gmail/status endpoint
+func (h *GmailHandler) Status(c *gin.Context) {
+	c.JSON(http.StatusOK, h.ConnectService.Status(c.Request.Context(), currentUser(c)))
+}
+
+// withQuery appends query parameters to a URL that may already have some
+func withQuery(raw string, params url.Values) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/dtos"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailsource"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
+)
+
+type ImportHandler struct {
+	EmailService *services.EmailService
+	// MaxUploadBytes caps the body of /import/mail
+	MaxUploadBytes int64
+}
+
+func NewImportHandler(e *services.EmailService, maxUploadBytes int64) *ImportHandler {
+	return &ImportHandler{EmailService: e, MaxUploadBytes: maxUploadBytes}
+}
+
+// ImportMail is the POST /import/mail endpoint: multipart "files" with .mbox archives or .eml
+// files (?dry_run=true to only report what would change). Large archives are better imported
+// with `api import mail`, the request lasts as long as the LLM takes.
+// The files are read as they arrive, nothing is buffered to disk, and the body may not be
+// larger than MaxUploadBytes (413).
+func (h *ImportHandler) ImportMail(c *gin.Context) {
+	dryRun := false
+	if raw := c.Query("dry_run"); raw != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be true or false"})
+			return
+		}
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.MaxUploadBytes)
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload .mbox or .eml files as multipart field \"files\""})
+		return
+	}
+
+	imp := services.NewMailImport(dryRun)
+	files := 0
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			h.respondUploadError(c, "Failed to read the upload: ", err)
+			return
+		}
+		if part.FormName() != "files" || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+		files++
+		err = mailsource.ReadArchive(part.FileName(), part, imp.Add)
+		part.Close()
+		if err != nil {
+			h.respondUploadError(c, "Failed to read "+part.FileName()+": ", err)
+			return
+		}
+	}
+	if files == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload .mbox or .eml files as multipart field \"files\""})
+		return
+	}
+
+	report, err := h.EmailService.ImportMail(c.Request.Context(), currentUser(c), imp)
+	if errors.Is(err, services.ErrSyncRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Your mail is being synced, try again in a minute"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Import failed: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// respondUploadError answers 413 when the upload went over MaxUploadBytes, 400 otherwise
+func (h *ImportHandler) respondUploadError(c *gin.Context, prefix string, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Upload is larger than %d MB, import it with `api import mail`", h.MaxUploadBytes>>20)})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": prefix + err.Error()})
+}
+
+// Backfill is the POST /sync/backfill endpoint (?from=2024-01-01&to=2024-03-31&dry_run=true).
+// It imports the user's job-related mail of those days from their connected mailboxes,
+// for history older than the first sync. The response is the same report as /import/mail.
+func (h *ImportHandler) Backfill(c *gin.Context) {
+	var q dtos.BackfillQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query: " + err.Error()})
+		return
+	}
+	var before time.Time
+	if !q.To.IsZero() {
+		before = q.To.AddDate(0, 0, 1) // "to" is inclusive
+		if !before.After(q.From) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+			return
+		}
+	}
+
+	report, err := h.EmailService.Backfill(c.Request.Context(), currentUser(c), q.From, before, q.DryRun)
+	if errors.Is(err, services.ErrNoMailbox) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Connect Gmail or an IMAP mailbox first"})
+		return
+	}
+	if errors.Is(err, services.ErrSyncRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Your mail is being synced, try again in a minute"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Backfill failed: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// Reprocess is the POST /emails/reprocess endpoint (?from=2024-01-01&to=2024-03-31&company=Acme
+// &job_id=3&dry_run=true). It classifies stored emails again with the current prompts and model;
+// with dry_run it only reports which verdicts and job statuses would change.
+func (h *ImportHandler) Reprocess(c *gin.Context) {
+	var q dtos.ReprocessQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query: " + err.Error()})
+		return
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && q.To.Before(q.From) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+
+	report, err := h.EmailService.Reprocess(c.Request.Context(), currentUser(c), &q)
+	if errors.Is(err, services.ErrSyncRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Your mail is being synced, try again in a minute"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Reprocessing failed: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
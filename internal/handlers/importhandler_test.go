@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
+)
+
+func TestImportMail(t *testing.T) {
+	db := sqliteDB(t)
+	user := models.User{Email: "me@example.com"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// A dry run of mail without sync keywords needs neither the LLM nor the sync lock
+	emailService := services.NewEmailService(db, nil, nil, services.NewUserService(db, nil), nil, nil, nil)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set(principalKey, &services.Principal{User: &user}) })
+	r.POST("/import/mail", NewImportHandler(emailService, 4<<10).ImportMail)
+	upload := func(field, name, content string) (int, services.ImportReport) {
+		t.Helper()
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, err := mw.CreateFormFile(field, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(content))
+		mw.Close()
+		req := httptest.NewRequest(http.MethodPost, "/import/mail?dry_run=true", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var report services.ImportReport
+		json.Unmarshal(w.Body.Bytes(), &report)
+		return w.Code, report
+	}
+
+	mbox := "From a@example.com Mon Jan  6 10:00:00 2025\nSubject: Lunch?\n\nTomorrow?\n\n" +
+		"From b@example.com Mon Jan  6 11:00:00 2025\nSubject: Re: Lunch?\n\nSure.\n"
+	code, report := upload("files", "takeout.mbox", mbox)
+	if code != http.StatusOK || report.Read != 2 || report.Unrelated != 2 {
+		t.Errorf("import: got %d %+v, want 200 with 2 emails read and unrelated", code, report)
+	}
+
+	if code, _ := upload("archive", "takeout.mbox", mbox); code != http.StatusBadRequest {
+		t.Errorf("no \"files\" field: got %d, want 400", code)
+	}
+
+	big := "From a@example.com Mon Jan  6 10:00:00 2025\nSubject: Big\n\n" + strings.Repeat("x", 8<<10) + "\n"
+	if code, _ := upload("files", "big.mbox", big); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("over the limit: got %d, want 413", code)
+	}
+}
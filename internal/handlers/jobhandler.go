@@ -1,12 +1,15 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/justsurfingit/Agentic-Job-Tracker/internal/dtos"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/llm"
 	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/status"
 )
 
 // LLm service such that we can use that global llm client here
@@ -17,7 +20,7 @@ type JobHandler struct {
 }
 
 // NewJobHandler creates the handler with dependencies
-func NewJobHandler(llm *services.LLMService,j *services.JobService) *JobHandler {
+func NewJobHandler(llm *services.LLMService, j *services.JobService) *JobHandler {
 	return &JobHandler{LLMService: llm,
 		JobService: j,
 	}
@@ -27,36 +30,228 @@ func NewJobHandler(llm *services.LLMService,j *services.JobService) *JobHandler
 func (h *JobHandler) ParseJob(c *gin.Context) {
 	var req dtos.JobExtractionRequest
 
-	
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format: " + err.Error()})
 		return
 	}
-	extractedJSON, err := h.LLMService.ExtractJobDetails(req.RawHTML)
+	extracted, err := h.LLMService.ExtractJobDetails(c.Request.Context(), req.RawHTML)
+	if errors.Is(err, llm.ErrInvalidOutput) {
+		// The model answered but never with valid JSON: that's an upstream failure, not ours
+		c.JSON(http.StatusBadGateway, gin.H{"error": "AI Extraction returned invalid data: " + err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "AI Extraction failed: " + err.Error()})
 		return
 	}
 
-	// 4. Return the raw JSON string from AI directly
-	// We use json.RawMessage to prevent Go from escaping the inner JSON string
+	// 4. Return the validated, typed result
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    json.RawMessage(extractedJSON),
+		"data":    extracted,
 	})
 }
+
 // creating the job
 func (h *JobHandler) CreateJob(c *gin.Context) {
 	var req dtos.JobCreationRequest
-	if err:=c.ShouldBindJSON(&req);err!=nil{
-		c.JSON(http.StatusBadRequest,gin.H{"error":"Invalid JSON format: "+err.Error()})
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format: " + err.Error()})
 		return
 	}
 	// creating the job
-	job,err:=h.JobService.CreateJob(&req)
-	if err!=nil{
-		c.JSON(http.StatusInternalServerError,gin.H{"error":"Failed to create job: "+err.Error()})
+	job, err := h.JobService.CreateJob(currentUserID(c), &req)
+	if err != nil {
+		respondJobError(c, "Failed to create job: ", err)
+		return
+	}
+	c.JSON(http.StatusCreated, job)
+}
+
+// ListJobs is the GET /jobs endpoint (paginated + filtered)
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	var q dtos.JobListQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters: " + err.Error()})
+		return
+	}
+	resp, err := h.JobService.ListJobs(currentUserID(c), &q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list jobs: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetJob is the GET /jobs/:id endpoint
+func (h *JobHandler) GetJob(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	job, err := h.JobService.GetJob(currentUserID(c), id)
+	if err != nil {
+		respondJobError(c, "Failed to fetch job: ", err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// UpdateJob is the PATCH /jobs/:id endpoint
+func (h *JobHandler) UpdateJob(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	var req dtos.JobUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format: " + err.Error()})
+		return
+	}
+	job, err := h.JobService.UpdateJob(currentUserID(c), id, &req)
+	if err != nil {
+		respondJobError(c, "Failed to update job: ", err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// DeleteJob is the DELETE /jobs/:id endpoint (soft delete)
+func (h *JobHandler) DeleteJob(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	if err := h.JobService.DeleteJob(currentUserID(c), id); err != nil {
+		respondJobError(c, "Failed to delete job: ", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ChangeStatus is the POST /jobs/:id/status endpoint
+func (h *JobHandler) ChangeStatus(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	var req dtos.StatusChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format: " + err.Error()})
+		return
+	}
+	to, err := status.Parse(req.Status)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	job, err := h.JobService.ChangeStatus(currentUserID(c), id, services.StatusChange{
+		To:     to,
+		Source: services.SourceManual,
+		Note:   req.Note,
+	})
+	if err != nil {
+		respondJobError(c, "Failed to change status: ", err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// GetTimeline is the GET /jobs/:id/timeline endpoint
+func (h *JobHandler) GetTimeline(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	events, err := h.JobService.Timeline(currentUserID(c), id)
+	if err != nil {
+		respondJobError(c, "Failed to load timeline: ", err)
+		return
+	}
+	c.JSON(http.StatusOK, dtos.TimelineResponse{JobID: id, Events: events})
+}
+
+// GetJobEmails is the GET /jobs/:id/emails endpoint: the emails about the job and how they were read
+func (h *JobHandler) GetJobEmails(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	emails, err := h.JobService.Emails(currentUserID(c), id)
+	if err != nil {
+		respondJobError(c, "Failed to load emails: ", err)
+		return
+	}
+	c.JSON(http.StatusOK, emails)
+}
+
+// GetEmail is the GET /emails/:id endpoint
+func (h *JobHandler) GetEmail(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	email, err := h.JobService.GetEmail(currentUserID(c), id)
+	if err != nil {
+		respondJobError(c, "Failed to load email: ", err)
+		return
+	}
+	c.JSON(http.StatusOK, email)
+}
+
+// AddNote is the POST /jobs/:id/notes endpoint
+func (h *JobHandler) AddNote(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	var req dtos.NoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format: " + err.Error()})
+		return
+	}
+	event, err := h.JobService.AddNote(currentUserID(c), id, req.Text)
+	if err != nil {
+		respondJobError(c, "Failed to add note: ", err)
+		return
+	}
+	c.JSON(http.StatusCreated, event)
+}
+
+// ListTechnologies is the GET /technologies endpoint (every tech the user has used, with its job count)
+func (h *JobHandler) ListTechnologies(c *gin.Context) {
+	stats, err := h.JobService.ListTechnologies(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list technologies: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// --- HELPERS ---
+
+// parseIDParam reads the ":id" path param and writes a 400 if it isn't a valid ID
+func parseIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid id: " + c.Param("id")})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondJobError maps service errors to HTTP status codes
+func respondJobError(c *gin.Context, prefix string, err error) {
+	switch {
+	case errors.Is(err, services.ErrJobNotFound), errors.Is(err, services.ErrEmailNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrInvalidWorkMode), errors.Is(err, services.ErrBlankCompanyName), errors.Is(err, status.ErrUnknownStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, status.ErrIllegalTransition), errors.Is(err, services.ErrStatusConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusCreated,job)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": prefix + err.Error()})
 }
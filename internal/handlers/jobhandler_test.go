@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/dtos"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
+)
+
+func TestUpdateJob(t *testing.T) {
+	db := sqliteDB(t)
+	user := models.User{Email: "me@example.com"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	jobs := services.NewJobService(db)
+	job, err := jobs.CreateJob(user.ID, &dtos.JobCreationRequest{CompanyName: "Acme", Title: "Backend Engineer", Location: "Berlin", WorkMode: "REMOTE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set(principalKey, &services.Principal{User: &user}) })
+	r.PATCH("/jobs/:id", NewJobHandler(nil, jobs).UpdateJob)
+	patch := func(body string) (int, models.Job) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPatch, "/jobs/"+strconv.Itoa(int(job.ID)), bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var got models.Job
+		json.Unmarshal(w.Body.Bytes(), &got)
+		return w.Code, got
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		want     int
+		workMode string
+	}{
+		{"blank company", `{"company_name": " "}`, http.StatusBadRequest, ""},
+		{"unknown work mode", `{"work_mode": "sometimes"}`, http.StatusBadRequest, ""},
+		{"location keeps the chosen work mode", `{"location": "Munich office"}`, http.StatusOK, models.WorkModeRemote},
+		{"work mode and location together", `{"location": "Remote", "work_mode": "hybrid"}`, http.StatusOK, models.WorkModeHybrid},
+	}
+	for _, tt := range tests {
+		code, got := patch(tt.body)
+		if code != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, code, tt.want)
+		}
+		if tt.workMode != "" && got.WorkMode != tt.workMode {
+			t.Errorf("%s: work mode %q, want %q", tt.name, got.WorkMode, tt.workMode)
+		}
+	}
+	if got, _ := jobs.GetJob(user.ID, job.ID); got.Company.Name != "Acme" {
+		t.Errorf("company %q after a blank name, want Acme", got.Company.Name)
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/auth"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
+)
+
+type PushHandler struct {
+	EmailService *services.EmailService
+	Verifier     auth.PushVerifier
+}
+
+func NewPushHandler(e *services.EmailService, v auth.PushVerifier) *PushHandler {
+	return &PushHandler{EmailService: e, Verifier: v}
+}
+
+// pushEnvelope is the body of a Pub/Sub push request, data is base64 in the JSON
+type pushEnvelope struct {
+	Message struct {
+		Data      []byte `json:"data"`
+		MessageID string `json:"messageId"`
+	} `json:"message" binding:"required"`
+	Subscription string `json:"subscription"`
+}
+
+// Push is the POST /gmail/push endpoint Pub/Sub delivers Gmail's mailbox changes to.
+// Any 2xx acknowledges the message, anything else makes Pub/Sub retry it later.
+func (h *PushHandler) Push(c *gin.Context) {
+	if err := h.Verifier.Verify(c.Request); err != nil {
+		log.Printf("⚠️ Gmail push rejected: %v", err)
+		if errors.Is(err, auth.ErrPushForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var env pushEnvelope
+	if err := c.ShouldBindJSON(&env); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid push message: " + err.Error()})
+		return
+	}
+	var n services.PushNotification
+	if err := json.Unmarshal(env.Message.Data, &n); err != nil || n.EmailAddress == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid push data"})
+		return
+	}
+
+	err := h.EmailService.HandlePush(c.Request.Context(), n)
+	if errors.Is(err, services.ErrUnknownPushAddress) {
+		// A mailbox that was disconnected, retrying won't help
+		log.Printf("⚠️ Gmail push %s for %s: %v", env.Message.MessageID, n.EmailAddress, err)
+		c.Status(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to handle push"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
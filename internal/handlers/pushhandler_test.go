@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/auth"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
+	"google.golang.org/api/idtoken"
+)
+
+const (
+	pushAudience       = "https://jobs.example.com/api/v1/gmail/push"
+	pushServiceAccount = "gmail-push@project.iam.gserviceaccount.com"
+)
+
+// stubTokens stands in for Google's signature check: the JWTs it knows and what they claim
+var stubTokens = map[string]*idtoken.Payload{
+	"valid": {Audience: pushAudience, Claims: map[string]interface{}{"email": pushServiceAccount, "email_verified": true}},
+	"other-audience": {Audience: "https://elsewhere.example.com/push",
+		Claims: map[string]interface{}{"email": pushServiceAccount, "email_verified": true}},
+	"other-signer": {Audience: pushAudience, Claims: map[string]interface{}{"email": "someone@else.iam.gserviceaccount.com", "email_verified": true}},
+	"unverified":   {Audience: pushAudience, Claims: map[string]interface{}{"email": pushServiceAccount}},
+}
+
+func stubValidate(_ context.Context, token, audience string) (*idtoken.Payload, error) {
+	if audience != "" {
+		return nil, errors.New("the verifier is supposed to check the audience itself")
+	}
+	if payload, ok := stubTokens[token]; ok {
+		return payload, nil
+	}
+	return nil, errors.New("idtoken: invalid token signature")
+}
+
+// syncCounter is the sync lock, it records every sync the watcher starts
+type syncCounter struct {
+	syncs chan uint
+}
+
+func (l *syncCounter) TryLock(ctx context.Context, userID uint) (func(), bool, error) {
+	l.syncs <- userID
+	return func() {}, true, nil
+}
+
+// fakePubSub delivers Gmail notifications the way a Pub/Sub push subscription does
+type fakePubSub struct {
+	url string
+}
+
+// push posts the notification, query and bearer are the sender's credentials ("" = none)
+func (p *fakePubSub) push(t *testing.T, data interface{}, query, bearer string) int {
+	t.Helper()
+	raw, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"message":      map[string]interface{}{"data": raw, "messageId": "1"}, // []byte is base64 in JSON
+		"subscription": "projects/p/subscriptions/gmail-push",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, p.url+query, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// pushServer runs the push endpoint and a watcher whose syncs end up in the returned channel.
+// me@gmail.com (user 1) is watched and synced up to history ID 100.
+func pushServer(t *testing.T, verifier auth.PushVerifier) (*fakePubSub, <-chan uint) {
+	t.Helper()
+	db := sqliteDB(t)
+	user := models.User{Email: "me@example.com", LastHistoryID: 100}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	watch := models.GmailWatch{UserID: user.ID, EmailAddress: "me@gmail.com", Topic: "projects/p/topics/gmail", HistoryID: 100, Expiration: time.Now().AddDate(0, 0, 7)}
+	if err := db.Create(&watch).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// The watcher only syncs what it's kicked for: no Gmail client and no IMAP accounts to poll
+	emailService := services.NewEmailService(db, nil, nil, services.NewUserService(db, nil), nil, nil, nil)
+	emailService.IMAPAccounts = services.NewIMAPAccountService(db, nil)
+	emailService.PushTopic = watch.Topic
+	counter := &syncCounter{syncs: make(chan uint, 16)}
+	emailService.Locks = counter
+	ctx, cancel := context.WithCancel(context.Background())
+	done := emailService.StartWatcher(ctx)
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/v1/gmail/push", NewPushHandler(emailService, verifier).Push)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return &fakePubSub{url: srv.URL + "/api/v1/gmail/push"}, counter.syncs
+}
+
+// expectSyncs waits for want syncs of the user, then makes sure no more follow
+func expectSyncs(t *testing.T, syncs <-chan uint, want int) {
+	t.Helper()
+	for i := 0; i < want; i++ {
+		select {
+		case userID := <-syncs:
+			if userID != 1 {
+				t.Errorf("synced user %d, want 1", userID)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d syncs, want %d", i, want)
+		}
+	}
+	select {
+	case <-syncs:
+		t.Fatalf("got more than %d syncs", want)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestPushOIDC(t *testing.T) {
+	verifier := &auth.OIDCPushVerifier{Audience: pushAudience, ServiceAccount: pushServiceAccount, Validate: stubValidate}
+	pubsub, syncs := pushServer(t, verifier)
+	news := services.PushNotification{EmailAddress: "Me@Gmail.com", HistoryID: 120}
+
+	rejected := []struct {
+		name   string
+		bearer string
+		want   int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"bad signature", "forged", http.StatusUnauthorized},
+		{"other audience", "other-audience", http.StatusForbidden},
+		{"other service account", "other-signer", http.StatusForbidden},
+		{"unverified service account", "unverified", http.StatusForbidden},
+	}
+	for _, c := range rejected {
+		if got := pubsub.push(t, news, "", c.bearer); got != c.want {
+			t.Errorf("%s: got %d, want %d", c.name, got, c.want)
+		}
+	}
+	expectSyncs(t, syncs, 0)
+
+	// A valid notification kicks exactly one sync of the mailbox's user
+	if got := pubsub.push(t, news, "", "valid"); got != http.StatusNoContent {
+		t.Fatalf("got %d, want 204", got)
+	}
+	expectSyncs(t, syncs, 1)
+
+	// Acknowledged without a sync: history we synced already, a mailbox nobody watches
+	if got := pubsub.push(t, services.PushNotification{EmailAddress: "me@gmail.com", HistoryID: 90}, "", "valid"); got != http.StatusNoContent {
+		t.Errorf("old history: got %d, want 204", got)
+	}
+	if got := pubsub.push(t, services.PushNotification{EmailAddress: "gone@gmail.com", HistoryID: 500}, "", "valid"); got != http.StatusNoContent {
+		t.Errorf("unknown address: got %d, want 204", got)
+	}
+	if got := pubsub.push(t, map[string]string{"unexpected": "data"}, "", "valid"); got != http.StatusBadRequest {
+		t.Errorf("bad data: got %d, want 400", got)
+	}
+	expectSyncs(t, syncs, 0)
+}
+
+func TestPushToken(t *testing.T) {
+	pubsub, syncs := pushServer(t, &auth.TokenPushVerifier{Token: "s3cret"})
+	news := services.PushNotification{EmailAddress: "me@gmail.com", HistoryID: 120}
+
+	if got := pubsub.push(t, news, "", ""); got != http.StatusUnauthorized {
+		t.Errorf("no token: got %d, want 401", got)
+	}
+	if got := pubsub.push(t, news, "?token=guess", ""); got != http.StatusUnauthorized {
+		t.Errorf("wrong token: got %d, want 401", got)
+	}
+	expectSyncs(t, syncs, 0)
+
+	if got := pubsub.push(t, news, "?token=s3cret", ""); got != http.StatusNoContent {
+		t.Fatalf("got %d, want 204", got)
+	}
+	expectSyncs(t, syncs, 1)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
+)
+
+type QueueHandler struct {
+	QueueService *services.EmailQueueService
+}
+
+func NewQueueHandler(q *services.EmailQueueService) *QueueHandler {
+	return &QueueHandler{QueueService: q}
+}
+
+// ListFailed is the GET /sync/failed endpoint: the emails the pipeline gave up on, with the last error
+func (h *QueueHandler) ListFailed(c *gin.Context) {
+	items, err := h.QueueService.Failed(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list failed emails: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// Requeue is the POST /sync/failed/:id/requeue endpoint. The email is tried again in the next sync.
+func (h *QueueHandler) Requeue(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	item, err := h.QueueService.Requeue(currentUserID(c), id)
+	if errors.Is(err, services.ErrQueuedEmailNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to requeue email: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+// RequeueAll is the POST /sync/failed/requeue endpoint, e.g. after an LLM outage
+func (h *QueueHandler) RequeueAll(c *gin.Context) {
+	n, err := h.QueueService.RequeueAll(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to requeue emails: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"requeued": n})
+}
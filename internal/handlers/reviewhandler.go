@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/dtos"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/status"
+)
+
+type ReviewHandler struct {
+	ReviewService *services.ReviewService
+}
+
+func NewReviewHandler(r *services.ReviewService) *ReviewHandler {
+	return &ReviewHandler{ReviewService: r}
+}
+
+// ListReviews is the GET /reviews endpoint (?state=PENDING|APPROVED|DISMISSED, default PENDING)
+func (h *ReviewHandler) ListReviews(c *gin.Context) {
+	reviews, err := h.ReviewService.List(currentUserID(c), strings.ToUpper(c.Query("state")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list reviews: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, reviews)
+}
+
+// ApproveReview is the POST /reviews/:id/approve endpoint. The body is optional.
+func (h *ReviewHandler) ApproveReview(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	var req dtos.ReviewApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format: " + err.Error()})
+		return
+	}
+	review, err := h.ReviewService.Approve(currentUserID(c), id, &req)
+	if err != nil {
+		respondReviewError(c, "Failed to approve review: ", err)
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
+// DismissReview is the POST /reviews/:id/dismiss endpoint
+func (h *ReviewHandler) DismissReview(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	review, err := h.ReviewService.Dismiss(currentUserID(c), id)
+	if err != nil {
+		respondReviewError(c, "Failed to dismiss review: ", err)
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
+func respondReviewError(c *gin.Context, prefix string, err error) {
+	switch {
+	case errors.Is(err, services.ErrReviewNotFound), errors.Is(err, services.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrReviewIncomplete), errors.Is(err, status.ErrUnknownStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrReviewResolved), errors.Is(err, status.ErrIllegalTransition), errors.Is(err, services.ErrStatusConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": prefix + err.Error()})
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/dtos"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/status"
+)
+
+// ListStatuses is the GET /statuses endpoint.
+// The dashboard uses it to only offer the moves the state machine accepts.
+func ListStatuses(c *gin.Context) {
+	var res []dtos.StatusInfo
+	for _, st := range status.All() {
+		next := []string{}
+		for _, n := range st.Next() {
+			next = append(next, string(n))
+		}
+		res = append(res, dtos.StatusInfo{
+			Status:   string(st),
+			Terminal: st.IsTerminal(),
+			Next:     next,
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
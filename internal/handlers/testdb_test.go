@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqliteDB opens a migrated SQLite database in a temp dir
+func sqliteDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dialector, err := database.Dialector(database.DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
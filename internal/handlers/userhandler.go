@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/dtos"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/services"
+)
+
+// principalKey is where Authenticate stores the *services.Principal in the gin context
+const principalKey = "principal"
+
+// currentPrincipal returns what the Authenticate middleware resolved
+func currentPrincipal(c *gin.Context) *services.Principal {
+	return c.MustGet(principalKey).(*services.Principal)
+}
+
+// currentUser returns the user the request acts as
+func currentUser(c *gin.Context) *models.User {
+	return currentPrincipal(c).User
+}
+
+// currentUserID is shorthand for currentUser(c).ID
+func currentUserID(c *gin.Context) uint {
+	return currentUser(c).ID
+}
+
+type UserHandler struct {
+	UserService *services.UserService
+}
+
+func NewUserHandler(u *services.UserService) *UserHandler {
+	return &UserHandler{UserService: u}
+}
+
+// GetMe is the GET /me endpoint
+func (h *UserHandler) GetMe(c *gin.Context) {
+	p := currentPrincipal(c)
+	c.JSON(http.StatusOK, gin.H{
+		"user":            p.User,
+		"scopes":          p.Scopes,
+		"gmail_connected": h.UserService.GmailConnected(c.Request.Context(), p.User.ID),
+	})
+}
+
+// GetSyncSettings is the GET /sync/settings endpoint
+func (h *UserHandler) GetSyncSettings(c *gin.Context) {
+	c.JSON(http.StatusOK, h.UserService.SyncSettings(currentUser(c)))
+}
+
+// UpdateSyncSettings is the PUT /sync/settings endpoint. Omitted or empty fields go back to the defaults.
+func (h *UserHandler) UpdateSyncSettings(c *gin.Context) {
+	var req dtos.SyncSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format: " + err.Error()})
+		return
+	}
+	settings, err := h.UserService.SetSyncSettings(currentUser(c), &req)
+	if errors.Is(err, services.ErrInvalidSyncSettings) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save sync settings: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
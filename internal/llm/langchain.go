@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/googleai"
+	"github.com/tmc/langchaingo/llms/ollama"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+const (
+	defaultGeminiModel = "gemini-2.5-flash"
+	defaultOpenAIModel = "gpt-4o-mini"
+	defaultOllamaModel = "llama3.1"
+)
+
+// langchainProvider adapts any LangChainGo model to our Provider interface
+type langchainProvider struct {
+	name   string
+	model  string
+	client llms.Model
+}
+
+func (p *langchainProvider) Name() string  { return p.name }
+func (p *langchainProvider) Model() string { return p.model }
+
+func (p *langchainProvider) Generate(ctx context.Context, req Request) (string, error) {
+	var opts []llms.CallOption
+	if req.Temperature > 0 {
+		opts = append(opts, llms.WithTemperature(req.Temperature))
+	}
+	return llms.GenerateFromSinglePrompt(ctx, p.client, req.Prompt, opts...)
+}
+
+func newGemini(ctx context.Context, cfg Config) (Provider, error) {
+	// Fail fast if the API key isn't available so we don't create an unauthenticated client.
+	if cfg.APIKey == "" {
+		return nil, errors.New("gemini provider needs an API key (GEMINI_API_KEY)")
+	}
+	model := orDefault(cfg.Model, defaultGeminiModel)
+	client, err := googleai.New(ctx,
+		googleai.WithAPIKey(cfg.APIKey),
+		googleai.WithDefaultModel(model),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &langchainProvider{name: ProviderGemini, model: model, client: client}, nil
+}
+
+func newOpenAI(cfg Config) (Provider, error) {
+	model := orDefault(cfg.Model, defaultOpenAIModel)
+	opts := []openai.Option{openai.WithModel(model)}
+	if cfg.APIKey != "" {
+		opts = append(opts, openai.WithToken(cfg.APIKey))
+	} else {
+		// Local OpenAI-compatible servers usually ignore the key, but the client insists on one
+		opts = append(opts, openai.WithToken("unused"))
+	}
+	if cfg.BaseURL != "" {
+		opts = append(opts, openai.WithBaseURL(cfg.BaseURL))
+	}
+	client, err := openai.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &langchainProvider{name: ProviderOpenAI, model: model, client: client}, nil
+}
+
+func newOllama(cfg Config) (Provider, error) {
+	model := orDefault(cfg.Model, defaultOllamaModel)
+	opts := []ollama.Option{ollama.WithModel(model)}
+	if cfg.BaseURL != "" {
+		opts = append(opts, ollama.WithServerURL(cfg.BaseURL))
+	}
+	client, err := ollama.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &langchainProvider{name: ProviderOllama, model: model, client: client}, nil
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
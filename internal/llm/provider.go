@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Task tells a provider which of our prompts it is answering.
+// Real models just read the prompt; offline backends use it to pick a rule set / fixture.
+type Task string
+
+const (
+	TaskExtractJob   Task = "extract_job"
+	TaskIdentifyRole Task = "identify_role"
+	TaskAnalyzeEmail Task = "analyze_email"
+)
+
+// Request is a single completion call
+type Request struct {
+	Task        Task
+	Prompt      string
+	Temperature float64 // 0 = provider default
+
+	// Inputs are the raw values the prompt was built from (e.g. "subject", "body"),
+	// so the rule-based backend doesn't have to parse them back out of the prompt
+	Inputs map[string]string
+}
+
+// Provider is anything that can answer our prompts: a hosted model, a local one, or plain rules
+type Provider interface {
+	// Name is the backend, e.g. "gemini" or "rules"
+	Name() string
+	// Model is the model version, e.g. "gemini-2.5-flash"
+	Model() string
+	Generate(ctx context.Context, req Request) (string, error)
+}
+
+// Supported values of Config.Provider
+const (
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai" // Any OpenAI-compatible API (OpenAI, Groq, vLLM, LM Studio, ...)
+	ProviderOllama = "ollama"
+	ProviderRules  = "rules"  // Deterministic keyword rules, no network
+	ProviderReplay = "replay" // Recorded responses from FixturesDir, falls back to rules
+)
+
+// Config selects and configures the provider
+type Config struct {
+	Provider string
+	Model    string // Empty = the provider's default model
+	APIKey   string
+	BaseURL  string // For openai-compatible servers and Ollama
+
+	// FixturesDir is read by the replay provider. If RecordFixtures is set,
+	// responses of a live provider are written there so they can be replayed offline later.
+	FixturesDir    string
+	RecordFixtures bool
+
+	// Limits of a hosted provider, per minute (0 = unlimited). The offline providers ignore them.
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// New builds the provider described by cfg
+func New(ctx context.Context, cfg Config) (Provider, error) {
+	var (
+		p   Provider
+		err error
+	)
+	switch strings.ToLower(cfg.Provider) {
+	case ProviderGemini:
+		p, err = newGemini(ctx, cfg)
+	case ProviderOpenAI:
+		p, err = newOpenAI(cfg)
+	case ProviderOllama:
+		p, err = newOllama(cfg)
+	case ProviderRules:
+		return NewRules(), nil
+	case ProviderReplay:
+		return NewReplay(cfg.FixturesDir, NewRules())
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q (want gemini, openai, ollama, rules or replay)", cfg.Provider)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.RequestsPerMinute > 0 || cfg.TokensPerMinute > 0 {
+		p = NewLimited(p, cfg.RequestsPerMinute, cfg.TokensPerMinute)
+	}
+	if cfg.RecordFixtures {
+		return NewRecorder(p, cfg.FixturesDir)
+	}
+	return p, nil
+}
@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limited wraps a hosted provider so we stay under its requests and tokens per minute.
+// Tokens are estimated (about 4 characters each); the prompt is paid for before the call,
+// the answer after it, so a long answer slows down the next requests.
+type Limited struct {
+	Provider
+	requests *rate.Limiter // nil = no limit
+	tokens   *rate.Limiter // nil = no limit
+}
+
+// NewLimited limits p to rpm requests and tpm tokens per minute, 0 = unlimited
+func NewLimited(p Provider, rpm, tpm int) *Limited {
+	l := &Limited{Provider: p}
+	if rpm > 0 {
+		l.requests = rate.NewLimiter(rate.Limit(float64(rpm)/60), rpm)
+	}
+	if tpm > 0 {
+		l.tokens = rate.NewLimiter(rate.Limit(float64(tpm)/60), tpm)
+	}
+	return l
+}
+
+func (l *Limited) Generate(ctx context.Context, req Request) (string, error) {
+	if l.requests != nil {
+		if err := l.requests.Wait(ctx); err != nil {
+			return "", err
+		}
+	}
+	if l.tokens != nil {
+		if err := l.tokens.WaitN(ctx, min(estimateTokens(req.Prompt), l.tokens.Burst())); err != nil {
+			return "", err
+		}
+	}
+
+	resp, err := l.Provider.Generate(ctx, req)
+	if l.tokens != nil && resp != "" {
+		l.tokens.ReserveN(time.Now(), min(estimateTokens(resp), l.tokens.Burst()))
+	}
+	return resp, err
+}
+
+// estimateTokens is the usual rule of thumb for English text, close enough for a budget
+func estimateTokens(s string) int {
+	return len(s)/4 + 1
+}
@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// ErrFixtureNotFound is returned by the replay provider when nothing was recorded for a prompt
+var ErrFixtureNotFound = errors.New("no recorded fixture for this prompt")
+
+// fixture is one recorded completion, stored as <dir>/<task>/<hash>.json
+type fixture struct {
+	Task     Task   `json:"task"`
+	Model    string `json:"model"`
+	Prompt   string `json:"prompt"`
+	Response string `json:"response"`
+}
+
+// Replay answers prompts from recorded fixtures. Unknown prompts go to the fallback
+// (the rules provider by default) so the app keeps working offline.
+type Replay struct {
+	dir      string
+	fallback Provider
+}
+
+func NewReplay(dir string, fallback Provider) (*Replay, error) {
+	if dir == "" {
+		return nil, errors.New("replay provider needs a fixtures directory")
+	}
+	return &Replay{dir: dir, fallback: fallback}, nil
+}
+
+func (r *Replay) Name() string  { return ProviderReplay }
+func (r *Replay) Model() string { return "replay:" + filepath.Base(r.dir) }
+
+func (r *Replay) Generate(ctx context.Context, req Request) (string, error) {
+	b, err := os.ReadFile(fixturePath(r.dir, req))
+	if errors.Is(err, os.ErrNotExist) {
+		if r.fallback == nil {
+			return "", ErrFixtureNotFound
+		}
+		return r.fallback.Generate(ctx, req)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var f fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		return "", fmt.Errorf("corrupt fixture: %w", err)
+	}
+	return f.Response, nil
+}
+
+// Recorder wraps a live provider and saves every answer as a fixture for the replay provider
+type Recorder struct {
+	Provider
+	dir string
+}
+
+func NewRecorder(p Provider, dir string) (*Recorder, error) {
+	if dir == "" {
+		return nil, errors.New("recording fixtures needs a fixtures directory")
+	}
+	return &Recorder{Provider: p, dir: dir}, nil
+}
+
+func (r *Recorder) Generate(ctx context.Context, req Request) (string, error) {
+	resp, err := r.Provider.Generate(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	// Recording is best effort, the live answer is still good if it fails
+	path := fixturePath(r.dir, req)
+	b, _ := json.MarshalIndent(fixture{
+		Task:     req.Task,
+		Model:    r.Provider.Model(),
+		Prompt:   req.Prompt,
+		Response: resp,
+	}, "", "  ")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		log.Printf("⚠️ Could not record LLM fixture: %v", err)
+	} else if err := os.WriteFile(path, b, 0o644); err != nil {
+		log.Printf("⚠️ Could not record LLM fixture: %v", err)
+	}
+	return resp, nil
+}
+
+// fixturePath hashes the prompt so the same prompt always maps to the same file
+func fixturePath(dir string, req Request) string {
+	sum := sha256.Sum256([]byte(string(req.Task) + "\n" + req.Prompt))
+	return filepath.Join(dir, string(req.Task), hex.EncodeToString(sum[:8])+".json")
+}
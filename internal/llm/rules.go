@@ -0,0 +1,257 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Rules is a deterministic, offline stand-in for the LLM.
+// It is nowhere near as smart as a model, but it is good enough for CI, demos
+// and running the tracker on a laptop without network access.
+type Rules struct{}
+
+func NewRules() *Rules {
+	return &Rules{}
+}
+
+func (r *Rules) Name() string  { return ProviderRules }
+func (r *Rules) Model() string { return "rules-v1" }
+
+func (r *Rules) Generate(ctx context.Context, req Request) (string, error) {
+	var out interface{}
+	switch req.Task {
+	case TaskExtractJob:
+		out = extractJobByRules(req.Inputs["raw_html"])
+	case TaskIdentifyRole:
+		out = map[string]int{"index": identifyRoleByRules(
+			strings.Split(req.Inputs["titles"], "\n"),
+			req.Inputs["subject"]+"\n"+req.Inputs["body"],
+		)}
+	case TaskAnalyzeEmail:
+		out = analyzeEmailByRules(req.Inputs["subject"], req.Inputs["body"])
+	default:
+		return "", fmt.Errorf("rules provider does not support task %q", req.Task)
+	}
+	b, err := json.Marshal(out)
+	return string(b), err
+}
+
+// --- EMAIL STATUS ---
+
+// Checked in order: the first rule with a matching phrase wins.
+// Rejections go first because they often contain "interview" too ("after your interview, unfortunately...").
+var emailStatusRules = []struct {
+	status     string
+	confidence float64
+	phrases    []string
+}{
+	{"REJECTED", 0.9, []string{"unfortunately", "not moving forward", "not be moving forward", "decided to move forward with other", "decided to pursue other", "will not be proceeding", "won't be proceeding", "position has been filled", "regret to inform"}},
+	{"OFFER", 0.85, []string{"pleased to offer", "happy to offer", "offer letter", "extend an offer", "extend you an offer", "job offer"}},
+	{"INTERVIEW", 0.8, []string{"technical interview", "onsite interview", "on-site interview", "final round", "next round", "interview loop", "schedule an interview", "invite you to interview", "invitation to interview", "coding interview", "system design interview"}},
+	{"SCREEN", 0.8, []string{"phone screen", "recruiter call", "introductory call", "quick chat", "intro call", "schedule a call", "screening call"}},
+	{"NO_CHANGE", 0.9, []string{"received your application", "thank you for applying", "thanks for applying", "application has been received", "we have received", "verify your email", "confirm your email"}},
+}
+
+func analyzeEmailByRules(subject, body string) map[string]interface{} {
+	text := strings.ToLower(subject + "\n" + body)
+	for _, rule := range emailStatusRules {
+		for _, phrase := range rule.phrases {
+			if strings.Contains(text, phrase) {
+				return map[string]interface{}{
+					"status":     rule.status,
+					"summary":    fmt.Sprintf("Rule match on %q: %s", phrase, truncateWords(subject, 10)),
+					"confidence": rule.confidence,
+				}
+			}
+		}
+	}
+	return map[string]interface{}{
+		"status":     "UNKNOWN",
+		"summary":    truncateWords(subject, 10),
+		"confidence": 0.3,
+	}
+}
+
+// --- ROLE IDENTIFICATION ---
+
+var wordRe = regexp.MustCompile(`[a-z0-9+#]+`)
+
+// Words that appear in almost every title and tell us nothing about which one is meant
+var titleStopWords = map[string]bool{
+	"engineer": true, "developer": true, "the": true, "and": true, "of": true, "for": true, "i": true, "ii": true, "iii": true, "-": true,
+}
+
+// identifyRoleByRules scores each title by how many of its distinctive words appear in the email.
+// A tie (or no match at all) means we can't tell, so -1.
+func identifyRoleByRules(titles []string, email string) int {
+	words := map[string]bool{}
+	for _, w := range wordRe.FindAllString(strings.ToLower(email), -1) {
+		words[w] = true
+	}
+
+	best, bestScore, tie := -1, 0, false
+	for i, title := range titles {
+		score := 0
+		for _, w := range wordRe.FindAllString(strings.ToLower(title), -1) {
+			if !titleStopWords[w] && words[w] {
+				score++
+			}
+		}
+		switch {
+		case score > bestScore:
+			best, bestScore, tie = i, score, false
+		case score == bestScore && score > 0:
+			tie = true
+		}
+	}
+	if tie {
+		return -1
+	}
+	return best
+}
+
+// --- JOB EXTRACTION ---
+
+var (
+	scriptStyleRe = regexp.MustCompile(`(?is)<(script|style|nav|footer|header|head)[^>]*>.*?</(script|style|nav|footer|header|head)>`)
+	tagRe         = regexp.MustCompile(`(?s)<[^>]+>`)
+	spaceRe       = regexp.MustCompile(`\s+`)
+	titleTagRe    = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	h1Re          = regexp.MustCompile(`(?is)<h1[^>]*>(.*?)</h1>`)
+	siteNameRe    = regexp.MustCompile(`(?i)<meta[^>]+property=["']og:site_name["'][^>]+content=["']([^"']+)["']`)
+	atCompanyRe   = regexp.MustCompile(`(?i)\bat\s+([A-Z][\w&.\- ]{1,40}?)(?:\s*[|\-–,(]|$)`)
+	locationRe    = regexp.MustCompile(`(?i)location\s*:?\s*([^\n|•]{2,60})`)
+	salaryRe      = regexp.MustCompile(`(?i)(?:[$€£₹]\s?\d[\d,.]*\s?[km]?(?:\s*(?:-|–|to)\s*[$€£₹]?\s?\d[\d,.]*\s?[km]?)?(?:\s*(?:/|per)\s*(?:year|yr|hour|hr|month))?|\d+\s*(?:-|to)\s*\d+\s*LPA)`)
+)
+
+// Tech keywords we look for in the posting (lower-case, matched on word boundaries)
+var knownTech = []string{
+	"Go", "Golang", "Python", "Java", "Kotlin", "Scala", "Rust", "C++", "C#", "TypeScript", "JavaScript", "Ruby", "PHP", "Swift", "Elixir",
+	"React", "Vue", "Angular", "Next.js", "Node.js", "Django", "Flask", "FastAPI", "Spring", "Rails", "Gin",
+	"PostgreSQL", "Postgres", "MySQL", "MongoDB", "Redis", "Kafka", "RabbitMQ", "Elasticsearch", "DynamoDB", "Cassandra", "SQLite",
+	"AWS", "GCP", "Azure", "Docker", "Kubernetes", "Terraform", "GraphQL", "gRPC", "Linux", "Spark", "Airflow", "PyTorch", "TensorFlow",
+}
+
+func extractJobByRules(rawHTML string) map[string]interface{} {
+	title := firstGroup(h1Re, rawHTML)
+	if title == "" {
+		title = firstGroup(titleTagRe, rawHTML)
+	}
+	title = cleanText(title)
+
+	company := cleanText(firstGroup(siteNameRe, rawHTML))
+	if company == "" {
+		company = strings.TrimSpace(firstGroup(atCompanyRe, cleanText(firstGroup(titleTagRe, rawHTML))))
+	}
+
+	text := htmlToText(rawHTML)
+
+	// Titles often look like "Backend Engineer at Stripe | Careers"
+	if i := strings.Index(strings.ToLower(title), " at "); i > 0 {
+		rest := strings.FieldsFunc(title[i+4:], func(r rune) bool { return r == '|' || r == '-' })
+		if company == "" && len(rest) > 0 {
+			company = strings.TrimSpace(rest[0])
+		}
+		title = strings.TrimSpace(title[:i])
+	}
+
+	var location interface{}
+	if loc := strings.TrimSpace(firstGroup(locationRe, text)); loc != "" {
+		location = loc
+	} else if strings.Contains(strings.ToLower(text), "remote") {
+		location = "Remote"
+	}
+
+	var salary interface{}
+	if m := salaryRe.FindString(text); m != "" {
+		salary = strings.TrimSpace(m)
+	}
+
+	description := text
+	if len([]rune(description)) > 1500 {
+		description = string([]rune(description)[:1500])
+	}
+
+	return map[string]interface{}{
+		"company_name": nullIfEmpty(company),
+		"role_title":   nullIfEmpty(title),
+		"location":     location,
+		"description":  nullIfEmpty(description),
+		"tech_stack":   findTech(text),
+		"salary_range": salary,
+	}
+}
+
+// techPatterns are the knownTech matchers, compiled once
+var techPatterns = compileTech(knownTech)
+
+type techPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+func compileTech(names []string) []techPattern {
+	patterns := make([]techPattern, len(names))
+	for i, tech := range names {
+		// Short names ("Go", "Gin") are also English words, so only match them with exact case
+		flags := "(?i)"
+		if len(tech) <= 3 {
+			flags = ""
+		}
+		patterns[i] = techPattern{name: tech, re: regexp.MustCompile(flags + `(^|[^\w+#.])` + regexp.QuoteMeta(tech) + `($|[^\w+#])`)}
+	}
+	return patterns
+}
+
+func findTech(text string) []string {
+	found := []string{}
+	for _, p := range techPatterns {
+		if p.re.MatchString(text) {
+			found = append(found, p.name)
+		}
+	}
+	return found
+}
+
+// htmlToText drops markup but keeps one line per block, so "Location: X" stays on its own line
+func htmlToText(s string) string {
+	s = scriptStyleRe.ReplaceAllString(s, " ")
+	s = html.UnescapeString(tagRe.ReplaceAllString(s, "\n"))
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(spaceRe.ReplaceAllString(line, " ")); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func cleanText(s string) string {
+	return strings.TrimSpace(spaceRe.ReplaceAllString(html.UnescapeString(tagRe.ReplaceAllString(s, " ")), " "))
+}
+
+func firstGroup(re *regexp.Regexp, s string) string {
+	if m := re.FindStringSubmatch(s); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func truncateWords(s string, n int) string {
+	words := strings.Fields(s)
+	if len(words) > n {
+		words = words[:n]
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,34 @@
+package llm
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFindTech(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"We use Go, PostgreSQL and Kubernetes.", []string{"Go", "PostgreSQL", "Kubernetes"}},
+		{"Ready to go? Join us!", []string{}}, // Short names only match with exact case
+		{"Experience with C++ and C# is a plus", []string{"C++", "C#"}},
+		{"Postgres or MySQL", []string{"Postgres", "MySQL"}},
+		{"Built with next.js on node.js", []string{"Next.js", "Node.js"}},
+		{"javascript, not java", []string{"Java", "JavaScript"}},
+		{"ScalaTest and Reactive streams", []string{}},
+	}
+	for _, tt := range tests {
+		if got := findTech(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("findTech(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkFindTech(b *testing.B) {
+	text := "We are hiring a Senior Backend Engineer to build our platform in Go and Python on AWS, " +
+		"with PostgreSQL, Redis and Kafka, deployed with Docker and Kubernetes."
+	for b.Loop() {
+		findTech(text)
+	}
+}
@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Schema is the small subset of JSON Schema we need to check model output:
+// types, required properties, enums, nullability and numeric bounds.
+type Schema struct {
+	Type       string // object | array | string | number | integer | boolean
+	Properties map[string]*Schema
+	Required   []string
+	Items      *Schema
+	Enum       []string
+	Nullable   bool
+	Minimum    *float64
+	Maximum    *float64
+}
+
+// SchemaError points at the first part of the document that doesn't match
+type SchemaError struct {
+	Path string
+	Msg  string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+}
+
+// Float is a helper for the Minimum / Maximum pointers
+func Float(v float64) *float64 {
+	return &v
+}
+
+// Validate parses data as JSON and checks it against the schema
+func (s *Schema) Validate(data []byte) error {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return &SchemaError{Path: "$", Msg: "not valid JSON: " + err.Error()}
+	}
+	return s.validate("$", doc)
+}
+
+func (s *Schema) validate(path string, v interface{}) error {
+	if v == nil {
+		if s.Nullable {
+			return nil
+		}
+		return &SchemaError{Path: path, Msg: "must not be null"}
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return typeError(path, s.Type, v)
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return &SchemaError{Path: path + "." + name, Msg: "is required"}
+			}
+		}
+		for name, prop := range s.Properties {
+			if val, ok := obj[name]; ok {
+				if err := prop.validate(path+"."+name, val); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			return typeError(path, s.Type, v)
+		}
+		if s.Items != nil {
+			for i, item := range arr {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return typeError(path, s.Type, v)
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, str) {
+			return &SchemaError{Path: path, Msg: fmt.Sprintf("%q is not one of %s", str, strings.Join(s.Enum, ", "))}
+		}
+	case "number", "integer":
+		num, ok := v.(float64)
+		if !ok {
+			return typeError(path, s.Type, v)
+		}
+		if s.Type == "integer" && num != float64(int64(num)) {
+			return &SchemaError{Path: path, Msg: "must be an integer"}
+		}
+		if s.Minimum != nil && num < *s.Minimum {
+			return &SchemaError{Path: path, Msg: fmt.Sprintf("must be >= %v", *s.Minimum)}
+		}
+		if s.Maximum != nil && num > *s.Maximum {
+			return &SchemaError{Path: path, Msg: fmt.Sprintf("must be <= %v", *s.Maximum)}
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return typeError(path, s.Type, v)
+		}
+	}
+	return nil
+}
+
+func typeError(path, want string, got interface{}) error {
+	return &SchemaError{Path: path, Msg: fmt.Sprintf("must be %s, got %T", want, got)}
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	schema := &Schema{
+		Type:     "object",
+		Required: []string{"status", "confidence"},
+		Properties: map[string]*Schema{
+			"status":     {Type: "string", Enum: []string{"APPLIED", "REJECTED"}},
+			"confidence": {Type: "number", Minimum: Float(0), Maximum: Float(1)},
+			"rounds":     {Type: "integer", Nullable: true},
+			"remote":     {Type: "boolean"},
+			"skills":     {Type: "array", Items: &Schema{Type: "string"}},
+		},
+	}
+
+	tests := []struct {
+		name, doc string
+		path      string // Where the error points, "" = valid
+	}{
+		{"minimal", `{"status": "APPLIED", "confidence": 0.5}`, ""},
+		{"full", `{"status": "REJECTED", "confidence": 1, "rounds": 3, "remote": true, "skills": ["go", "sql"]}`, ""},
+		{"nullable", `{"status": "APPLIED", "confidence": 0, "rounds": null}`, ""},
+		{"unknown properties are fine", `{"status": "APPLIED", "confidence": 0.5, "note": "hi"}`, ""},
+
+		{"not JSON", `{"status": `, "$"},
+		{"not an object", `["APPLIED"]`, "$"},
+		{"null document", `null`, "$"},
+		{"missing required", `{"status": "APPLIED"}`, "$.confidence"},
+		{"enum", `{"status": "GHOSTED", "confidence": 0.5}`, "$.status"},
+		{"enum is case sensitive", `{"status": "applied", "confidence": 0.5}`, "$.status"},
+		{"string type", `{"status": 1, "confidence": 0.5}`, "$.status"},
+		{"below minimum", `{"status": "APPLIED", "confidence": -0.1}`, "$.confidence"},
+		{"above maximum", `{"status": "APPLIED", "confidence": 1.5}`, "$.confidence"},
+		{"number as string", `{"status": "APPLIED", "confidence": "0.5"}`, "$.confidence"},
+		{"not nullable", `{"status": "APPLIED", "confidence": null}`, "$.confidence"},
+		{"integer", `{"status": "APPLIED", "confidence": 0.5, "rounds": 2.5}`, "$.rounds"},
+		{"boolean", `{"status": "APPLIED", "confidence": 0.5, "remote": "yes"}`, "$.remote"},
+		{"array type", `{"status": "APPLIED", "confidence": 0.5, "skills": "go"}`, "$.skills"},
+		{"array items", `{"status": "APPLIED", "confidence": 0.5, "skills": ["go", 3]}`, "$.skills[1]"},
+	}
+	for _, tt := range tests {
+		err := schema.Validate([]byte(tt.doc))
+		if tt.path == "" {
+			if err != nil {
+				t.Errorf("%s: %v, want valid", tt.name, err)
+			}
+			continue
+		}
+		var se *SchemaError
+		if !errors.As(err, &se) {
+			t.Errorf("%s: got %v, want a *SchemaError", tt.name, err)
+			continue
+		}
+		if se.Path != tt.path {
+			t.Errorf("%s: error at %s (%v), want %s", tt.name, se.Path, err, tt.path)
+		}
+	}
+}
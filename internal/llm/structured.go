@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ErrInvalidOutput is wrapped by every *OutputError so callers can use errors.Is
+var ErrInvalidOutput = errors.New("invalid LLM output")
+
+// OutputError means the model never produced a reply matching the schema
+type OutputError struct {
+	Task     Task
+	Attempts int
+	Raw      string // The last reply we got, for debugging / the review queue
+	Err      error  // Why the last reply was rejected
+}
+
+func (e *OutputError) Error() string {
+	return fmt.Sprintf("%s: no valid output after %d attempt(s): %v", e.Task, e.Attempts, e.Err)
+}
+
+func (e *OutputError) Unwrap() []error {
+	return []error{ErrInvalidOutput, e.Err}
+}
+
+// GenerateJSON asks the provider for a JSON answer, repairs the usual formatting slips,
+// validates it against schema and decodes it into out. A reply that still fails is sent
+// back to the model together with the error, at most maxAttempts times in total.
+// Provider (network) errors are returned as-is without re-asking.
+func GenerateJSON(ctx context.Context, p Provider, req Request, schema *Schema, out interface{}, maxAttempts int) error {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	prompt := req.Prompt
+	var raw string
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		attemptReq := req
+		attemptReq.Prompt = prompt
+
+		var err error
+		raw, err = p.Generate(ctx, attemptReq)
+		if err != nil {
+			return err
+		}
+
+		cleaned := Repair(raw)
+		if lastErr = schema.Validate([]byte(cleaned)); lastErr == nil {
+			if lastErr = json.Unmarshal([]byte(cleaned), out); lastErr == nil {
+				return nil
+			}
+		}
+
+		log.Printf("⚠️ LLM %s reply rejected (attempt %d/%d): %v", req.Task, attempt, maxAttempts, lastErr)
+		prompt = req.Prompt + fmt.Sprintf(`
+
+### YOUR PREVIOUS ANSWER WAS INVALID
+Error: %s
+Previous answer:
+%s
+
+Return ONLY the corrected JSON object, with no explanation and no Markdown.`, lastErr, raw)
+	}
+
+	return &OutputError{Task: req.Task, Attempts: maxAttempts, Raw: raw, Err: lastErr}
+}
+
+// Repair fixes the formatting mistakes models make most often:
+// Markdown code fences, chatter around the object, and trailing commas.
+func Repair(raw string) string {
+	s := strings.TrimSpace(raw)
+	s = strings.TrimPrefix(s, "```json")
+	s = strings.TrimPrefix(s, "```")
+	s = strings.TrimSuffix(s, "```")
+	s = strings.TrimSpace(s)
+
+	// "Here is the JSON: {...} Hope this helps" -> {...}
+	if start, end := strings.Index(s, "{"), strings.LastIndex(s, "}"); start >= 0 && end > start {
+		s = s[start : end+1]
+	}
+
+	return removeTrailingCommas(s)
+}
+
+// removeTrailingCommas drops "," before "}" or "]" while leaving string contents alone
+func removeTrailingCommas(s string) string {
+	var b strings.Builder
+	inString, escaped := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			b.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		if c == '"' {
+			inString = true
+		}
+		if c == ',' {
+			// Look ahead past whitespace for a closing bracket
+			j := i + 1
+			for j < len(s) && strings.ContainsRune(" \t\r\n", rune(s[j])) {
+				j++
+			}
+			if j < len(s) && (s[j] == '}' || s[j] == ']') {
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// scriptedProvider answers with its replies in order and records the prompts it got
+type scriptedProvider struct {
+	replies []string
+	err     error // Returned instead of a reply once the script runs out, when set
+	prompts []string
+}
+
+func (p *scriptedProvider) Name() string  { return "scripted" }
+func (p *scriptedProvider) Model() string { return "scripted-1" }
+
+func (p *scriptedProvider) Generate(ctx context.Context, req Request) (string, error) {
+	p.prompts = append(p.prompts, req.Prompt)
+	if len(p.prompts) > len(p.replies) {
+		if p.err != nil {
+			return "", p.err
+		}
+		return p.replies[len(p.replies)-1], nil
+	}
+	return p.replies[len(p.prompts)-1], nil
+}
+
+var verdictSchema = &Schema{
+	Type:     "object",
+	Required: []string{"status", "confidence"},
+	Properties: map[string]*Schema{
+		"status":     {Type: "string", Enum: []string{"APPLIED", "INTERVIEW", "REJECTED"}},
+		"confidence": {Type: "number", Minimum: Float(0), Maximum: Float(1)},
+	},
+}
+
+type verdict struct {
+	Status     string  `json:"status"`
+	Confidence float64 `json:"confidence"`
+}
+
+func TestGenerateJSON(t *testing.T) {
+	req := Request{Task: TaskAnalyzeEmail, Prompt: "Classify this email."}
+
+	t.Run("repairs the reply", func(t *testing.T) {
+		p := &scriptedProvider{replies: []string{"```json\n{\"status\": \"INTERVIEW\", \"confidence\": 0.9,}\n```"}}
+		var got verdict
+		if err := GenerateJSON(context.Background(), p, req, verdictSchema, &got, 3); err != nil {
+			t.Fatal(err)
+		}
+		if got != (verdict{"INTERVIEW", 0.9}) || len(p.prompts) != 1 {
+			t.Errorf("got %+v after %d calls", got, len(p.prompts))
+		}
+	})
+
+	t.Run("asks again with the error", func(t *testing.T) {
+		p := &scriptedProvider{replies: []string{
+			`{"status": "GHOSTED", "confidence": 0.9}`,
+			`{"status": "REJECTED", "confidence": 0.8}`,
+		}}
+		var got verdict
+		if err := GenerateJSON(context.Background(), p, req, verdictSchema, &got, 3); err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != "REJECTED" || len(p.prompts) != 2 {
+			t.Errorf("got %+v after %d calls", got, len(p.prompts))
+		}
+		retry := p.prompts[1]
+		if !strings.HasPrefix(retry, req.Prompt) || !strings.Contains(retry, `"GHOSTED" is not one of`) || !strings.Contains(retry, p.replies[0]) {
+			t.Errorf("the retry prompt lacks the prompt, the error or the previous answer:\n%s", retry)
+		}
+	})
+
+	t.Run("gives up after maxAttempts", func(t *testing.T) {
+		p := &scriptedProvider{replies: []string{`{"status": "APPLIED"}`, `{"status": "APPLIED", "confidence": 7}`}}
+		var got verdict
+		err := GenerateJSON(context.Background(), p, req, verdictSchema, &got, 2)
+		var oe *OutputError
+		if !errors.As(err, &oe) {
+			t.Fatalf("got %v, want an *OutputError", err)
+		}
+		if len(p.prompts) != 2 || oe.Attempts != 2 || oe.Task != TaskAnalyzeEmail {
+			t.Errorf("%d calls, error %+v", len(p.prompts), oe)
+		}
+		if oe.Raw != p.replies[1] {
+			t.Errorf("Raw = %q, want the last reply %q", oe.Raw, p.replies[1])
+		}
+		var se *SchemaError
+		if !errors.Is(err, ErrInvalidOutput) || !errors.As(err, &se) || se.Path != "$.confidence" {
+			t.Errorf("error %v doesn't wrap ErrInvalidOutput and the last schema error", err)
+		}
+	})
+
+	t.Run("asks at least once", func(t *testing.T) {
+		p := &scriptedProvider{replies: []string{"Sorry, I can't help with that."}}
+		var got verdict
+		err := GenerateJSON(context.Background(), p, req, verdictSchema, &got, 0)
+		if !errors.Is(err, ErrInvalidOutput) || len(p.prompts) != 1 {
+			t.Errorf("got %v after %d calls, want ErrInvalidOutput after 1", err, len(p.prompts))
+		}
+	})
+
+	t.Run("provider errors aren't retried", func(t *testing.T) {
+		down := errors.New("503 service unavailable")
+		p := &scriptedProvider{err: down}
+		var got verdict
+		err := GenerateJSON(context.Background(), p, req, verdictSchema, &got, 3)
+		if !errors.Is(err, down) || errors.Is(err, ErrInvalidOutput) || len(p.prompts) != 1 {
+			t.Errorf("got %v after %d calls, want the provider error after 1", err, len(p.prompts))
+		}
+	})
+}
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		name, raw, want string
+	}{
+		{"clean", `{"a": 1}`, `{"a": 1}`},
+		{"json fence", "```json\n{\"a\": 1}\n```", `{"a": 1}`},
+		{"bare fence", "```\n{\"a\": 1}\n```", `{"a": 1}`},
+		{"chatter", "Here is the JSON: {\"a\": 1} Hope this helps!", `{"a": 1}`},
+		{"trailing commas", "{\"a\": [1, 2,], \"b\": {\"c\": 3,\n},\n}", "{\"a\": [1, 2], \"b\": {\"c\": 3\n}\n}"},
+		{"commas in strings stay", `{"a": "x,}", "b": "y\",]",}`, `{"a": "x,}", "b": "y\",]"}`},
+	}
+	for _, tt := range tests {
+		if got := Repair(tt.raw); got != tt.want {
+			t.Errorf("%s: Repair(%q) = %q, want %q", tt.name, tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package mailbody
+
+import (
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// headerDecoder reads encoded words in every charset the HTML spec knows, not just UTF-8 and Latin-1
+var headerDecoder = &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+
+// DecodeHeader decodes the RFC 2047 encoded words of a header value ("=?iso-2022-jp?B?...?=").
+// A value that doesn't decode is returned as it is.
+func DecodeHeader(v string) string {
+	decoded, err := headerDecoder.DecodeHeader(v)
+	if err != nil {
+		return v
+	}
+	return decoded
+}
+
+// decodeCharset turns text in the named charset into UTF-8. Without a charset we know,
+// HTML is sniffed for a <meta> charset, and anything else is taken as UTF-8 when it is
+// valid UTF-8 and as Windows-1252 (the superset of Latin-1 mailers mean) otherwise.
+func decodeCharset(data []byte, name string, isHTML bool) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	enc, _ := charset.Lookup(name)
+	switch {
+	case name == "us-ascii" || name == "ascii":
+		// Mailers declare ASCII and send UTF-8 all the time
+		enc = nil
+	case enc == nil && isHTML:
+		enc, _, _ = charset.DetermineEncoding(data, "text/html")
+	}
+	if enc == nil {
+		if utf8.Valid(data) {
+			return string(data)
+		}
+		enc = charmap.Windows1252
+	}
+	text, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return strings.ToValidUTF8(string(data), "\ufffd")
+	}
+	return string(text)
+}
@@ -0,0 +1,205 @@
+package mailbody
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLToText renders an HTML body as the text a mail client shows: hidden content is
+// dropped, blocks and <br> break lines and list items get a dash. Quoted replies
+// (<blockquote>, Gmail's quote container) come out as "> " lines and signatures after a
+// "-- " line, like in a plain text mail, so Reply can strip them.
+func HTMLToText(doc string) string {
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		return doc
+	}
+	var r htmlRenderer
+	r.render(root)
+	return r.b.String()
+}
+
+type htmlRenderer struct {
+	b        strings.Builder
+	newlines int // Line breaks at the end of b, spaces aside
+	pre      int // Depth of <pre> elements, whose whitespace is kept
+}
+
+func (r *htmlRenderer) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		r.children(n) // Document, doctype, comments (Outlook's conditional ones are skipped with them)
+		return
+	}
+
+	if hidden(n) {
+		return
+	}
+	switch {
+	case n.DataAtom == atom.Br:
+		r.write("\n")
+	case n.DataAtom == atom.Hr:
+		r.breakLines(2)
+	case n.DataAtom == atom.Li:
+		r.breakLines(1)
+		r.write("- ")
+		r.children(n)
+		r.breakLines(1)
+	case n.DataAtom == atom.Td || n.DataAtom == atom.Th:
+		r.children(n)
+		r.write(" ")
+	case n.DataAtom == atom.Pre:
+		r.breakLines(1)
+		r.pre++
+		r.children(n)
+		r.pre--
+		r.breakLines(1)
+	case quote(n):
+		var inner htmlRenderer
+		inner.children(n)
+		text := normalizeSpace(inner.b.String())
+		r.breakLines(1)
+		if forwarded.MatchString(text) {
+			// Gmail puts forwarded mail in its quote container too, but that is the content
+			r.write(text)
+		} else {
+			for _, line := range strings.Split(text, "\n") {
+				r.write(strings.TrimRight("> "+line, " ") + "\n")
+			}
+		}
+		r.breakLines(1)
+	case signature(n):
+		r.breakLines(1)
+		r.write("-- \n")
+		r.children(n)
+		r.breakLines(1)
+	case paragraphs[n.DataAtom]:
+		r.breakLines(2)
+		r.children(n)
+		r.breakLines(2)
+	case blocks[n.DataAtom]:
+		r.breakLines(1)
+		r.children(n)
+		r.breakLines(1)
+	default:
+		r.children(n)
+	}
+}
+
+// write adds text and keeps count of the line breaks it ends with
+func (r *htmlRenderer) write(s string) {
+	r.b.WriteString(s)
+	if rest := strings.TrimRight(s, " \n"); rest != "" {
+		r.newlines = 0
+		s = s[len(rest):]
+	}
+	r.newlines += strings.Count(s, "\n")
+}
+
+// breakLines ends the current line and, for n = 2, leaves a blank one
+func (r *htmlRenderer) breakLines(n int) {
+	for r.newlines < n {
+		r.write("\n")
+	}
+}
+
+func (r *htmlRenderer) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.render(c)
+	}
+}
+
+// text writes a text node, whitespace collapses like in a browser outside <pre>
+func (r *htmlRenderer) text(s string) {
+	if r.pre > 0 {
+		r.write(s)
+		return
+	}
+	collapsed := strings.Join(strings.Fields(s), " ")
+	if collapsed == "" {
+		if s != "" {
+			r.write(" ")
+		}
+		return
+	}
+	if s[0] != collapsed[0] {
+		collapsed = " " + collapsed
+	}
+	if s[len(s)-1] != collapsed[len(collapsed)-1] {
+		collapsed += " "
+	}
+	r.write(collapsed)
+}
+
+// Elements that start and end a paragraph (a blank line) or a line
+var (
+	paragraphs = map[atom.Atom]bool{
+		atom.P: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+		atom.Table: true, atom.Ul: true, atom.Ol: true,
+	}
+	blocks = map[atom.Atom]bool{
+		atom.Div: true, atom.Tr: true, atom.Section: true, atom.Article: true, atom.Header: true,
+		atom.Footer: true, atom.Center: true, atom.Address: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+		atom.Form: true, atom.Fieldset: true, atom.Main: true, atom.Nav: true, atom.Aside: true,
+	}
+	invisible = map[atom.Atom]bool{
+		atom.Head: true, atom.Title: true, atom.Script: true, atom.Style: true, atom.Noscript: true,
+		atom.Template: true, atom.Img: true, atom.Svg: true, atom.Object: true, atom.Iframe: true,
+	}
+)
+
+// hidden reports elements a reader never sees, e.g. the preview text newsletters and ATS
+// mails put in a display:none span
+func hidden(n *html.Node) bool {
+	if invisible[n.DataAtom] {
+		return true
+	}
+	for _, a := range n.Attr {
+		switch a.Key {
+		case "hidden":
+			return true
+		case "style":
+			style := strings.ToLower(strings.ReplaceAll(a.Val, " ", ""))
+			if strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// quote reports the containers mail clients put a quoted message in
+func quote(n *html.Node) bool {
+	return n.DataAtom == atom.Blockquote || hasClass(n, "gmail_quote", "yahoo_quoted")
+}
+
+// signature reports the containers mail clients put the sender's signature in
+func signature(n *html.Node) bool {
+	return hasClass(n, "gmail_signature", "moz-signature") || attr(n, "id") == "Signature" || attr(n, "data-smartmail") == "gmail_signature"
+}
+
+func hasClass(n *html.Node, classes ...string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		for _, want := range classes {
+			if c == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
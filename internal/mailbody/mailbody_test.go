@@ -0,0 +1,113 @@
+package mailbody
+
+import (
+	"flag"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestGolden reads every testdata/<name>.eml and compares its text with <name>.txt and
+// what's left of it after Reply with <name>.reply.txt. Run with -update after a
+// deliberate change and review the diff.
+func TestGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no testdata/*.eml files")
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(file, ".eml")
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			text, err := readText(t, file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden(t, name+".txt", text)
+			golden(t, name+".reply.txt", Reply(text))
+		})
+	}
+}
+
+func TestTextUndecodable(t *testing.T) {
+	// The only text part is broken, that's an error rather than an empty body
+	file := filepath.Join(t.TempDir(), "broken.eml")
+	raw := "Content-Type: text/plain\r\nContent-Transfer-Encoding: base64\r\n\r\n!!!not base64!!!\r\n"
+	if err := os.WriteFile(file, []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if text, err := readText(t, file); err == nil {
+		t.Fatalf("got %q, want an error", text)
+	}
+}
+
+func TestReplyOnlyQuotes(t *testing.T) {
+	// Nothing new in it, so the quote is what there is to read
+	if got := Reply("> Can we do Thursday?"); got != "> Can we do Thursday?" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got, cut := Truncate("héllo wörld", 2); got != "hé" || !cut {
+		t.Errorf("got %q, %v", got, cut)
+	}
+	if got, cut := Truncate("日本", 5); got != "日本" || cut {
+		t.Errorf("got %q, %v", got, cut)
+	}
+}
+
+func TestDecodeHeader(t *testing.T) {
+	cases := map[string]string{
+		"=?iso-2022-jp?B?GyRCRnxLXBsoQg==?=":          "日本",
+		"=?iso-8859-1?q?J=FCrgen_M=FCller?= <j@x.de>": "Jürgen Müller <j@x.de>",
+		"=?unknown?q?broken":                          "=?unknown?q?broken",
+	}
+	for in, want := range cases {
+		if got := DecodeHeader(in); got != want {
+			t.Errorf("DecodeHeader(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func readText(t *testing.T, file string) (string, error) {
+	t.Helper()
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	msg, err := mail.ReadMessage(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part, err := Parse(msg.Header, msg.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return part.Text()
+}
+
+// golden compares got with the file, the file ends in a newline got doesn't have
+func golden(t *testing.T, file, got string) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(file, []byte(got+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != strings.TrimSuffix(string(want), "\n") {
+		t.Errorf("%s differs:\n--- got ---\n%s\n--- want ---\n%s", file, got, want)
+	}
+}
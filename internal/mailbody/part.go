@@ -0,0 +1,184 @@
+package mailbody
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"strings"
+)
+
+// Header is the part of a MIME header Parse reads (mail.Header, textproto.MIMEHeader)
+type Header interface {
+	Get(key string) string
+}
+
+// Part is a node of a message's MIME tree
+type Part struct {
+	MediaType  string            // Lower case, e.g. "text/plain"
+	Params     map[string]string // Content-Type parameters, e.g. charset
+	Attachment bool              // Content-Disposition: attachment, never read as body text
+	Data       []byte            // Content of text parts, transfer encoding already decoded
+	Err        error             // Why Data couldn't be decoded
+	Parts      []*Part           // Children of multipart parts
+}
+
+// NewPart makes a part from its Content-Type and Content-Disposition headers
+func NewPart(contentType, disposition string) *Part {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "" {
+		mediaType, params = "text/plain", map[string]string{} // The RFC 2045 default
+	}
+	p := &Part{MediaType: strings.ToLower(mediaType), Params: params}
+	if d, _, err := mime.ParseMediaType(disposition); err == nil && strings.EqualFold(d, "attachment") {
+		p.Attachment = true
+	}
+	return p
+}
+
+// Parse reads a MIME entity, nested multiparts included. Only text parts are kept in
+// memory. A text part that doesn't decode is kept with Err set, so one broken
+// alternative doesn't cost the others; a broken multipart structure fails the whole parse.
+func Parse(h Header, r io.Reader) (*Part, error) {
+	p := NewPart(h.Get("Content-Type"), h.Get("Content-Disposition"))
+
+	if strings.HasPrefix(p.MediaType, "multipart/") {
+		mr := multipart.NewReader(r, p.Params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", p.MediaType, err)
+			}
+			child, err := Parse(part.Header, part)
+			if err != nil {
+				return nil, err
+			}
+			p.Parts = append(p.Parts, child)
+		}
+		return p, nil
+	}
+
+	if !p.isText() {
+		return p, nil // Attachments, images, ...
+	}
+	p.Data, p.Err = io.ReadAll(decodeTransfer(h.Get("Content-Transfer-Encoding"), r))
+	if p.Err != nil {
+		p.Err = fmt.Errorf("%s part: %w", p.MediaType, p.Err)
+	}
+	return p, nil
+}
+
+// isText reports whether the part can be the body of the message
+func (p *Part) isText() bool {
+	return !p.Attachment && (p.MediaType == "text/plain" || p.MediaType == "text/html")
+}
+
+// Text is the readable text of the part: the plain text alternative when there is one,
+// converted HTML otherwise, and the inline parts of a mixed multipart one after the other.
+// Its whitespace is normalized like Normalize's and it still has quoted replies, see Reply.
+// The error is only set when there was text but none of it could be decoded.
+func (p *Part) Text() (string, error) {
+	var errs []error
+	text := p.text(&errs)
+	if text == "" && len(errs) > 0 {
+		return "", errors.Join(errs...)
+	}
+	// The HTML is rendered already, normalizing must not read the text as HTML again
+	return normalizeSpace(text), nil
+}
+
+func (p *Part) text(errs *[]error) string {
+	switch {
+	case p.MediaType == "multipart/alternative":
+		// Plain text first, then the first alternative that has any text (HTML, related, ...)
+		for _, child := range p.Parts {
+			if child.MediaType == "text/plain" {
+				if text := child.text(errs); strings.TrimSpace(text) != "" {
+					return text
+				}
+			}
+		}
+		for _, child := range p.Parts {
+			if child.MediaType == "text/plain" {
+				continue
+			}
+			if text := child.text(errs); strings.TrimSpace(text) != "" {
+				return text
+			}
+		}
+		return ""
+	case p.MediaType == "multipart/related":
+		// The first part is the document, the others are what it embeds
+		if len(p.Parts) == 0 {
+			return ""
+		}
+		return p.Parts[0].text(errs)
+	case strings.HasPrefix(p.MediaType, "multipart/"):
+		// mixed and the unknown kinds: text split around inline images and the like
+		var texts []string
+		for _, child := range p.Parts {
+			if text := child.text(errs); strings.TrimSpace(text) != "" {
+				texts = append(texts, text)
+			}
+		}
+		return strings.Join(texts, "\n\n")
+	case !p.isText():
+		return ""
+	case p.Err != nil:
+		*errs = append(*errs, p.Err)
+		return ""
+	}
+
+	text := decodeCharset(p.Data, p.Params["charset"], p.MediaType == "text/html")
+	if p.MediaType == "text/html" {
+		return HTMLToText(text)
+	}
+	if strings.EqualFold(p.Params["format"], "flowed") {
+		text = unflow(text, strings.EqualFold(p.Params["delsp"], "yes"))
+	}
+	return text
+}
+
+func decodeTransfer(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		// The decoder skips the line breaks itself
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// unflow joins the lines of a format=flowed text (RFC 3676): a line ending in a space
+// continues on the next one, whose quote markers and stuffed space are dropped
+func unflow(text string, delSp bool) string {
+	var b strings.Builder
+	joining := false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		depth := 0
+		for depth < len(line) && line[depth] == '>' {
+			depth++
+		}
+		content := strings.TrimPrefix(line[depth:], " ")
+		if !joining && depth > 0 {
+			b.WriteString(line[:depth] + " ")
+		}
+		flowed := content != "-- " && strings.HasSuffix(content, " ")
+		if flowed && delSp {
+			content = content[:len(content)-1]
+		}
+		b.WriteString(content)
+		if joining = flowed; !joining {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package mailbody
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// "On Mon, 5 Oct 2026 at 10:00, Jane <jane@acme.com> wrote:" and its translations
+	attribution = regexp.MustCompile(`(?i)^(on|le|am|el|op|il)\s.+\s(wrote|a écrit|schrieb|escribió|schreef|ha scritto)\s?:$`)
+	// Outlook's plain text reply separators
+	originalMessage = regexp.MustCompile(`(?i)^-{2,}\s*(original message|ursprüngliche nachricht|message d'origine|mensaje original)\s*-{2,}$`)
+	underscores     = regexp.MustCompile(`^_{8,}$`)
+	// The header block Outlook quotes the original message with ("From: ...", then "Sent: ...")
+	headerFrom = regexp.MustCompile(`(?i)^\*?(from|von|de)\s?:`)
+	headerSent = regexp.MustCompile(`(?i)^\*?(sent|date|gesendet|envoyé)\s?:`)
+	// Forwarded mail is what the sender wants us to read, it's never stripped
+	forwarded    = regexp.MustCompile(`(?im)^-{2,}\s*(forwarded message|weitergeleitete nachricht|message transféré)\s*-{2,}$|^begin forwarded message:$`)
+	mobileFooter = regexp.MustCompile(`(?i)^(sent from my \S+|sent from (mail|outlook) for \S+|get outlook for (ios|android))`)
+)
+
+// Reply strips what a normalized body repeats from earlier mail: quoted lines ("> "),
+// everything from the "On ... wrote:" line or Outlook's original message header on, and
+// the signature after a "-- " line or a "Sent from my iPhone" footer. Inline answers
+// between quoted lines are kept, and so is forwarded mail. A body that is nothing but
+// quotes comes back unchanged.
+func Reply(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := make([]string, 0, len(lines))
+cut:
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		next := ""
+		if i+1 < len(lines) {
+			next = strings.TrimSpace(lines[i+1])
+		}
+		switch {
+		case forwarded.MatchString(line):
+			kept = append(kept, lines[i:]...)
+			break cut
+		case strings.HasPrefix(line, ">"):
+			continue
+		case line == "--", mobileFooter.MatchString(line):
+			break cut
+		case attribution.MatchString(line), attribution.MatchString(line + " " + next):
+			// Clients wrap long attribution lines
+			break cut
+		case originalMessage.MatchString(line), underscores.MatchString(line) && headerFrom.MatchString(next):
+			break cut
+		case headerFrom.MatchString(line) && quotedHeader(lines[i+1:]):
+			break cut
+		}
+		kept = append(kept, lines[i])
+	}
+
+	reply := normalizeSpace(strings.Join(kept, "\n"))
+	if reply == "" {
+		return text
+	}
+	return reply
+}
+
+// quotedHeader reports whether the lines after a "From:" line continue a quoted message
+// header: a "Sent:" or "Date:" within the next few lines
+func quotedHeader(lines []string) bool {
+	for i := 0; i < len(lines) && i < 3; i++ {
+		if headerSent.MatchString(strings.TrimSpace(lines[i])) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package mailbody
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlHint    = regexp.MustCompile(`(?i)<(html|body|div|p|br|table|span)[\s/>]`)
+	blankLines  = regexp.MustCompile(`\n{3,}`)
+	innerSpaces = regexp.MustCompile(`[ \t\p{Zs}]+`)
+	// Zero-width characters and soft hyphens, HTML mails pad their preview text with them
+	invisibleRunes = strings.NewReplacer("\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "", "\u00ad", "", "\u034f", "")
+)
+
+// Normalize turns a raw body of unknown kind into plain text: HTML is rendered as text
+// (see HTMLToText), line endings are \n, runs of spaces are one space and there's at most
+// one blank line in a row. Text that came out of Part.Text is normalized already, running
+// it through here again would take a mention of "<p>" for HTML.
+func Normalize(body string) string {
+	if htmlHint.MatchString(body) {
+		body = HTMLToText(body)
+	}
+	return normalizeSpace(body)
+}
+
+func normalizeSpace(text string) string {
+	text = strings.ToValidUTF8(text, "\ufffd")
+	text = invisibleRunes.Replace(text)
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(innerSpaces.ReplaceAllString(line, " "))
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// Truncate keeps the first n characters of s and reports whether that cut anything off.
+// Unlike slicing bytes it never cuts a character in half.
+func Truncate(s string, n int) (string, bool) {
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i], true
+		}
+		count++
+	}
+	return s, false
+}
@@ -0,0 +1,116 @@
+package mailsource
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReadMbox calls fn for every message of an mbox archive (mboxo or mboxrd, e.g. Google Takeout).
+// Messages that can't be parsed are passed to fn as errors, the rest of the archive is still read.
+func ReadMbox(r io.Reader, fn func(*Message, error)) error {
+	br := bufio.NewReader(r)
+	var raw bytes.Buffer
+	started, blank := false, true
+
+	flush := func() {
+		if started {
+			fn(ReadEML(bytes.NewReader(raw.Bytes())))
+		}
+		raw.Reset()
+	}
+
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			switch {
+			// A "From " line after a blank line (or at the top) starts the next message
+			case blank && bytes.HasPrefix(line, []byte("From ")):
+				flush()
+				started = true
+			case started:
+				// mboxrd escapes body lines starting with "From " as ">From ", ">>From "...
+				if trimmed := bytes.TrimLeft(line, ">"); len(trimmed) < len(line) && bytes.HasPrefix(trimmed, []byte("From ")) {
+					line = line[1:]
+				}
+				raw.Write(line)
+			}
+			blank = len(bytes.TrimRight(line, "\r\n")) == 0
+		}
+		if err == io.EOF {
+			flush()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ReadEML parses one .eml file. Messages without a Message-ID are keyed by a hash of their content,
+// so importing the same file twice still dedups.
+func ReadEML(r io.Reader) (*Message, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	return ParseRFC822(bytes.NewReader(raw), "sha256:"+hex.EncodeToString(sum[:16]))
+}
+
+// ReadPath reads an .mbox file, an .eml file, or a directory of them (recursively)
+func ReadPath(path string, fn func(*Message, error)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return readFile(path, fn)
+	}
+	return filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !IsArchiveName(p) {
+			return nil
+		}
+		return readFile(p, fn)
+	})
+}
+
+// IsArchiveName reports whether the file name looks like something ReadPath can read
+func IsArchiveName(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".eml", ".mbox", ".mbx":
+		return true
+	}
+	return false
+}
+
+// ReadArchive reads one .eml or mbox file, telling them apart by name
+func ReadArchive(name string, r io.Reader, fn func(*Message, error)) error {
+	if strings.EqualFold(filepath.Ext(name), ".eml") {
+		fn(ReadEML(r))
+		return nil
+	}
+	return ReadMbox(r, fn)
+}
+
+func readFile(path string, fn func(*Message, error)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := ReadArchive(path, f, fn); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
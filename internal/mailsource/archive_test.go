@@ -0,0 +1,102 @@
+package mailsource
+
+import (
+	"strings"
+	"testing"
+)
+
+// readAll collects what ReadArchive hands out, failing on unparseable messages
+func readAll(t *testing.T, name, archive string) []*Message {
+	t.Helper()
+	var msgs []*Message
+	err := ReadArchive(name, strings.NewReader(archive), func(msg *Message, err error) {
+		if err != nil {
+			t.Fatalf("reading a message of %s: %v", name, err)
+		}
+		msgs = append(msgs, msg)
+	})
+	if err != nil {
+		t.Fatalf("reading %s: %v", name, err)
+	}
+	return msgs
+}
+
+func TestReadMbox(t *testing.T) {
+	archive := strings.Join([]string{
+		"From hr@acme.com Mon Jan  6 10:00:00 2025",
+		"Message-ID: <1@acme.com>",
+		"Subject: Interview invite",
+		"",
+		"Hi,",
+		"From the team at Acme: see you Monday.", // Not after a blank line, so no separator
+		"",
+		">From now on, we're hiring.", // mboxrd escapes
+		">>From here it stays quoted.",
+		"> From isn't escaped.",
+		"",
+		"From recruiter@globex.com Tue Jan  7 10:00:00 2025",
+		"Subject: No id",
+		"",
+		"Thanks for applying.",
+		"",
+		"From recruiter@globex.com Tue Jan  7 11:00:00 2025",
+		"Subject: No id either",
+		"",
+		"Sorry.",
+		"",
+	}, "\n")
+
+	msgs := readAll(t, "takeout.mbox", archive)
+	if len(msgs) != 3 {
+		t.Fatalf("got %d messages, want 3", len(msgs))
+	}
+
+	first := msgs[0]
+	if first.ID != "<1@acme.com>" || first.Header("Subject") != "Interview invite" {
+		t.Errorf("first message is %q %q", first.ID, first.Header("Subject"))
+	}
+	for _, want := range []string{
+		"From the team at Acme: see you Monday.",
+		"\nFrom now on, we're hiring.",
+		"\n>From here it stays quoted.",
+		"\n> From isn't escaped.",
+	} {
+		if !strings.Contains(first.Body, want) {
+			t.Errorf("body lacks %q:\n%s", want, first.Body)
+		}
+	}
+
+	// Without a Message-ID the content is the key
+	for _, msg := range msgs[1:] {
+		if !strings.HasPrefix(msg.ID, "sha256:") {
+			t.Errorf("message %q has ID %q, want a sha256 one", msg.Header("Subject"), msg.ID)
+		}
+	}
+	if msgs[1].ID == msgs[2].ID {
+		t.Errorf("different messages share ID %q", msgs[1].ID)
+	}
+}
+
+func TestReadEMLDedup(t *testing.T) {
+	eml := "Subject: Thanks for applying\r\nFrom: jobs@acme.com\r\n\r\nWe got your application.\r\n"
+
+	first := readAll(t, "a.eml", eml)
+	again := readAll(t, "copy.eml", eml)
+	if len(first) != 1 || len(again) != 1 {
+		t.Fatalf("got %d and %d messages, want 1 each", len(first), len(again))
+	}
+	if first[0].ID != again[0].ID {
+		t.Errorf("the same file got IDs %q and %q, importing it twice wouldn't dedup", first[0].ID, again[0].ID)
+	}
+
+	// The same message inside an mbox gets the same key too
+	mbox := readAll(t, "a.mbox", "From jobs@acme.com Mon Jan  6 10:00:00 2025\r\n"+eml)
+	if len(mbox) != 1 || mbox[0].ID != first[0].ID {
+		t.Errorf("the mbox copy got %v, want ID %q", mbox, first[0].ID)
+	}
+
+	other := readAll(t, "b.eml", strings.Replace(eml, "We got", "We received", 1))
+	if other[0].ID == first[0].ID {
+		t.Errorf("different content shares ID %q", first[0].ID)
+	}
+}
@@ -0,0 +1,93 @@
+package mailsource
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultWindowDays is how far back the first sync of a mailbox looks
+const DefaultWindowDays = 7
+
+// DefaultKeywords narrow the sync to mail that's likely about an application
+var DefaultKeywords = []string{"application", "interview", "update", "offer", "rejected", "status"}
+
+// Filter picks the mail a bootstrap or a backfill looks at. The incremental sync takes
+// everything new, the matcher decides what's relevant.
+type Filter struct {
+	Keywords []string  // Any of them in the subject (empty = DefaultKeywords)
+	Labels   []string  // Any of these Gmail labels (empty = all mail). IMAP syncs one folder and ignores them.
+	After    time.Time // Received at or after (zero = no lower bound)
+	Before   time.Time // Received before (zero = no upper bound)
+}
+
+func (f Filter) keywords() []string {
+	if len(f.Keywords) == 0 {
+		return DefaultKeywords
+	}
+	return f.Keywords
+}
+
+// Matches applies the subject keywords and the date range to a message that's already loaded,
+// for bulk imports. Labels only exist in Gmail and aren't checked.
+func (f Filter) Matches(m *Message) bool {
+	if !f.InRange(m) {
+		return false
+	}
+	subject := strings.ToLower(m.Header("Subject"))
+	for _, word := range f.keywords() {
+		if strings.Contains(subject, strings.ToLower(word)) {
+			return true
+		}
+	}
+	return false
+}
+
+// InRange applies only the date range, messages without a date are in it
+func (f Filter) InRange(m *Message) bool {
+	if m.Date.IsZero() {
+		return true
+	}
+	if !f.After.IsZero() && m.Date.Before(f.After) {
+		return false
+	}
+	return f.Before.IsZero() || m.Date.Before(f.Before)
+}
+
+// gmailQuery turns the filter into Gmail search syntax, e.g.
+// `subject:(offer OR "next steps") {label:jobs label:recruiters} after:1700000000`
+func (f Filter) gmailQuery() string {
+	words := make([]string, 0, len(f.keywords()))
+	for _, word := range f.keywords() {
+		words = append(words, gmailTerm(word))
+	}
+	parts := []string{fmt.Sprintf("subject:(%s)", strings.Join(words, " OR "))}
+
+	if len(f.Labels) > 0 {
+		labels := make([]string, 0, len(f.Labels))
+		for _, label := range f.Labels {
+			// Gmail search spells "Job Search/Replies" as job-search-replies
+			name := strings.NewReplacer(" ", "-", "/", "-").Replace(strings.ToLower(strings.TrimSpace(label)))
+			labels = append(labels, "label:"+name)
+		}
+		parts = append(parts, "{"+strings.Join(labels, " ")+"}")
+	}
+
+	// Seconds since the epoch are exact, dates would be in the account's time zone
+	if !f.After.IsZero() {
+		parts = append(parts, fmt.Sprintf("after:%d", f.After.Unix()))
+	}
+	if !f.Before.IsZero() {
+		parts = append(parts, fmt.Sprintf("before:%d", f.Before.Unix()))
+	}
+	return strings.Join(parts, " ")
+}
+
+// gmailTerm quotes a search term with spaces as a phrase
+func gmailTerm(word string) string {
+	word = strings.ReplaceAll(word, `"`, "")
+	if strings.ContainsAny(word, " \t") {
+		return `"` + word + `"`
+	}
+	return word
+}
@@ -0,0 +1,286 @@
+package mailsource
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailbody"
+	"golang.org/x/time/rate"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// gmailPageSize is the most results Gmail returns per page of a list
+const gmailPageSize = 500
+
+// Gmail API quota units of the calls we make
+const (
+	quotaGetProfile   = 1
+	quotaHistoryList  = 2
+	quotaMessagesList = 5
+	quotaMessagesGet  = 5
+)
+
+// Gmail reads a mailbox through the Gmail API, the cursor is the mailbox history ID
+type Gmail struct {
+	svc *gmail.Service
+	// Bootstrap is the mail a first sync picks up
+	Bootstrap Filter
+	// Quota paces the API calls in quota units (nil = unlimited). Gmail's limit is per user,
+	// so share one limiter between all sources of the same mailbox. Safe for concurrent Fetch.
+	Quota *rate.Limiter
+}
+
+func NewGmail(svc *gmail.Service, bootstrap Filter) *Gmail {
+	return &Gmail{svc: svc, Bootstrap: bootstrap}
+}
+
+func (g *Gmail) List(ctx context.Context, cursor string) ([]string, string, error) {
+	if cursor == "" {
+		return g.fullSync(ctx)
+	}
+	startID, err := strconv.ParseUint(cursor, 10, 64)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: bad history ID %q", ErrCursorExpired, cursor)
+	}
+	return g.incrementalSync(ctx, startID)
+}
+
+// fullSync lists the mail matching the bootstrap filter and returns the current History ID (Bootstrap)
+func (g *Gmail) fullSync(ctx context.Context) ([]string, string, error) {
+	// 1. Get the CURRENT History ID first to set our new anchor.
+	// Mail arriving while we page through the search is picked up by the next incremental sync.
+	if err := g.spend(ctx, quotaGetProfile); err != nil {
+		return nil, "", err
+	}
+	profile, err := g.svc.Users.GetProfile("me").Context(ctx).Do()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 2. Every page of the search
+	ids, err := g.Search(ctx, g.Bootstrap)
+	if err != nil {
+		return nil, "", err
+	}
+	return ids, strconv.FormatUint(profile.HistoryId, 10), nil
+}
+
+// Search pages through Messages.List with the filter as query
+func (g *Gmail) Search(ctx context.Context, f Filter) ([]string, error) {
+	q := f.gmailQuery()
+	var ids []string
+	pageToken := ""
+	for {
+		var resp *gmail.ListMessagesResponse
+		err := retry(ctx, 3, 1*time.Second, func() error {
+			if e := g.spend(ctx, quotaMessagesList); e != nil {
+				return e
+			}
+			var e error
+			call := g.svc.Users.Messages.List("me").Q(q).MaxResults(gmailPageSize)
+			if pageToken != "" {
+				call.PageToken(pageToken)
+			}
+			resp, e = call.Context(ctx).Do()
+			return e
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range resp.Messages {
+			ids = append(ids, m.Id)
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+
+	// Gmail lists the newest first
+	slices.Reverse(ids)
+	return ids, nil
+}
+
+// incrementalSync asks Google ONLY for what changed since startID, page by page
+func (g *Gmail) incrementalSync(ctx context.Context, startID uint64) ([]string, string, error) {
+	var ids []string
+	seen := map[string]bool{} // A message shows up once per history record that touched it
+	next := startID
+	pageToken := ""
+	for {
+		var resp *gmail.ListHistoryResponse
+		err := retry(ctx, 3, 1*time.Second, func() error {
+			if e := g.spend(ctx, quotaHistoryList); e != nil {
+				return e
+			}
+			var e error
+			call := g.svc.Users.History.List("me").StartHistoryId(startID).MaxResults(gmailPageSize)
+			// We only care about added messages, not label changes
+			call.HistoryTypes("messageAdded")
+			if pageToken != "" {
+				call.PageToken(pageToken)
+			}
+			resp, e = call.Context(ctx).Do()
+			return e
+		})
+		if isHistoryExpiredError(err) {
+			return nil, "", ErrCursorExpired
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		// Extract all added messages from the history events
+		for _, h := range resp.History {
+			for _, mAdded := range h.MessagesAdded {
+				if mAdded.Message != nil && !seen[mAdded.Message.Id] {
+					seen[mAdded.Message.Id] = true
+					ids = append(ids, mAdded.Message.Id)
+				}
+			}
+		}
+		if resp.HistoryId > next {
+			next = resp.HistoryId
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+	return ids, strconv.FormatUint(next, 10), nil
+}
+
+// Fetch gets the full body/headers of one message
+func (g *Gmail) Fetch(ctx context.Context, id string) (*Message, error) {
+	var msg *gmail.Message
+	// Retry individual message fetches
+	err := retry(ctx, 2, 500*time.Millisecond, func() error {
+		if e := g.spend(ctx, quotaMessagesGet); e != nil {
+			return e
+		}
+		var e error
+		msg, e = g.svc.Users.Messages.Get("me", id).Context(ctx).Do()
+		return e
+	})
+	if err != nil {
+		return nil, err
+	}
+	body, err := gmailPart(msg.Payload).Text()
+	if err != nil {
+		// Fetching again won't help, the headers alone are still worth classifying
+		log.Printf("⚠️ Gmail message %s has no readable body, using its headers: %v", id, err)
+		body = ""
+	}
+	return &Message{
+		ID:       msg.Id,
+		ThreadID: msg.ThreadId,
+		Headers:  gmailHeaders(msg),
+		Body:     body,
+		Date:     time.UnixMilli(msg.InternalDate),
+	}, nil
+}
+
+func (g *Gmail) Close() error { return nil }
+
+// spend waits until the quota allows a call of that many units
+func (g *Gmail) spend(ctx context.Context, units int) error {
+	if g.Quota == nil {
+		return nil
+	}
+	if err := g.Quota.WaitN(ctx, units); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// The wait would outlast the context
+		return fmt.Errorf("%w: %v", context.DeadlineExceeded, err)
+	}
+	return nil
+}
+
+// --- HELPERS ---
+
+// retry executes a function with exponential backoff. The wait ends early when ctx is
+// done, with ctx's error.
+func retry(ctx context.Context, attempts int, sleep time.Duration, f func() error) error {
+	var err error
+	for i := 0; i < attempts; i++ {
+		err = f()
+		if err == nil {
+			return nil
+		}
+		// If 404 (History Expired), fail fast so we can switch to Full Sync
+		if isHistoryExpiredError(err) {
+			return err
+		}
+		// Out of time, the next cycle picks it up
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+
+		if i == attempts-1 {
+			break
+		}
+
+		log.Printf("⚠️ API Error: %v. Retrying in %v...", err, sleep)
+		timer := time.NewTimer(sleep)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		sleep *= 2
+	}
+	return fmt.Errorf("failed after %d attempts: %w", attempts, err)
+}
+
+func isHistoryExpiredError(err error) bool {
+	if gErr, ok := err.(*googleapi.Error); ok {
+		return gErr.Code == 404
+	}
+	return false
+}
+
+func gmailHeaders(msg *gmail.Message) map[string]string {
+	res := make(map[string]string)
+	for _, h := range msg.Payload.Headers {
+		res[textproto.CanonicalMIMEHeaderKey(h.Name)] = h.Value
+	}
+	return res
+}
+
+// gmailPart turns the MIME tree Gmail returns into ours. Gmail has undone the transfer
+// encoding already, the data of a part is just base64url on top of its charset.
+func gmailPart(p *gmail.MessagePart) *mailbody.Part {
+	h := make(textproto.MIMEHeader, len(p.Headers))
+	for _, header := range p.Headers {
+		h.Add(header.Name, header.Value)
+	}
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		contentType = p.MimeType
+	}
+	part := mailbody.NewPart(contentType, h.Get("Content-Disposition"))
+	for _, child := range p.Parts {
+		part.Parts = append(part.Parts, gmailPart(child))
+	}
+	if strings.HasPrefix(part.MediaType, "text/") && p.Body != nil && p.Body.Data != "" {
+		// Gmail sometimes pads, sometimes not
+		data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(p.Body.Data, "="))
+		if err != nil {
+			part.Err = fmt.Errorf("%s part: %w", part.MediaType, err)
+		}
+		part.Data = data
+	}
+	return part
+}
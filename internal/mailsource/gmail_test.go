@@ -0,0 +1,67 @@
+package mailsource
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestRetry(t *testing.T) {
+	flaky := errors.New("500 backend error")
+
+	// 1. Retries until it works, waiting in between
+	calls := 0
+	err := retry(context.Background(), 3, time.Millisecond, func() error {
+		calls++
+		if calls < 3 {
+			return flaky
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("got %v after %d calls, want success after 3", err, calls)
+	}
+
+	// 2. Gives up after the last attempt without waiting once more
+	calls = 0
+	start := time.Now()
+	err = retry(context.Background(), 2, 100*time.Millisecond, func() error {
+		calls++
+		return flaky
+	})
+	if !errors.Is(err, flaky) || calls != 2 {
+		t.Errorf("got %v after %d calls, want the error after 2", err, calls)
+	}
+	if elapsed := time.Since(start); elapsed >= 300*time.Millisecond {
+		t.Errorf("took %s, want one wait of 100ms", elapsed)
+	}
+
+	// 3. An expired history fails fast
+	calls = 0
+	err = retry(context.Background(), 3, time.Hour, func() error {
+		calls++
+		return &googleapi.Error{Code: 404}
+	})
+	if !isHistoryExpiredError(err) || calls != 1 {
+		t.Errorf("got %v after %d calls, want the 404 after 1", err, calls)
+	}
+
+	// 4. Cancelling ends the wait
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	calls = 0
+	start = time.Now()
+	err = retry(ctx, 3, time.Hour, func() error {
+		calls++
+		return flaky
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("got %v after %d calls, want context.Canceled after 1", err, calls)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancelled retry took %s", elapsed)
+	}
+}
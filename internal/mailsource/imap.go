@@ -0,0 +1,281 @@
+package mailsource
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+)
+
+// imapTimeout bounds every IMAP command when the context has no deadline
+const imapTimeout = 1 * time.Minute
+
+// IMAP reads one folder of an IMAP mailbox (Fastmail, Outlook, self-hosted...).
+// The cursor is "<UIDVALIDITY>:<last seen UID>", see IMAPCursor.
+type IMAP struct {
+	Addr     string // host:port
+	TLS      bool   // Implicit TLS (port 993). Without it the connection is plaintext, for local servers only.
+	Username string
+	Password string
+	Mailbox  string // "" = INBOX
+	// Bootstrap is the mail a first sync picks up
+	Bootstrap Filter
+
+	client   *client.Client
+	validity uint32
+}
+
+// IMAPCursor encodes an IMAP sync position
+func IMAPCursor(uidValidity, lastUID uint32) string {
+	return fmt.Sprintf("%d:%d", uidValidity, lastUID)
+}
+
+// ParseIMAPCursor decodes a cursor from IMAPCursor
+func ParseIMAPCursor(cursor string) (uint32, uint32, error) {
+	v, u, ok := strings.Cut(cursor, ":")
+	validity, err1 := strconv.ParseUint(v, 10, 32)
+	uid, err2 := strconv.ParseUint(u, 10, 32)
+	if !ok || err1 != nil || err2 != nil {
+		return 0, 0, fmt.Errorf("bad IMAP cursor %q", cursor)
+	}
+	return uint32(validity), uint32(uid), nil
+}
+
+// connect logs in and selects the folder (read-only, so nothing gets marked as seen)
+func (m *IMAP) connect(ctx context.Context) (*imap.MailboxStatus, error) {
+	if m.client != nil {
+		m.setTimeout(ctx)
+		return m.client.Mailbox(), nil
+	}
+
+	dialer := &net.Dialer{Timeout: imapTimeout}
+	var c *client.Client
+	var err error
+	if m.TLS {
+		host, _, _ := net.SplitHostPort(m.Addr)
+		c, err = client.DialWithDialerTLS(dialer, m.Addr, &tls.Config{ServerName: host})
+	} else {
+		c, err = client.DialWithDialer(dialer, m.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", m.Addr, err)
+	}
+	m.client = c
+	m.setTimeout(ctx)
+
+	if err := c.Login(m.Username, m.Password); err != nil {
+		m.Close()
+		return nil, fmt.Errorf("logging in as %s: %w", m.Username, err)
+	}
+	mailbox := m.Mailbox
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+	status, err := c.Select(mailbox, true)
+	if err != nil {
+		m.Close()
+		return nil, fmt.Errorf("opening %s: %w", mailbox, err)
+	}
+	m.validity = status.UidValidity
+	return status, nil
+}
+
+// setTimeout applies the context deadline to the next commands, the client has no context support
+func (m *IMAP) setTimeout(ctx context.Context) {
+	m.client.Timeout = imapTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		m.client.Timeout = time.Until(deadline)
+	}
+}
+
+// Check logs in and opens the folder, to validate an account before saving it
+func (m *IMAP) Check(ctx context.Context) error {
+	_, err := m.connect(ctx)
+	return err
+}
+
+func (m *IMAP) List(ctx context.Context, cursor string) ([]string, string, error) {
+	status, err := m.connect(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 1. Pick the search: everything after the last UID, or the mail of the bootstrap filter
+	var criteria *imap.SearchCriteria
+	var lastUID uint32
+	if cursor != "" {
+		validity, uid, err := ParseIMAPCursor(cursor)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrCursorExpired, err)
+		}
+		// UIDs of a folder are only comparable while its UIDVALIDITY stays the same
+		if validity != status.UidValidity {
+			return nil, "", ErrCursorExpired
+		}
+		lastUID = uid
+		criteria = imap.NewSearchCriteria()
+		criteria.Uid = new(imap.SeqSet)
+		criteria.Uid.AddRange(uid+1, 0) // uid+1:*
+	} else {
+		criteria = filterCriteria(m.Bootstrap)
+	}
+
+	uids, err := m.client.UidSearch(criteria)
+	if err != nil {
+		return nil, "", err
+	}
+	slices.Sort(uids)
+
+	// 2. "uid+1:*" always matches the newest message, even when it's older than uid+1
+	var ids []string
+	next := lastUID
+	for _, uid := range uids {
+		if uid <= lastUID {
+			continue
+		}
+		ids = append(ids, strconv.FormatUint(uint64(uid), 10))
+		if uid > next {
+			next = uid
+		}
+	}
+	// A bootstrap only looked at some of the mail, continue after the newest message
+	if status.UidNext > 0 && status.UidNext-1 > next {
+		next = status.UidNext - 1
+	}
+	return ids, IMAPCursor(status.UidValidity, next), nil
+}
+
+// Search runs the filter as an IMAP SEARCH on the folder, Labels don't apply
+func (m *IMAP) Search(ctx context.Context, f Filter) ([]string, error) {
+	if _, err := m.connect(ctx); err != nil {
+		return nil, err
+	}
+	uids, err := m.client.UidSearch(filterCriteria(f))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(uids)
+	ids := make([]string, 0, len(uids))
+	for _, uid := range uids {
+		ids = append(ids, strconv.FormatUint(uint64(uid), 10))
+	}
+	return ids, nil
+}
+
+func (m *IMAP) Fetch(ctx context.Context, id string) (*Message, error) {
+	if _, err := m.connect(ctx); err != nil {
+		return nil, err
+	}
+	uid, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("bad IMAP UID %q", id)
+	}
+
+	set := new(imap.SeqSet)
+	set.AddNum(uint32(uid))
+	section := &imap.BodySectionName{Peek: true}
+	ch := make(chan *imap.Message, 1)
+	if err := m.client.UidFetch(set, []imap.FetchItem{section.FetchItem()}, ch); err != nil {
+		return nil, err
+	}
+	msg := <-ch
+	if msg == nil {
+		return nil, fmt.Errorf("message %d not found", uid)
+	}
+	body := msg.GetBody(section)
+	if body == nil {
+		return nil, errors.New("server returned no message body")
+	}
+	// Messages without a Message-ID are keyed by their place in the folder
+	return ParseRFC822(body, "imap:"+IMAPCursor(m.validity, uint32(uid)))
+}
+
+// FetchBatch loads the messages with one UID FETCH
+func (m *IMAP) FetchBatch(ctx context.Context, ids []string) (map[string]*Message, error) {
+	if _, err := m.connect(ctx); err != nil {
+		return nil, err
+	}
+	set := new(imap.SeqSet)
+	for _, id := range ids {
+		uid, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("bad IMAP UID %q", id)
+		}
+		set.AddNum(uint32(uid))
+	}
+
+	section := &imap.BodySectionName{Peek: true}
+	ch := make(chan *imap.Message, len(ids))
+	done := make(chan error, 1)
+	go func() {
+		done <- m.client.UidFetch(set, []imap.FetchItem{imap.FetchUid, section.FetchItem()}, ch)
+	}()
+
+	msgs := make(map[string]*Message, len(ids))
+	for msg := range ch {
+		body := msg.GetBody(section)
+		if body == nil {
+			continue
+		}
+		parsed, err := ParseRFC822(body, "imap:"+IMAPCursor(m.validity, msg.Uid))
+		if err != nil {
+			continue // Left out like a deleted message, Fetch reports the error
+		}
+		msgs[strconv.FormatUint(uint64(msg.Uid), 10)] = parsed
+	}
+	if err := <-done; err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+func (m *IMAP) Close() error {
+	if m.client == nil {
+		return nil
+	}
+	err := m.client.Logout()
+	m.client = nil
+	return err
+}
+
+// filterCriteria is the SEARCH for a filter: SINCE / BEFORE and any of the subject keywords.
+// IMAP dates have no time, the range is widened to whole days.
+func filterCriteria(f Filter) *imap.SearchCriteria {
+	c := imap.NewSearchCriteria()
+	c.Since = f.After
+	if !f.Before.IsZero() {
+		y, m, d := f.Before.Date()
+		c.Before = time.Date(y, m, d, 0, 0, 0, 0, f.Before.Location())
+		if c.Before.Before(f.Before) {
+			c.Before = c.Before.AddDate(0, 0, 1)
+		}
+	}
+
+	subject := func(word string) *imap.SearchCriteria {
+		s := imap.NewSearchCriteria()
+		s.Header.Add("Subject", word)
+		return s
+	}
+	words := f.keywords()
+	if len(words) == 1 {
+		c.Header.Add("Subject", words[0])
+		return c
+	}
+	// SUBJECT a OR SUBJECT b OR ...
+	either := subject(words[0])
+	for _, word := range words[1:] {
+		or := imap.NewSearchCriteria()
+		or.Or = [][2]*imap.SearchCriteria{{either, subject(word)}}
+		either = or
+	}
+	c.Or = either.Or
+	return c
+}
@@ -0,0 +1,186 @@
+package mailsource
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend"
+	"github.com/emersion/go-imap/backend/memory"
+	"github.com/emersion/go-imap/server"
+)
+
+// The memory backend's account. Its INBOX starts with one message, UID 6, whose subject
+// has none of the DefaultKeywords.
+const (
+	imapUser     = "username"
+	imapPassword = "password"
+)
+
+// testBackend is the go-imap memory backend with a UIDVALIDITY the test can change.
+// The memory backend always reports 1.
+type testBackend struct {
+	*memory.Backend
+	validity atomic.Uint32
+}
+
+func (b *testBackend) Login(conn *imap.ConnInfo, username, password string) (backend.User, error) {
+	user, err := b.Backend.Login(conn, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return &testUser{User: user, validity: &b.validity}, nil
+}
+
+type testUser struct {
+	backend.User
+	validity *atomic.Uint32
+}
+
+func (u *testUser) GetMailbox(name string) (backend.Mailbox, error) {
+	mbox, err := u.User.GetMailbox(name)
+	if err != nil {
+		return nil, err
+	}
+	return &testMailbox{Mailbox: mbox, validity: u.validity}, nil
+}
+
+type testMailbox struct {
+	backend.Mailbox
+	validity *atomic.Uint32
+}
+
+func (m *testMailbox) Status(items []imap.StatusItem) (*imap.MailboxStatus, error) {
+	status, err := m.Mailbox.Status(items)
+	if err == nil && status.UidValidity != 0 {
+		status.UidValidity = m.validity.Load()
+	}
+	return status, err
+}
+
+// imapServer serves a fresh memory mailbox on a local port
+func imapServer(t *testing.T) (string, *testBackend) {
+	t.Helper()
+	be := &testBackend{Backend: memory.New()}
+	be.validity.Store(1)
+	srv := server.New(be)
+	srv.AllowInsecureAuth = true
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+	return l.Addr().String(), be
+}
+
+// deliver adds a message to the INBOX, received at the given time
+func deliver(t *testing.T, be *testBackend, received time.Time, raw string) {
+	t.Helper()
+	user, err := be.Backend.Login(nil, imapUser, imapPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inbox, err := user.GetMailbox("INBOX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := inbox.CreateMessage(nil, received, strings.NewReader(raw)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIMAPCheck(t *testing.T) {
+	addr, _ := imapServer(t)
+	ctx := context.Background()
+
+	good := &IMAP{Addr: addr, Username: imapUser, Password: imapPassword}
+	defer good.Close()
+	if err := good.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	bad := &IMAP{Addr: addr, Username: imapUser, Password: "wrong"}
+	if err := bad.Check(ctx); err == nil || !strings.Contains(err.Error(), "logging in") {
+		t.Errorf("got %v, want a login error", err)
+	}
+	if bad.client != nil {
+		t.Error("a failed login left the connection open")
+	}
+
+	missing := &IMAP{Addr: addr, Username: imapUser, Password: imapPassword, Mailbox: "Jobs"}
+	if err := missing.Check(ctx); err == nil || !strings.Contains(err.Error(), "opening Jobs") {
+		t.Errorf("got %v, want the folder to be missing", err)
+	}
+}
+
+func TestIMAPList(t *testing.T) {
+	addr, be := imapServer(t)
+	ctx := context.Background()
+	now := time.Now()
+	deliver(t, be, now.AddDate(0, 0, -30), "Subject: Your application\r\nMessage-ID: <old@acme.com>\r\n\r\ntoo old\r\n")
+	deliver(t, be, now, "Subject: Lunch?\r\nMessage-ID: <lunch@acme.com>\r\n\r\nno keyword\r\n")
+	deliver(t, be, now, "From: Acme <jobs@acme.com>\r\nSubject: Interview invitation\r\nMessage-ID: <m1@acme.com>\r\n\r\nWe'd like to interview you\r\n")
+
+	src := &IMAP{Addr: addr, Username: imapUser, Password: imapPassword,
+		Bootstrap: Filter{After: now.AddDate(0, 0, -DefaultWindowDays)}}
+	defer src.Close()
+
+	// 1. First sync: only the bootstrap's mail, then carry on after the newest message
+	ids, cursor, err := src.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != "9" || cursor != "1:9" {
+		t.Fatalf("bootstrap got %v, %q", ids, cursor)
+	}
+	msg, err := src.Fetch(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.ID != "<m1@acme.com>" || msg.Header("subject") != "Interview invitation" || msg.Body != "We'd like to interview you" {
+		t.Errorf("got %+v", msg)
+	}
+
+	// 2. Incremental: everything after the cursor's UID, whatever it's about
+	ids, next, err := src.List(ctx, cursor)
+	if err != nil || len(ids) != 0 || next != cursor {
+		t.Fatalf("nothing new got %v, %q, %v", ids, next, err)
+	}
+	deliver(t, be, now, "Subject: Lunch again?\r\nMessage-ID: <lunch2@acme.com>\r\n\r\nno keyword\r\n")
+	deliver(t, be, now, "Subject: Re: Interview invitation\r\n\r\nNo Message-ID\r\n")
+	ids, cursor, err = src.List(ctx, cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(ids, ",") != "10,11" || cursor != "1:11" {
+		t.Fatalf("incremental got %v, %q", ids, cursor)
+	}
+	msgs, err := src.FetchBatch(ctx, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || msgs["10"].ID != "<lunch2@acme.com>" || msgs["11"].ID != "imap:1:11" {
+		t.Errorf("got %+v", msgs)
+	}
+
+	// 3. The folder was rebuilt: its UIDs mean something else now
+	src.Close()
+	be.validity.Store(2)
+	if _, _, err := src.List(ctx, cursor); !errors.Is(err, ErrCursorExpired) {
+		t.Fatalf("got %v, want ErrCursorExpired", err)
+	}
+	ids, cursor, err = src.List(ctx, "")
+	if err != nil || strings.Join(ids, ",") != "9,11" || cursor != "2:11" {
+		t.Errorf("bootstrap after the reset got %v, %q, %v", ids, cursor, err)
+	}
+
+	if _, _, err := src.List(ctx, "not a cursor"); !errors.Is(err, ErrCursorExpired) {
+		t.Errorf("got %v, want ErrCursorExpired for a bad cursor", err)
+	}
+}
@@ -0,0 +1,41 @@
+package mailsource
+
+import (
+	"io"
+	"net/mail"
+	"strings"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailbody"
+)
+
+// ParseRFC822 reads a raw email (IMAP BODY[], .eml files). Message.ID is the
+// Message-ID header, or fallbackID when the message has none.
+func ParseRFC822(r io.Reader, fallbackID string) (*Message, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(msg.Header))
+	for k, v := range msg.Header {
+		if len(v) == 0 {
+			continue
+		}
+		headers[k] = mailbody.DecodeHeader(v[0])
+	}
+
+	id := strings.TrimSpace(msg.Header.Get("Message-Id"))
+	if id == "" {
+		id = fallbackID
+	}
+	part, err := mailbody.Parse(msg.Header, msg.Body)
+	if err != nil {
+		return nil, err
+	}
+	body, err := part.Text()
+	if err != nil {
+		return nil, err
+	}
+	date, _ := msg.Header.Date()
+	return &Message{ID: id, Headers: headers, Body: body, Date: date}, nil
+}
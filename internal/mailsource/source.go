@@ -0,0 +1,74 @@
+package mailsource
+
+import (
+	"context"
+	"errors"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// ErrCursorExpired means the source can't continue from the cursor (Gmail dropped the
+// history, the IMAP folder got a new UIDVALIDITY). The caller starts over with an empty cursor.
+var ErrCursorExpired = errors.New("mail cursor expired, a full sync is needed")
+
+// Source is a mailbox the watcher reads new mail from
+type Source interface {
+	// List returns the IDs of the messages added since cursor and the cursor to continue from.
+	// An empty cursor bootstraps: it searches with the source's bootstrap Filter and returns the current position.
+	List(ctx context.Context, cursor string) ([]string, string, error)
+	// Search returns the IDs of all messages matching the filter, oldest first. It doesn't move the cursor.
+	Search(ctx context.Context, f Filter) ([]string, error)
+	// Fetch loads a message listed by List
+	Fetch(ctx context.Context, id string) (*Message, error)
+	Close() error
+}
+
+// BatchFetcher is a Source that loads many messages in one round trip
+type BatchFetcher interface {
+	// FetchBatch returns the messages found, by ID. IDs missing from the result were deleted
+	// or could not be parsed.
+	FetchBatch(ctx context.Context, ids []string) (map[string]*Message, error)
+}
+
+// Message is an email in the shape the processing pipeline needs, whatever mailbox it came from
+type Message struct {
+	// ID is unique within the user's mail and is what dedup keys on
+	ID       string
+	ThreadID string            // The mailbox's conversation ID (Gmail's threadId), "" when it has none
+	Headers  map[string]string // Canonical keys ("Subject", "From", "Message-Id")
+	Body     string            // Readable text with quotes, see mailbody.Part.Text
+	Date     time.Time         // Zero when unknown
+}
+
+// Header looks a header up by any spelling of its name
+func (m *Message) Header(name string) string {
+	return m.Headers[textproto.CanonicalMIMEHeaderKey(name)]
+}
+
+// Thread names the conversation the message belongs to: the mailbox's thread ID, or else
+// the first message the References header goes back to, the one it replies to, or itself
+func (m *Message) Thread() string {
+	if m.ThreadID != "" {
+		return m.ThreadID
+	}
+	if refs := strings.Fields(m.Header("References")); len(refs) > 0 {
+		return refs[0]
+	}
+	if parent := strings.TrimSpace(m.Header("In-Reply-To")); parent != "" {
+		return parent
+	}
+	if id := m.Header("Message-Id"); id != "" {
+		return id
+	}
+	return m.ID
+}
+
+// DedupKeys are the IDs the message is known by: its source ID and its Message-ID header
+func (m *Message) DedupKeys() []string {
+	keys := []string{m.ID}
+	if header := m.Header("Message-Id"); header != "" && header != m.ID {
+		keys = append(keys, header)
+	}
+	return keys
+}
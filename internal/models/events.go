@@ -0,0 +1,49 @@
+package models
+
+import "github.com/justsurfingit/Agentic-Job-Tracker/internal/status"
+
+// EventKind is the type of a JobEvent. Each kind has its own payload struct below.
+type EventKind string
+
+const (
+	EventCreated            EventKind = "created"
+	EventStatusChanged      EventKind = "status_changed"
+	EventEmailReceived      EventKind = "email_received"
+	EventNoteAdded          EventKind = "note_added"
+	EventInterviewScheduled EventKind = "interview_scheduled"
+)
+
+// CreatedPayload is stored with EventCreated
+type CreatedPayload struct {
+	Title   string        `json:"title"`
+	Company string        `json:"company"`
+	Status  status.Status `json:"status"`
+}
+
+// StatusChangedPayload is stored with EventStatusChanged
+type StatusChangedPayload struct {
+	From status.Status `json:"from"`
+	To   status.Status `json:"to"`
+	Note string        `json:"note,omitempty"`
+}
+
+// EmailReceivedPayload is stored with EventEmailReceived.
+// ProposedStatus is what the LLM read from the email, whether or not it was applied.
+type EmailReceivedPayload struct {
+	MessageID      string `json:"message_id"`
+	Subject        string `json:"subject"`
+	From           string `json:"from"`
+	Summary        string `json:"summary,omitempty"`
+	ProposedStatus string `json:"proposed_status,omitempty"`
+}
+
+// NoteAddedPayload is stored with EventNoteAdded
+type NoteAddedPayload struct {
+	Text string `json:"text"`
+}
+
+// InterviewScheduledPayload is stored with EventInterviewScheduled (one per interview round)
+type InterviewScheduledPayload struct {
+	Round int    `json:"round"`
+	Note  string `json:"note,omitempty"`
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/status"
 	"gorm.io/gorm"
 )
 
@@ -14,6 +15,20 @@ type User struct {
 
 	Email         string `gorm:"uniqueIndex;not null" json:"email"`
 	LastHistoryID uint64 `json:"last_history_id"`
+
+	// GmailToken is the user's OAuth token, encrypted by auth.DBTokenStore ("" = not stored here)
+	GmailToken string `gorm:"type:text" json:"-"`
+
+	// Which mail the sync looks at (columns are prefixed with "sync_")
+	Sync SyncSettings `gorm:"embedded;embeddedPrefix:sync_" json:"sync"`
+}
+
+// SyncSettings narrow the first sync of a mailbox and backfills. Empty values use the defaults
+// of the mailsource package; new mail after the first sync is always read in full.
+type SyncSettings struct {
+	Keywords   JSON `json:"keywords"`    // []string, any of them in the subject
+	Labels     JSON `json:"labels"`      // []string, any of these Gmail labels
+	WindowDays int  `json:"window_days"` // How many days back the first sync looks
 }
 
 type Company struct {
@@ -22,8 +37,9 @@ type Company struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
-	// Ensure Name matches the JSON key you want
-	Name string `gorm:"uniqueIndex;not null" json:"company_name"`
+	// Every user has their own companies, names are unique per user
+	UserID uint   `gorm:"uniqueIndex:idx_companies_user_name;not null" json:"-"`
+	Name   string `gorm:"uniqueIndex:idx_companies_user_name;not null" json:"company_name"`
 
 	// 'omitempty' prevents infinite loops when fetching a Job -> Company -> Jobs -> ...
 	Jobs []Job `json:"jobs,omitempty"`
@@ -35,27 +51,272 @@ type Job struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Owner. Every query on jobs is scoped to it.
+	UserID uint `gorm:"index;not null" json:"-"`
+
 	// Foreign Key
 	CompanyID uint `json:"company_id"`
 	// Association: GORM needs Preload() to fill this
 	Company Company `json:"company"`
 
-	Title       string `gorm:"not null" json:"title"`
-	Description string `gorm:"type:text" json:"description"`
-	JobLink     string `json:"job_link"`
-	Status      string `gorm:"default:'APPLIED'" json:"status"`
-	ResumeLink  string `json:"resume_link"`
+	Title       string        `gorm:"not null" json:"title"`
+	Description string        `gorm:"type:text" json:"description"`
+	JobLink     string        `json:"job_link"`
+	Status      status.Status `gorm:"default:'APPLIED'" json:"status"`
+	ResumeLink  string        `json:"resume_link"`
+
+	// InterviewRound counts how many times the job entered INTERVIEW (0 = never interviewed)
+	InterviewRound int `gorm:"default:0" json:"interview_round"`
+
+	// Where the job is and how it is worked. WorkMode is one of the WorkMode* constants ("" = unknown).
+	// WorkModeGuessed is set when it came from the location rather than from the user.
+	Location        string `json:"location"`
+	WorkMode        string `gorm:"index" json:"work_mode"`
+	WorkModeGuessed bool   `gorm:"not null;default:false" json:"work_mode_guessed"`
+
+	// Normalized salary parsed from the free-text range (columns are prefixed with "salary_")
+	Salary Salary `gorm:"embedded;embeddedPrefix:salary_" json:"salary"`
+
+	Technologies []Technology `gorm:"many2many:job_technologies;" json:"technologies,omitempty"`
 }
 
-type JobEvent struct {
+const (
+	WorkModeRemote = "REMOTE"
+	WorkModeHybrid = "HYBRID"
+	WorkModeOnsite = "ONSITE"
+)
+
+// Salary is the structured form of strings like "$100k - $150k" or "€45/hour".
+// Min/Max are whole currency units; 0 means "not mentioned".
+type Salary struct {
+	Min      int64  `gorm:"index" json:"min,omitempty"`
+	Max      int64  `gorm:"index" json:"max,omitempty"`
+	Currency string `json:"currency,omitempty"` // ISO 4217 code, e.g. "USD"
+	Period   string `json:"period,omitempty"`   // HOUR | DAY | WEEK | MONTH | YEAR
+	Raw      string `json:"raw,omitempty"`      // What the user / LLM originally gave us
+}
+
+const (
+	SalaryPeriodHour  = "HOUR"
+	SalaryPeriodDay   = "DAY"
+	SalaryPeriodWeek  = "WEEK"
+	SalaryPeriodMonth = "MONTH"
+	SalaryPeriodYear  = "YEAR"
+)
+
+// Technology is shared across jobs so we can filter and count by stack.
+// Slug is the lower-cased name used for matching ("Go", "go", " GO " -> "go").
+type Technology struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
 	CreatedAt time.Time `json:"created_at"`
-	JobID     uint      `json:"job_id"`
-	EventType string    `json:"event_type"`
-	Details   string    `gorm:"type:text" json:"details"`
+
+	Name string `gorm:"not null" json:"name"`
+	Slug string `gorm:"uniqueIndex;not null" json:"slug"`
+
+	Jobs []Job `gorm:"many2many:job_technologies;" json:"jobs,omitempty"`
+}
+
+// JobEvent is one entry of a job's timeline. Payload holds the kind-specific struct from events.go.
+type JobEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	UserID    uint      `gorm:"index;not null" json:"-"`
+	JobID     uint      `gorm:"index;uniqueIndex:idx_job_events_job_message" json:"job_id"`
+	Kind      EventKind `gorm:"index" json:"kind"`
+	Source    string    `json:"source"` // manual | email
+	Payload   JSON      `json:"payload"`
+	// MessageID keys email_received events, an email is on a job's timeline at most once
+	MessageID *string `gorm:"uniqueIndex:idx_job_events_job_message" json:"-"`
 }
 
+// ProcessedEmail marks a message as handled. Message IDs are only unique within one mailbox.
 type ProcessedEmail struct {
-	ID        string `gorm:"primaryKey"`
+	UserID    uint   `gorm:"primaryKey;autoIncrement:false"`
+	MessageID string `gorm:"primaryKey"`
 	CreatedAt time.Time
 }
+
+// PendingReview is an email classification we didn't trust enough to apply on our own.
+// A human approves it (optionally picking another job/status) or dismisses it.
+type PendingReview struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uint      `gorm:"index;not null" json:"-"`
+
+	State  string `gorm:"index;default:'PENDING'" json:"state"` // PENDING | APPROVED | DISMISSED
+	Reason string `json:"reason"`                               // Why a human has to look at it, see services.Review* constants
+
+	// The email
+	MessageID string `gorm:"index" json:"message_id"`
+	Subject   string `json:"subject"`
+	Sender    string `json:"sender"`
+	Snippet   string `gorm:"type:text" json:"snippet"`
+
+	// What the pipeline found / proposed
+	CompanyID       *uint   `json:"company_id"`
+	CandidateJobIDs JSON    `json:"candidate_job_ids"` // []uint of the jobs it could be about
+	ProposedJobID   *uint   `json:"proposed_job_id"`
+	ProposedStatus  string  `json:"proposed_status"`
+	Summary         string  `json:"summary"`
+	Confidence      float64 `json:"confidence"`
+	RawLLMOutput    string  `gorm:"type:text" json:"raw_llm_output,omitempty"`
+
+	// Filled in when a human resolves it
+	ResolvedJobID  *uint      `json:"resolved_job_id"`
+	ResolvedStatus string     `json:"resolved_status"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+}
+
+const (
+	ReviewStatePending   = "PENDING"
+	ReviewStateApproved  = "APPROVED"
+	ReviewStateDismissed = "DISMISSED"
+)
+
+// APIKey is a personal access token. Only the SHA-256 of the key is stored; the key itself
+// is shown once when it is created. Prefix is kept in clear so users can tell keys apart.
+type APIKey struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `gorm:"index;not null" json:"-"`
+
+	Name   string `gorm:"not null" json:"name"`
+	Prefix string `gorm:"not null" json:"prefix"`
+	Hash   string `gorm:"uniqueIndex;not null" json:"-"`
+	Scopes string `gorm:"not null" json:"scopes"` // Space separated, e.g. "jobs:read jobs:write"
+
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// Active reports whether the key can still be used at the given time
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// OAuthState is a Gmail connect flow in progress. It is single use and short lived.
+// Verifier is the PKCE code verifier sent back to Google with the code.
+type OAuthState struct {
+	State     string `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UserID    uint      `gorm:"not null"`
+	Verifier  string    `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+func (OAuthState) TableName() string { return "oauth_states" }
+
+// IMAPAccount is a user's mailbox on an IMAP server, synced instead of (or next to) Gmail.
+// UIDValidity and LastUID are its sync position, what LastHistoryID is for Gmail.
+type IMAPAccount struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uint      `gorm:"uniqueIndex;not null" json:"-"`
+
+	Addr     string `gorm:"not null" json:"addr"` // host:port
+	TLS      bool   `gorm:"not null" json:"tls"`
+	Username string `gorm:"not null" json:"username"`
+	Password string `gorm:"not null" json:"-"` // Sealed with auth.Cipher
+	Mailbox  string `gorm:"not null" json:"mailbox"`
+
+	UIDValidity uint32 `json:"uid_validity"`
+	LastUID     uint32 `json:"last_uid"`
+}
+
+func (IMAPAccount) TableName() string { return "imap_accounts" }
+
+// SyncCheckpoint is the mail a sync cycle listed but hasn't fetched, so the next cycle
+// resumes with it instead of listing everything again
+type SyncCheckpoint struct {
+	UserID    uint   `gorm:"primaryKey;autoIncrement:false"`
+	Mailbox   string `gorm:"primaryKey"` // "gmail" | "imap"
+	Pending   JSON   // []string of message IDs, oldest first
+	UpdatedAt time.Time
+}
+
+// GmailWatch is a user's users.watch subscription: Gmail pushes a Pub/Sub message for every
+// change of the mailbox until Expiration. EmailAddress is how pushes find their user.
+type GmailWatch struct {
+	UserID       uint      `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	EmailAddress string    `gorm:"index;not null" json:"email_address"`
+	Topic        string    `gorm:"not null" json:"topic"`
+	HistoryID    uint64    `json:"history_id"` // Latest one pushed
+	Expiration   time.Time `json:"expiration"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// QueuedEmail is a fetched email on its way through the pipeline. Failed attempts are retried
+// with backoff; after the last one the email stays FAILED (a dead letter) until it's requeued.
+type QueuedEmail struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uint      `gorm:"uniqueIndex:idx_email_queue_message;index:idx_email_queue_due,priority:1;not null" json:"-"`
+
+	// The email, Message is the mailsource.Message and is dropped once it's DONE
+	Mailbox   string `gorm:"uniqueIndex:idx_email_queue_message;not null" json:"mailbox"` // "gmail" | "imap"
+	MessageID string `gorm:"uniqueIndex:idx_email_queue_message;not null" json:"message_id"`
+	Subject   string `json:"subject"`
+	Sender    string `json:"sender"`
+	Message   JSON   `json:"-"`
+
+	State         string    `gorm:"index:idx_email_queue_due,priority:2;not null" json:"state"` // PENDING | PROCESSING | DONE | FAILED
+	Attempts      int       `gorm:"not null;default:0" json:"attempts"`
+	LastError     string    `gorm:"type:text" json:"last_error,omitempty"`
+	NextAttemptAt time.Time `gorm:"index:idx_email_queue_due,priority:3" json:"next_attempt_at"`
+}
+
+func (QueuedEmail) TableName() string { return "email_queue" }
+
+const (
+	QueueStatePending    = "PENDING"
+	QueueStateProcessing = "PROCESSING"
+	QueueStateDone       = "DONE"
+	QueueStateFailed     = "FAILED"
+)
+
+// Email is an email the pipeline processed, as it read it, and what it made of it.
+// It shows where a status change came from, and can be classified again from Body.
+type Email struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uint      `gorm:"uniqueIndex:idx_emails_user_message;not null" json:"-"`
+	JobID     *uint     `gorm:"index" json:"job_id"` // The job it was about, when the pipeline found one
+
+	MessageID  string     `gorm:"uniqueIndex:idx_emails_user_message;not null" json:"message_id"` // As in ProcessedEmail
+	ThreadID   string     `gorm:"index" json:"thread_id"`
+	Subject    string     `json:"subject"`
+	Sender     string     `json:"sender"`
+	Headers    JSON       `json:"headers"`               // map[string]string
+	Body       string     `gorm:"type:text" json:"body"` // Normalized plain text, the LLM reads its mailbody.Reply
+	ReceivedAt *time.Time `json:"received_at"`
+
+	// The latest verdict on it (columns are prefixed with "classification_")
+	Classification EmailClassification `gorm:"embedded;embeddedPrefix:classification_" json:"classification"`
+}
+
+// EmailClassification is what the pipeline decided about an email and with which model
+type EmailClassification struct {
+	Action     string    `json:"action"` // services.Outcome* constant, e.g. "status_changed"
+	Reason     string    `json:"reason,omitempty"`
+	Status     string    `json:"status,omitempty"` // What the LLM read from it, e.g. "INTERVIEW" or "NO_CHANGE"
+	Summary    string    `gorm:"type:text" json:"summary,omitempty"`
+	Confidence float64   `json:"confidence"`
+	Model      string    `json:"model"` // Provider and model, e.g. "gemini/gemini-2.5-flash"
+	At         time.Time `json:"at"`
+}
+
+// EmailThread ties a conversation to the job it's about, so later mail in it goes straight
+// to that job. ThreadID is mailsource.Message.Thread(): Gmail's threadId, or the Message-ID
+// the conversation started with.
+type EmailThread struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	ThreadID  string    `gorm:"primaryKey" json:"thread_id"`
+	JobID     uint      `gorm:"index;not null" json:"job_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
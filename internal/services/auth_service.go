@@ -0,0 +1,250 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+// Scopes an API key can be limited to
+const (
+	ScopeJobsRead     = "jobs:read"
+	ScopeJobsWrite    = "jobs:write"
+	ScopeReviewsRead  = "reviews:read"
+	ScopeReviewsWrite = "reviews:write"
+	ScopeKeysManage   = "keys:manage"
+	ScopeGmailManage  = "gmail:manage"
+	// ScopeEmailsReprocess allows classifying stored emails again, which can move many jobs at once
+	ScopeEmailsReprocess = "emails:reprocess"
+)
+
+// AllScopes is what a key gets when none are requested
+var AllScopes = []string{ScopeJobsRead, ScopeJobsWrite, ScopeReviewsRead, ScopeReviewsWrite, ScopeKeysManage, ScopeGmailManage, ScopeEmailsReprocess}
+
+// apiKeyPrefix marks our keys so they are easy to spot in logs and secret scanners
+const apiKeyPrefix = "jt_"
+
+var (
+	ErrUnauthenticated = errors.New("missing or invalid credentials")
+	ErrUnknownScope    = errors.New("unknown scope")
+	ErrScopeEscalation = errors.New("a key can't grant scopes its creator doesn't have")
+	ErrAPIKeyNotFound  = errors.New("api key not found")
+)
+
+// Principal is who a request acts as and what it may do
+type Principal struct {
+	User   *models.User
+	KeyID  uint
+	Scopes []string
+}
+
+// Can reports whether the principal holds the scope
+func (p *Principal) Can(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthService issues and checks API keys and session cookies.
+// A session is always minted from an API key and dies with it.
+type AuthService struct {
+	DB            *gorm.DB
+	SessionSecret []byte
+	SessionTTL    time.Duration
+}
+
+// NewAuthService uses a random secret when none is configured, which logs everyone out on restart
+func NewAuthService(db *gorm.DB, sessionSecret string, sessionTTL time.Duration) (*AuthService, error) {
+	secret := []byte(sessionSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+	return &AuthService{
+		DB:            db,
+		SessionSecret: secret,
+		SessionTTL:    sessionTTL,
+	}, nil
+}
+
+// CreateAPIKey returns the plaintext key (shown once) and its stored record.
+// With a creator, the new key can't have scopes the creator lacks.
+func (s *AuthService) CreateAPIKey(userID uint, name string, scopes []string, expiresAt *time.Time, creator *Principal) (string, *models.APIKey, error) {
+	// 1. Validate scopes
+	if len(scopes) == 0 {
+		scopes = AllScopes
+		if creator != nil {
+			scopes = creator.Scopes
+		}
+	}
+	for _, scope := range scopes {
+		if !knownScope(scope) {
+			return "", nil, fmt.Errorf("%w: %q", ErrUnknownScope, scope)
+		}
+		if creator != nil && !creator.Can(scope) {
+			return "", nil, ErrScopeEscalation
+		}
+	}
+
+	// 2. Generate the key, store only its hash
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	plaintext := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    plaintext[:len(apiKeyPrefix)+6],
+		Hash:      hashAPIKey(plaintext),
+		Scopes:    strings.Join(scopes, " "),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.DB.Create(key).Error; err != nil {
+		return "", nil, err
+	}
+	return plaintext, key, nil
+}
+
+// ListAPIKeys returns the user's keys, newest first
+func (s *AuthService) ListAPIKeys(userID uint) ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+	err := s.DB.Where("user_id = ?", userID).Order("id DESC").Find(&keys).Error
+	return keys, err
+}
+
+// RevokeAPIKey disables the key and every session made from it
+func (s *AuthService) RevokeAPIKey(userID, id uint) error {
+	res := s.DB.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// AuthenticateAPIKey checks a plaintext key from an Authorization header
+func (s *AuthService) AuthenticateAPIKey(plaintext string) (*Principal, error) {
+	if !strings.HasPrefix(plaintext, apiKeyPrefix) {
+		return nil, ErrUnauthenticated
+	}
+	var key models.APIKey
+	err := s.DB.Where("hash = ?", hashAPIKey(plaintext)).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnauthenticated
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	principal, err := s.principalFor(&key)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	s.DB.Model(&key).Update("last_used_at", now)
+	return principal, nil
+}
+
+// session is the signed payload of the session cookie
+type session struct {
+	KeyID     uint  `json:"k"`
+	ExpiresAt int64 `json:"e"`
+}
+
+// NewSession returns a signed cookie value for the principal and when it expires
+func (s *AuthService) NewSession(p *Principal) (string, time.Time, error) {
+	expiresAt := time.Now().Add(s.SessionTTL)
+	payload, err := json.Marshal(session{KeyID: p.KeyID, ExpiresAt: expiresAt.Unix()})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	return body + "." + s.sign(body), expiresAt, nil
+}
+
+// AuthenticateSession verifies the cookie's signature and expiry, then that its key is still active
+func (s *AuthService) AuthenticateSession(value string) (*Principal, error) {
+	body, sig, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(s.sign(body))) {
+		return nil, ErrUnauthenticated
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+	var sess session
+	if err := json.Unmarshal(payload, &sess); err != nil || time.Now().Unix() >= sess.ExpiresAt {
+		return nil, ErrUnauthenticated
+	}
+
+	var key models.APIKey
+	err = s.DB.First(&key, sess.KeyID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnauthenticated
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.principalFor(&key)
+}
+
+// principalFor loads the key's owner if the key is still usable
+func (s *AuthService) principalFor(key *models.APIKey) (*Principal, error) {
+	if !key.Active(time.Now()) {
+		return nil, ErrUnauthenticated
+	}
+	var user models.User
+	err := s.DB.First(&user, key.UserID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnauthenticated
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{
+		User:   &user,
+		KeyID:  key.ID,
+		Scopes: strings.Fields(key.Scopes),
+	}, nil
+}
+
+func (s *AuthService) sign(body string) string {
+	mac := hmac.New(sha256.New, s.SessionSecret)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// hashAPIKey is a plain SHA-256: keys are 256 random bits, so a slow hash adds nothing
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+func knownScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
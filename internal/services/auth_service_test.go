@@ -0,0 +1,241 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestAPIKeys(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		s, err := NewAuthService(db, "secret", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		me := createUser(t, db, "me@example.com")
+
+		// 1. Only the hash is stored, the key itself is only returned
+		plaintext, key, err := s.CreateAPIKey(me, " laptop ", nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(plaintext, apiKeyPrefix) || !strings.HasPrefix(plaintext, key.Prefix) || key.Name != "laptop" {
+			t.Errorf("key %q stored as %+v", plaintext, key)
+		}
+		var stored models.APIKey
+		if err := db.First(&stored, key.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if stored.Hash != hashAPIKey(plaintext) || strings.Contains(stored.Hash, plaintext[len(apiKeyPrefix):]) {
+			t.Errorf("stored hash %q for key %q", stored.Hash, plaintext)
+		}
+		if strings.Join(strings.Fields(stored.Scopes), " ") != strings.Join(AllScopes, " ") {
+			t.Errorf("a key without scopes got %q, want all of them", stored.Scopes)
+		}
+
+		// 2. The key authenticates its user and is marked used
+		principal, err := s.AuthenticateAPIKey(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if principal.User.ID != me || principal.KeyID != key.ID {
+			t.Errorf("got %+v", principal)
+		}
+		if db.First(&stored, key.ID); stored.LastUsedAt == nil {
+			t.Error("last_used_at wasn't set")
+		}
+
+		// 3. Anything else doesn't
+		for _, wrong := range []string{"", plaintext[len(apiKeyPrefix):], plaintext + "x", apiKeyPrefix + "nope", stored.Hash} {
+			if _, err := s.AuthenticateAPIKey(wrong); !errors.Is(err, ErrUnauthenticated) {
+				t.Errorf("key %q: got %v, want ErrUnauthenticated", wrong, err)
+			}
+		}
+
+		// 4. Neither do revoked and expired keys
+		if err := s.RevokeAPIKey(me, key.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.AuthenticateAPIKey(plaintext); !errors.Is(err, ErrUnauthenticated) {
+			t.Errorf("revoked key: got %v", err)
+		}
+		if err := s.RevokeAPIKey(me, key.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+			t.Errorf("revoked twice: %v", err)
+		}
+		yesterday := time.Now().AddDate(0, 0, -1)
+		expired, _, err := s.CreateAPIKey(me, "old", nil, &yesterday, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.AuthenticateAPIKey(expired); !errors.Is(err, ErrUnauthenticated) {
+			t.Errorf("expired key: got %v", err)
+		}
+
+		// 5. Users can't revoke each other's keys
+		other := createUser(t, db, "other@example.com")
+		_, theirs, err := s.CreateAPIKey(other, "theirs", nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.RevokeAPIKey(me, theirs.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+			t.Errorf("revoked another user's key: %v", err)
+		}
+	})
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		s, err := NewAuthService(db, "secret", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		me := createUser(t, db, "me@example.com")
+
+		if _, _, err := s.CreateAPIKey(me, "typo", []string{"jobs:delete"}, nil, nil); !errors.Is(err, ErrUnknownScope) {
+			t.Errorf("unknown scope: got %v", err)
+		}
+
+		// 1. A read-only key reads and nothing else
+		readKey, _, err := s.CreateAPIKey(me, "dashboard", []string{ScopeJobsRead, ScopeReviewsRead}, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader, err := s.AuthenticateAPIKey(readKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, scope := range AllScopes {
+			want := scope == ScopeJobsRead || scope == ScopeReviewsRead
+			if reader.Can(scope) != want {
+				t.Errorf("read key: Can(%s) = %v, want %v", scope, reader.Can(scope), want)
+			}
+		}
+
+		// 2. It can't mint a key with more than it has, and a key it mints gets only its scopes
+		for _, scopes := range [][]string{{ScopeJobsWrite}, {ScopeJobsRead, ScopeKeysManage}} {
+			if _, _, err := s.CreateAPIKey(me, "escalate", scopes, nil, reader); !errors.Is(err, ErrScopeEscalation) {
+				t.Errorf("read key minted %v: %v", scopes, err)
+			}
+		}
+		_, minted, err := s.CreateAPIKey(me, "copy", nil, nil, reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if minted.Scopes != ScopeJobsRead+" "+ScopeReviewsRead {
+			t.Errorf("minted key has %q", minted.Scopes)
+		}
+
+		// 3. A session carries the key's scopes, not more
+		cookie, _, err := s.NewSession(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		session, err := s.AuthenticateSession(cookie)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if session.Can(ScopeJobsWrite) || session.Can(ScopeKeysManage) || !session.Can(ScopeJobsRead) {
+			t.Errorf("session has %v", session.Scopes)
+		}
+	})
+}
+
+func TestSessions(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		s, err := NewAuthService(db, "secret", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		me := createUser(t, db, "me@example.com")
+		plaintext, key, err := s.CreateAPIKey(me, "browser", nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		principal, err := s.AuthenticateAPIKey(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cookie, expiresAt, err := s.NewSession(principal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := time.Until(expiresAt); d < 59*time.Minute || d > time.Hour {
+			t.Errorf("session expires in %s, want an hour", d)
+		}
+		got, err := s.AuthenticateSession(cookie)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.User.ID != me || got.KeyID != key.ID {
+			t.Errorf("got %+v", got)
+		}
+
+		// 1. Tampering breaks the signature
+		body, sig, _ := strings.Cut(cookie, ".")
+		other := createUser(t, db, "other@example.com")
+		_, theirs, err := s.CreateAPIKey(other, "theirs", nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		forged := func(sess session) string {
+			payload, _ := json.Marshal(sess)
+			return base64.RawURLEncoding.EncodeToString(payload)
+		}
+		longer := forged(session{KeyID: key.ID, ExpiresAt: time.Now().AddDate(1, 0, 0).Unix()})
+		foreign := forged(session{KeyID: theirs.ID, ExpiresAt: expiresAt.Unix()})
+		otherSecret, err := NewAuthService(db, "another secret", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resigned, _, err := otherSecret.NewSession(principal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		flipped := []byte(sig)
+		flipped[len(flipped)/2] ^= 1
+		garbage := base64.RawURLEncoding.EncodeToString([]byte("not json"))
+		for name, value := range map[string]string{
+			"empty":           "",
+			"no signature":    body,
+			"empty signature": body + ".",
+			"other key":       foreign + "." + sig,
+			"longer expiry":   longer + "." + sig,
+			"flipped bit":     body + "." + string(flipped),
+			"other secret":    resigned,
+			"signed garbage":  garbage + "." + s.sign(garbage),
+		} {
+			if _, err := s.AuthenticateSession(value); !errors.Is(err, ErrUnauthenticated) {
+				t.Errorf("%s: got %v, want ErrUnauthenticated", name, err)
+			}
+		}
+
+		// 2. Expired sessions are refused, even with a valid signature
+		expired := forged(session{KeyID: key.ID, ExpiresAt: time.Now().Add(-time.Second).Unix()})
+		if _, err := s.AuthenticateSession(expired + "." + s.sign(expired)); !errors.Is(err, ErrUnauthenticated) {
+			t.Errorf("expired session: got %v", err)
+		}
+		s.SessionTTL = 0
+		now, _, err := s.NewSession(principal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.AuthenticateSession(now); !errors.Is(err, ErrUnauthenticated) {
+			t.Errorf("session at its expiry: got %v", err)
+		}
+
+		// 3. The session dies with its key
+		if err := s.RevokeAPIKey(me, key.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.AuthenticateSession(cookie); !errors.Is(err, ErrUnauthenticated) {
+			t.Errorf("session of a revoked key: got %v", err)
+		}
+	})
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailsource"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+)
+
+// ImportReport summarizes an offline import (mbox / .eml)
+type ImportReport struct {
+	DryRun     bool `json:"dry_run"`
+	Read       int  `json:"read"`
+	Unreadable int  `json:"unreadable"`
+	Unrelated  int  `json:"unrelated"` // None of the user's sync keywords in the subject
+	Duplicates int  `json:"duplicates"`
+	Processed  int  `json:"processed"`
+
+	// Counts is the number of processed emails per outcome action
+	Counts map[string]int `json:"counts"`
+	// Changes are the status changes that were applied (or would be, in a dry run)
+	Changes  []EmailOutcome `json:"changes"`
+	Outcomes []EmailOutcome `json:"outcomes"`
+}
+
+// ErrNoMailbox means the user has neither Gmail nor IMAP connected
+var ErrNoMailbox = errors.New("no mailbox connected")
+
+// MailImport collects archived messages for ImportMail
+type MailImport struct {
+	report   *ImportReport
+	messages []*mailsource.Message
+
+	// Backfills only take mail received in [after, before)
+	after, before time.Time
+}
+
+func NewMailImport(dryRun bool) *MailImport {
+	return &MailImport{report: &ImportReport{DryRun: dryRun, Counts: map[string]int{}, Changes: []EmailOutcome{}, Outcomes: []EmailOutcome{}}}
+}
+
+// Add is the callback for mailsource.ReadPath / ReadArchive
+func (m *MailImport) Add(msg *mailsource.Message, err error) {
+	m.report.Read++
+	if err != nil {
+		m.report.Unreadable++
+		return
+	}
+	m.messages = append(m.messages, msg)
+}
+
+// ImportMail runs the collected messages through the email pipeline, oldest first so
+// statuses move in the order things happened. Only mail with one of the user's sync keywords
+// in the subject is imported, like the first sync, and replies in a conversation an earlier
+// email tied to a job. A dry run writes nothing (the LLM is still asked).
+func (s *EmailService) ImportMail(ctx context.Context, user *models.User, m *MailImport) (*ImportReport, error) {
+	// The watcher could be processing the same emails, a dry run doesn't race it
+	if !m.report.DryRun {
+		unlock, ok, err := s.Locks.TryLock(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrSyncRunning
+		}
+		defer unlock()
+	}
+	return s.importMail(ctx, user, m), nil
+}
+
+// importMail is ImportMail for callers that hold the user's sync lock
+func (s *EmailService) importMail(ctx context.Context, user *models.User, m *MailImport) *ImportReport {
+	report := m.report
+	filter := syncFilter(user)
+	filter.After, filter.Before = m.after, m.before
+
+	// Replies without a keyword wait for the emails before them to link their thread
+	var messages []*mailsource.Message
+	replies := map[*mailsource.Message]bool{}
+	for _, msg := range m.messages {
+		switch {
+		case filter.Matches(msg):
+			messages = append(messages, msg)
+		case filter.InRange(msg) && inThread(msg):
+			messages = append(messages, msg)
+			replies[msg] = true
+		default:
+			report.Unrelated++
+		}
+	}
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].Date.Before(messages[j].Date) })
+
+	var dry *dryRun
+	seen := map[string]bool{} // Duplicates within the archive, a dry run doesn't mark anything processed
+	if report.DryRun {
+		dry = newDryRun()
+	}
+
+	log.Printf("📦 [%s] Importing %d job-related emails and %d possible replies (of %d read)...", user.Email, len(messages)-len(replies), len(replies), report.Read)
+	for _, msg := range messages {
+		if ctx.Err() != nil {
+			break
+		}
+		if replies[msg] {
+			if job, err := s.threadJob(user.ID, msg, nil); job == nil || err != nil {
+				report.Unrelated++
+				continue
+			}
+		}
+		if s.alreadyProcessed(user.ID, msg) || anySeen(seen, msg.DedupKeys()) {
+			report.Duplicates++
+			continue
+		}
+
+		outcome := s.processSingleEmail(ctx, user, msg, dry, nil)
+		if dry == nil && outcome.Action != OutcomeFailed {
+			// Failed ones are tried again by the next import
+			s.saveEmail(user.ID, msg, outcome)
+			s.markProcessed(user.ID, msg)
+		}
+
+		report.Processed++
+		report.Counts[outcome.Action]++
+		report.Outcomes = append(report.Outcomes, *outcome)
+		if outcome.Action == OutcomeStatusChanged {
+			report.Changes = append(report.Changes, *outcome)
+		}
+	}
+	log.Printf("📦 [%s] Import done: %d processed, %d status changes, %d duplicates.", user.Email, report.Processed, len(report.Changes), report.Duplicates)
+	return report
+}
+
+// Backfill imports the mail the user received in [after, before) from their connected
+// mailboxes, e.g. to pick up history older than the first sync's window.
+// before zero = until now. The sync position doesn't move, processed mail is skipped.
+func (s *EmailService) Backfill(ctx context.Context, user *models.User, after, before time.Time, dryRun bool) (*ImportReport, error) {
+	boxes := s.mailboxesFor(ctx, user)
+	if len(boxes) == 0 {
+		return nil, ErrNoMailbox
+	}
+	// The watcher would process the same emails
+	unlock, ok, err := s.Locks.TryLock(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrSyncRunning
+	}
+	defer unlock()
+
+	filter := syncFilter(user)
+	filter.After, filter.Before = after, before
+
+	imp := NewMailImport(dryRun)
+	imp.after, imp.before = after, before
+	for _, box := range boxes {
+		if err := s.backfillMailbox(ctx, user, box, filter, imp); err != nil {
+			return nil, fmt.Errorf("%s: %w", box.name, err)
+		}
+	}
+	return s.importMail(ctx, user, imp), nil
+}
+
+// backfillMailbox fetches the mailbox's messages matching the filter into imp
+func (s *EmailService) backfillMailbox(ctx context.Context, user *models.User, box mailbox, filter mailsource.Filter, imp *MailImport) error {
+	source, err := box.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	ids, err := source.Search(ctx, filter)
+	if err != nil {
+		return err
+	}
+	log.Printf("🔎 [%s %s] Backfill found %d emails", user.Email, box.name, len(ids))
+	for start := 0; start < len(ids); start += syncBatchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Gmail IDs are dedup keys already, no need to download what we've seen
+		var fetch []string
+		for _, id := range ids[start:min(start+syncBatchSize, len(ids))] {
+			if s.alreadyProcessed(user.ID, &mailsource.Message{ID: id}) {
+				imp.report.Read++
+				imp.report.Duplicates++
+			} else {
+				fetch = append(fetch, id)
+			}
+		}
+
+		msgs, errs := s.fetchBatch(ctx, source, fetch)
+		for i := range fetch {
+			if msgs[i] != nil || errs[i] != nil { // Neither = deleted meanwhile
+				imp.Add(msgs[i], errs[i])
+			}
+		}
+	}
+	return nil
+}
+
+// anySeen reports whether one of the keys was seen before, and remembers them all
+func anySeen(seen map[string]bool, keys []string) bool {
+	found := false
+	for _, k := range keys {
+		found = found || seen[k]
+		seen[k] = true
+	}
+	return found
+}
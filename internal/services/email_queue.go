@@ -0,0 +1,173 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailsource"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxRetryBackoff caps the wait between two attempts at an email
+const maxRetryBackoff = 6 * time.Hour
+
+// queuePurgeInterval is how often the watcher deletes emails past the queue's Retention
+const queuePurgeInterval = time.Hour
+
+var ErrQueuedEmailNotFound = errors.New("failed email not found")
+
+// EmailQueueService holds fetched emails until the pipeline has processed them, so an LLM
+// outage or a bad answer delays an email instead of losing it. A user's queue is only worked
+// under their sync lock, which is why claiming needs no row locks.
+type EmailQueueService struct {
+	DB *gorm.DB
+
+	// MaxAttempts is how often an email is tried before it's dead-lettered (FAILED).
+	// The first retry waits RetryBackoff, every further one twice as long.
+	MaxAttempts  int
+	RetryBackoff time.Duration
+
+	// Retention is how long DONE emails are kept before PurgeDone deletes them (0 = forever).
+	// The dedup table, not the queue, keeps a purged email from being processed again.
+	Retention time.Duration
+}
+
+func NewEmailQueueService(db *gorm.DB) *EmailQueueService {
+	return &EmailQueueService{DB: db, MaxAttempts: 5, RetryBackoff: time.Minute, Retention: 7 * 24 * time.Hour}
+}
+
+// Enqueue adds fetched messages of a mailbox. Messages queued before are left as they are.
+func (q *EmailQueueService) Enqueue(userID uint, mailbox string, msgs []*mailsource.Message) error {
+	items := make([]models.QueuedEmail, 0, len(msgs))
+	now := time.Now()
+	for _, msg := range msgs {
+		data, err := models.NewJSON(msg)
+		if err != nil {
+			return err
+		}
+		items = append(items, models.QueuedEmail{
+			UserID:        userID,
+			Mailbox:       mailbox,
+			MessageID:     msg.ID,
+			Subject:       msg.Header("Subject"),
+			Sender:        msg.Header("From"),
+			Message:       data,
+			State:         models.QueueStatePending,
+			NextAttemptAt: now,
+		})
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return q.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&items).Error
+}
+
+// Claim marks up to limit of the user's due emails PROCESSING and returns them, oldest first
+func (q *EmailQueueService) Claim(userID uint, limit int) ([]models.QueuedEmail, error) {
+	items := []models.QueuedEmail{}
+	err := q.DB.Where("user_id = ? AND state = ? AND next_attempt_at <= ?", userID, models.QueueStatePending, time.Now()).
+		Order("id").
+		Limit(limit).
+		Find(&items).Error
+	if err != nil || len(items) == 0 {
+		return items, err
+	}
+	ids := make([]uint, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+		items[i].State = models.QueueStateProcessing
+	}
+	err = q.DB.Model(&models.QueuedEmail{}).Where("id IN ?", ids).Update("state", models.QueueStateProcessing).Error
+	return items, err
+}
+
+// Recover puts back the user's PROCESSING emails, left over from a process that died mid-sync
+func (q *EmailQueueService) Recover(userID uint) error {
+	return q.DB.Model(&models.QueuedEmail{}).
+		Where("user_id = ? AND state = ?", userID, models.QueueStateProcessing).
+		Update("state", models.QueueStatePending).Error
+}
+
+// Finish marks the email DONE and drops its content
+func (q *EmailQueueService) Finish(item *models.QueuedEmail) error {
+	return q.DB.Model(item).Updates(map[string]interface{}{
+		"state":      models.QueueStateDone,
+		"message":    nil,
+		"last_error": "",
+	}).Error
+}
+
+// PurgeDone deletes every user's DONE emails finished more than Retention ago and returns how many
+func (q *EmailQueueService) PurgeDone() (int64, error) {
+	if q.Retention <= 0 {
+		return 0, nil
+	}
+	res := q.DB.Where("state = ? AND updated_at < ?", models.QueueStateDone, time.Now().Add(-q.Retention)).
+		Delete(&models.QueuedEmail{})
+	return res.RowsAffected, res.Error
+}
+
+// Release puts an email back as it was, for attempts that were cut off rather than failed
+func (q *EmailQueueService) Release(item *models.QueuedEmail) error {
+	return q.DB.Model(item).Update("state", models.QueueStatePending).Error
+}
+
+// Fail records a failed attempt and schedules the next one, or dead-letters the email
+// after MaxAttempts
+func (q *EmailQueueService) Fail(item *models.QueuedEmail, cause error) error {
+	item.Attempts++
+	item.LastError = cause.Error()
+	item.State = models.QueueStatePending
+	item.NextAttemptAt = time.Now().Add(q.backoff(item.Attempts))
+	if item.Attempts >= q.MaxAttempts {
+		item.State = models.QueueStateFailed
+	}
+	return q.DB.Model(item).Select("attempts", "last_error", "state", "next_attempt_at").Updates(item).Error
+}
+
+// backoff is the wait after the nth failed attempt: RetryBackoff, then doubling up to maxRetryBackoff
+func (q *EmailQueueService) backoff(attempts int) time.Duration {
+	d := q.RetryBackoff
+	for i := 1; i < attempts && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxRetryBackoff)
+}
+
+// Failed lists the user's dead letters, most recently failed first
+func (q *EmailQueueService) Failed(userID uint) ([]models.QueuedEmail, error) {
+	items := []models.QueuedEmail{}
+	err := q.DB.Where("user_id = ? AND state = ?", userID, models.QueueStateFailed).
+		Order("updated_at DESC, id DESC").
+		Find(&items).Error
+	return items, err
+}
+
+// Requeue gives a dead letter a fresh set of attempts, starting with the next sync
+func (q *EmailQueueService) Requeue(userID, id uint) (*models.QueuedEmail, error) {
+	var item models.QueuedEmail
+	err := q.DB.Where("id = ? AND user_id = ? AND state = ?", id, userID, models.QueueStateFailed).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrQueuedEmailNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	item.State, item.Attempts, item.NextAttemptAt = models.QueueStatePending, 0, time.Now()
+	err = q.DB.Model(&item).Select("state", "attempts", "next_attempt_at").Updates(&item).Error
+	return &item, err
+}
+
+// RequeueAll requeues every dead letter of the user and returns how many there were
+func (q *EmailQueueService) RequeueAll(userID uint) (int64, error) {
+	res := q.DB.Model(&models.QueuedEmail{}).
+		Where("user_id = ? AND state = ?", userID, models.QueueStateFailed).
+		Updates(map[string]interface{}{
+			"state":           models.QueueStatePending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	return res.RowsAffected, res.Error
+}
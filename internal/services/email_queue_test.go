@@ -0,0 +1,197 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailsource"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestQueueBackoff(t *testing.T) {
+	q := &EmailQueueService{RetryBackoff: time.Minute}
+	want := []time.Duration{
+		time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute,
+		32 * time.Minute, 64 * time.Minute, 128 * time.Minute, 256 * time.Minute,
+		6 * time.Hour, 6 * time.Hour, // Capped
+	}
+	for i, w := range want {
+		if got := q.backoff(i + 1); got != w {
+			t.Errorf("after attempt %d: waits %s, want %s", i+1, got, w)
+		}
+	}
+	if got := q.backoff(1000); got != maxRetryBackoff {
+		t.Errorf("after attempt 1000: waits %s, want the cap", got)
+	}
+
+	// A first wait above the cap is capped too
+	q.RetryBackoff = 10 * time.Hour
+	if got := q.backoff(1); got != maxRetryBackoff {
+		t.Errorf("with a 10h backoff: waits %s, want the cap", got)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		q := NewEmailQueueService(db)
+		q.MaxAttempts = 3
+		me := createUser(t, db, "me@example.com")
+		msgs := []*mailsource.Message{
+			{ID: "<1@acme.com>", Headers: map[string]string{"Subject": "Interview"}},
+			{ID: "<2@acme.com>", Headers: map[string]string{"Subject": "Offer"}},
+		}
+		if err := q.Enqueue(me, "imap", msgs); err != nil {
+			t.Fatal(err)
+		}
+		// Fetching them again doesn't queue them twice
+		if err := q.Enqueue(me, "imap", msgs[:1]); err != nil {
+			t.Fatal(err)
+		}
+
+		// 1. Claiming takes the due emails, oldest first, and only once
+		items, err := q.Claim(me, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 2 || items[0].MessageID != "<1@acme.com>" || items[0].State != models.QueueStateProcessing {
+			t.Fatalf("claimed %+v", items)
+		}
+		if again, _ := q.Claim(me, 10); len(again) != 0 {
+			t.Errorf("claimed %d emails twice", len(again))
+		}
+		if err := q.Finish(&items[1]); err != nil {
+			t.Fatal(err)
+		}
+
+		// 2. A failure waits for its backoff, then the email is claimed again
+		item := &items[0]
+		start := time.Now()
+		if err := q.Fail(item, errors.New("LLM is down")); err != nil {
+			t.Fatal(err)
+		}
+		if item.State != models.QueueStatePending || item.Attempts != 1 || item.LastError != "LLM is down" {
+			t.Errorf("after the first failure: %+v", item)
+		}
+		if wait := item.NextAttemptAt.Sub(start); wait < q.RetryBackoff || wait > q.RetryBackoff+time.Second {
+			t.Errorf("retries after %s, want %s", wait, q.RetryBackoff)
+		}
+		if early, _ := q.Claim(me, 10); len(early) != 0 {
+			t.Errorf("claimed an email before its backoff ran out")
+		}
+
+		due := func() *models.QueuedEmail {
+			t.Helper()
+			if err := db.Model(item).UpdateColumn("next_attempt_at", time.Now().Add(-time.Second)).Error; err != nil {
+				t.Fatal(err)
+			}
+			claimed, err := q.Claim(me, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(claimed) != 1 || claimed[0].ID != item.ID {
+				t.Fatalf("claimed %+v once the backoff ran out", claimed)
+			}
+			return &claimed[0]
+		}
+		item = due()
+		if err := q.Fail(item, errors.New("LLM is down")); err != nil {
+			t.Fatal(err)
+		}
+		if item.State != models.QueueStatePending || item.Attempts != 2 {
+			t.Errorf("after the second failure: %+v", item)
+		}
+
+		// 3. The last attempt dead-letters it, it's listed and never claimed again
+		item = due()
+		if err := q.Fail(item, errors.New("still down")); err != nil {
+			t.Fatal(err)
+		}
+		if item.State != models.QueueStateFailed || item.Attempts != 3 {
+			t.Errorf("after the last failure: %+v", item)
+		}
+		db.Model(item).UpdateColumn("next_attempt_at", time.Now().Add(-time.Second))
+		if claimed, _ := q.Claim(me, 10); len(claimed) != 0 {
+			t.Errorf("claimed a dead letter")
+		}
+		failed, err := q.Failed(me)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(failed) != 1 || failed[0].ID != item.ID || failed[0].LastError != "still down" {
+			t.Errorf("dead letters: %+v", failed)
+		}
+
+		// 4. Requeueing starts over
+		requeued, err := q.Requeue(me, item.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if requeued.State != models.QueueStatePending || requeued.Attempts != 0 {
+			t.Errorf("requeued: %+v", requeued)
+		}
+		if claimed, _ := q.Claim(me, 10); len(claimed) != 1 {
+			t.Errorf("claimed %d emails after the requeue, want 1", len(claimed))
+		}
+	})
+}
+
+func TestQueuePurge(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		q := NewEmailQueueService(db)
+		q.MaxAttempts = 1
+		me := createUser(t, db, "me@example.com")
+		other := createUser(t, db, "other@example.com")
+		for _, userID := range []uint{me, other} {
+			err := q.Enqueue(userID, "gmail", []*mailsource.Message{{ID: "old"}, {ID: "new"}, {ID: "old-failed"}, {ID: "old-pending"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			items, err := q.Claim(userID, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range items {
+				item := &items[i]
+				switch item.MessageID {
+				case "old", "new":
+					err = q.Finish(item)
+				case "old-failed":
+					err = q.Fail(item, errors.New("unreadable"))
+				case "old-pending":
+					err = q.Release(item)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		err := db.Model(&models.QueuedEmail{}).Where("message_id <> ?", "new").
+			UpdateColumn("updated_at", time.Now().Add(-q.Retention-time.Hour)).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Only DONE emails past the retention go, of every user
+		purged, err := q.PurgeDone()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if purged != 2 {
+			t.Errorf("purged %d emails, want 2", purged)
+		}
+		var left []string
+		db.Model(&models.QueuedEmail{}).Where("user_id = ?", me).Order("message_id").Pluck("message_id", &left)
+		if len(left) != 3 || left[0] != "new" || left[1] != "old-failed" || left[2] != "old-pending" {
+			t.Errorf("left %v", left)
+		}
+
+		// Without a retention nothing goes
+		db.Model(&models.QueuedEmail{}).Where("message_id = ?", "new").UpdateColumn("updated_at", time.Now().AddDate(-1, 0, 0))
+		q.Retention = 0
+		if purged, err := q.PurgeDone(); purged != 0 || err != nil {
+			t.Errorf("purged %d emails without a retention: %v", purged, err)
+		}
+	})
+}
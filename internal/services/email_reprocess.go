@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/dtos"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailsource"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+)
+
+// ReprocessReport compares what the pipeline makes of stored emails now with what it made of them before
+type ReprocessReport struct {
+	DryRun  bool   `json:"dry_run"`
+	Model   string `json:"model"`   // The provider/model that classified them this time
+	Emails  int    `json:"emails"`  // Matched the query
+	Changed int    `json:"changed"` // Read as another status or job than before
+	Failed  int    `json:"failed"`  // Couldn't be classified, the stored verdict stays
+
+	// Counts is the number of emails per outcome action
+	Counts map[string]int `json:"counts"`
+	// Diffs are the emails read differently than before
+	Diffs []EmailDiff `json:"diffs"`
+	// Changes are the job status changes that were applied (or would be, in a dry run)
+	Changes []EmailOutcome `json:"changes"`
+}
+
+// EmailDiff is an email whose verdict changed
+type EmailDiff struct {
+	EmailID uint         `json:"email_id"`
+	Subject string       `json:"subject"`
+	Before  EmailVerdict `json:"before"`
+	After   EmailVerdict `json:"after"`
+}
+
+// EmailVerdict is the part of a classification a diff compares
+type EmailVerdict struct {
+	JobID  *uint  `json:"job_id,omitempty"`
+	Action string `json:"action"`
+	Status string `json:"status,omitempty"` // What the LLM read from the email
+	Model  string `json:"model,omitempty"`
+}
+
+// Reprocess runs the stored emails the query picks through matching and classification
+// again, oldest first, e.g. after a prompt change or with another provider. A dry run only
+// reports: it asks the LLM but writes nothing, so it shows the blast radius of the change.
+// Otherwise status changes are applied, and the new verdicts replace the stored ones.
+// Emails older than their job's last status change only get a new verdict, see outdated.
+func (s *EmailService) Reprocess(ctx context.Context, user *models.User, q *dtos.ReprocessQuery) (*ReprocessReport, error) {
+	// 1. Writing races the watcher, a dry run doesn't
+	if !q.DryRun {
+		unlock, ok, err := s.Locks.TryLock(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrSyncRunning
+		}
+		defer unlock()
+	}
+
+	// 2. Pick the emails
+	tx := s.DB.Where("user_id = ?", user.ID)
+	if !q.From.IsZero() {
+		tx = tx.Where("received_at >= ?", q.From)
+	}
+	if !q.To.IsZero() {
+		tx = tx.Where("received_at < ?", q.To.AddDate(0, 0, 1)) // "to" is inclusive
+	}
+	if q.JobID != 0 {
+		tx = tx.Where("job_id = ?", q.JobID)
+	}
+	if q.Company != "" {
+		tx = tx.Where("job_id IN (?)", s.DB.Model(&models.Job{}).Select("jobs.id").
+			Joins("JOIN companies ON companies.id = jobs.company_id").
+			Where("jobs.user_id = ? AND LOWER(companies.name) = LOWER(?)", user.ID, q.Company))
+	}
+	emails := []models.Email{}
+	if err := tx.Order("received_at ASC, id ASC").Find(&emails).Error; err != nil {
+		return nil, err
+	}
+
+	report := &ReprocessReport{
+		DryRun:  q.DryRun,
+		Model:   s.LLMService.ModelVersion(),
+		Emails:  len(emails),
+		Counts:  map[string]int{},
+		Diffs:   []EmailDiff{},
+		Changes: []EmailOutcome{},
+	}
+	var dry *dryRun
+	if q.DryRun {
+		dry = newDryRun()
+	}
+	run := newReprocessRun()
+
+	// 3. Classify them again and compare
+	log.Printf("♻️ [%s] Reprocessing %d emails with %s...", user.Email, len(emails), report.Model)
+	for i := range emails {
+		if ctx.Err() != nil {
+			break
+		}
+		email := &emails[i]
+		msg := storedMessage(email)
+		if dry == nil {
+			// The new verdict replaces reviews nobody has looked at yet
+			err := s.DB.Where("user_id = ? AND message_id = ? AND state = ?", user.ID, email.MessageID, models.ReviewStatePending).
+				Delete(&models.PendingReview{}).Error
+			if err != nil {
+				return nil, err
+			}
+			// And the thread's old link, unless an earlier email of the run made it
+			if _, relinked := run.links[msg.Thread()]; !relinked {
+				err := s.DB.Where("user_id = ? AND thread_id = ?", user.ID, msg.Thread()).Delete(&models.EmailThread{}).Error
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		outcome := s.processSingleEmail(ctx, user, msg, dry, run)
+		report.Counts[outcome.Action]++
+		if outcome.Action == OutcomeFailed {
+			report.Failed++
+			continue
+		}
+		if outcome.Action == OutcomeStatusChanged {
+			report.Changes = append(report.Changes, *outcome)
+		}
+
+		before := EmailVerdict{JobID: email.JobID, Action: email.Classification.Action, Status: email.Classification.Status, Model: email.Classification.Model}
+		after := EmailVerdict{JobID: outcome.JobID, Action: outcome.Action, Status: outcome.ToStatus, Model: report.Model}
+		if before.Status != after.Status || !sameJob(before.JobID, after.JobID) {
+			report.Changed++
+			report.Diffs = append(report.Diffs, EmailDiff{EmailID: email.ID, Subject: email.Subject, Before: before, After: after})
+		}
+		if dry == nil {
+			s.saveEmail(user.ID, msg, outcome)
+		}
+	}
+	log.Printf("♻️ [%s] Reprocessed %d emails: %d read differently, %d job status changes, %d failed.", user.Email, report.Emails, report.Changed, len(report.Changes), report.Failed)
+	return report, nil
+}
+
+// reprocessRun is what a reprocess run carries from one email to the next
+type reprocessRun struct {
+	// links: replies are matched again too, through the threads the run links anew
+	links threadLinks
+	// statusSince is when each job got the status it had before the run
+	statusSince map[uint]time.Time
+}
+
+func newReprocessRun() *reprocessRun {
+	return &reprocessRun{links: threadLinks{}, statusSince: map[uint]time.Time{}}
+}
+
+// outdated reports whether the email predates the job's status from before the run. Its
+// verdict is then only reported: an old invite must not revive a job that was ghosted
+// since. Changes the run makes itself don't count, so a dry run and a real one agree.
+// An email without a date can't be placed and counts as outdated.
+func (s *EmailService) outdated(run *reprocessRun, job *models.Job, msg *mailsource.Message) (bool, error) {
+	since, ok := run.statusSince[job.ID]
+	if !ok {
+		var err error
+		if since, err = s.JobService.StatusSince(job); err != nil {
+			return false, err
+		}
+		run.statusSince[job.ID] = since
+	}
+	return msg.Date.IsZero() || msg.Date.Before(since), nil
+}
+
+// storedMessage rebuilds the message the pipeline saw from a stored email
+func storedMessage(email *models.Email) *mailsource.Message {
+	msg := &mailsource.Message{ID: email.MessageID, ThreadID: email.ThreadID, Body: email.Body}
+	// Unreadable headers leave Subject and From, which have their own columns
+	if err := email.Headers.Decode(&msg.Headers); err != nil || msg.Headers == nil {
+		msg.Headers = map[string]string{"Subject": email.Subject, "From": email.Sender}
+	}
+	if email.ReceivedAt != nil {
+		msg.Date = *email.ReceivedAt
+	}
+	return msg
+}
+
+func sameJob(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/dtos"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/status"
+	"gorm.io/gorm"
+)
+
+func storeEmail(t *testing.T, db *gorm.DB, userID, jobID uint, messageID, subject, body string, received time.Time) {
+	t.Helper()
+	headers, _ := models.NewJSON(map[string]string{"Subject": subject, "From": "Acme <jobs@acme.com>"})
+	email := &models.Email{
+		UserID:         userID,
+		JobID:          &jobID,
+		MessageID:      messageID,
+		ThreadID:       messageID,
+		Subject:        subject,
+		Sender:         "Acme <jobs@acme.com>",
+		Headers:        headers,
+		Body:           body,
+		ReceivedAt:     &received,
+		Classification: models.EmailClassification{Action: OutcomeNoChange, Model: "rules/rules-v0"},
+	}
+	if err := db.Create(email).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// An old invite must not revive a job that was ghosted after it. A dry run and a real run
+// of the same emails report the same thing.
+func TestReprocessOutdated(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		svc := emailService(db)
+		jobs := svc.JobService
+		ctx := context.Background()
+		me := loadUser(t, db, createUser(t, db, "me@example.com"))
+		now := time.Now()
+
+		// Applied a month ago, ghosted two days ago
+		job := mustCreate(t, jobs, me.ID, newJob("Acme", "Backend Engineer"))
+		if _, err := jobs.ChangeStatus(me.ID, job.ID, StatusChange{To: status.Ghosted, Source: SourceManual}); err != nil {
+			t.Fatal(err)
+		}
+		db.Model(&models.JobEvent{}).Where("job_id = ? AND kind = ?", job.ID, models.EventCreated).Update("created_at", now.AddDate(0, 0, -30))
+		db.Model(&models.JobEvent{}).Where("job_id = ? AND kind = ?", job.ID, models.EventStatusChanged).Update("created_at", now.AddDate(0, 0, -2))
+
+		storeEmail(t, db, me.ID, job.ID, "<invite@acme.com>", "Next steps", "We'd like to invite you to interview.", now.AddDate(0, 0, -20))
+		storeEmail(t, db, me.ID, job.ID, "<screen@acme.com>", "Checking in", "Can we schedule a call this week?", now.AddDate(0, 0, -1))
+
+		dry, err := svc.Reprocess(ctx, me, &dtos.ReprocessQuery{DryRun: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := jobs.GetJob(me.ID, job.ID); got.Status != status.Ghosted {
+			t.Fatalf("the dry run moved the job to %s", got.Status)
+		}
+		report, err := svc.Reprocess(ctx, me, &dtos.ReprocessQuery{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wantCounts := map[string]int{OutcomeOutdated: 1, OutcomeStatusChanged: 1}
+		for _, r := range []*ReprocessReport{dry, report} {
+			if !reflect.DeepEqual(r.Counts, wantCounts) {
+				t.Errorf("dry run %v: counts %v, want %v", r.DryRun, r.Counts, wantCounts)
+			}
+			if len(r.Changes) != 1 || r.Changes[0].ToStatus != "SCREEN" {
+				t.Errorf("dry run %v: changes %+v, want only the screen", r.DryRun, r.Changes)
+			}
+			if len(r.Diffs) != 2 || r.Diffs[0].After.Status != "INTERVIEW" || r.Diffs[0].After.Action != OutcomeOutdated {
+				t.Errorf("dry run %v: diffs %+v, want the invite reported as outdated", r.DryRun, r.Diffs)
+			}
+		}
+		if !reflect.DeepEqual(dry.Diffs, report.Diffs) || !reflect.DeepEqual(dry.Changes, report.Changes) {
+			t.Errorf("the dry run reported\n%+v\n%+v\nthe real one\n%+v\n%+v", dry.Diffs, dry.Changes, report.Diffs, report.Changes)
+		}
+
+		// Only the newer email moved the job, nothing went to review
+		got, _ := jobs.GetJob(me.ID, job.ID)
+		if got.Status != status.Screen || got.InterviewRound != 0 {
+			t.Errorf("job is %s round %d, want SCREEN round 0", got.Status, got.InterviewRound)
+		}
+		if pending, _ := svc.ReviewService.List(me.ID, ""); len(pending) != 0 {
+			t.Errorf("%d reviews queued", len(pending))
+		}
+		var invite models.Email
+		db.Where("message_id = ?", "<invite@acme.com>").First(&invite)
+		if invite.Classification.Action != OutcomeOutdated || invite.Classification.Status != "INTERVIEW" {
+			t.Errorf("stored verdict %+v", invite.Classification)
+		}
+	})
+}
@@ -2,365 +2,850 @@ package services
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/auth"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/llm"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailbody"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailsource"
 	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/status"
+	"golang.org/x/oauth2"
 	"google.golang.org/api/gmail/v1"
-	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type EmailService struct {
 	DB             *gorm.DB
 	LLMService     *LLMService
 	MatcherService *MatcherService
-	GmailClient    *gmail.Service
+	JobService     *JobService
+	ReviewService  *ReviewService
+	UserService    *UserService
+
+	// OAuthConfig is the app's Gmail client, every user syncs with their own token (nil = Gmail disabled)
+	OAuthConfig *oauth2.Config
+	// IMAPAccounts holds the users' IMAP mailboxes (nil = IMAP disabled)
+	IMAPAccounts *IMAPAccountService
+
+	// PollInterval is how often the inboxes are checked, SyncTimeout bounds one user's cycle
+	PollInterval time.Duration
+	SyncTimeout  time.Duration
+
+	// Workers is how many messages of one mailbox are fetched and processed at once
+	Workers int
+	// Users is how many users are synced at once. A sync holds its lock, and on Postgres
+	// with it a pooled connection, so this stays below the pool size.
+	Users int
+	// GmailQuota is the Gmail API quota units one user's sync may spend per second
+	GmailQuota int
+	quotas     sync.Map // User ID -> *rate.Limiter, shared by all of the user's cycles
+
+	// PushTopic is the Pub/Sub topic Gmail pushes mailbox changes to ("" = polling only).
+	// With push, Gmail is still polled every PushFallback in case a push gets lost.
+	PushTopic    string
+	PushFallback time.Duration
+	kicks        chan uint // Users whose Gmail was pushed, for the watcher loop
+	gmailPolled  sync.Map  // User ID -> time.Time of the last Gmail sync
+
+	// Locks keeps the watcher, backfills and other instances from syncing a user twice at once
+	Locks SyncLocker
+	// Queue holds the fetched emails until they're processed
+	Queue *EmailQueueService
 }
 
-func NewEmailService(db *gorm.DB, llm *LLMService, gmail *gmail.Service, matcher *MatcherService) *EmailService {
+func NewEmailService(db *gorm.DB, llm *LLMService, oauthConfig *oauth2.Config, users *UserService, matcher *MatcherService, jobs *JobService, reviews *ReviewService) *EmailService {
 	return &EmailService{
 		DB:             db,
 		LLMService:     llm,
-		GmailClient:    gmail,
+		OAuthConfig:    oauthConfig,
+		UserService:    users,
 		MatcherService: matcher,
+		JobService:     jobs,
+		ReviewService:  reviews,
+		PollInterval:   1 * time.Minute,
+		SyncTimeout:    2 * time.Minute,
+		Workers:        4,
+		Users:          4,
+		GmailQuota:     100,
+		PushFallback:   15 * time.Minute,
+		Locks:          NewSyncLocker(db),
+		Queue:          NewEmailQueueService(db),
 	}
 }
 
-// StartWatcher starts the background polling
-func (s *EmailService) StartWatcher() {
-	if s.GmailClient == nil {
-		log.Println("⚠️ Gmail Watcher disabled (no client). Check credentials.")
-		return
+// StartWatcher starts the background polling, and the pushed syncs when PushTopic is set,
+// until ctx is cancelled. Cancelling also stops the syncs in flight, the returned channel
+// is closed once they have.
+func (s *EmailService) StartWatcher(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	if s.OAuthConfig == nil && s.IMAPAccounts == nil {
+		log.Println("⚠️ Email Watcher disabled (no Gmail OAuth client, no IMAP). Check credentials.")
+		close(done)
+		return done
 	}
 
-	// Ticker triggers every 15 minutes
-	ticker := time.NewTicker(1 * time.Minute)
-
-	// Run immediately on startup
-	go s.SyncEmails()
+	// Ticker triggers every PollInterval (1 minute by default). One loop runs the cycles,
+	// so a slow one delays the next instead of overlapping it.
+	ticker := time.NewTicker(s.PollInterval)
+	purge := time.NewTicker(queuePurgeInterval)
+	s.kicks = make(chan uint, kickQueueSize)
 
 	go func() {
-		for range ticker.C {
-			s.SyncEmails()
+		defer close(done)
+		defer ticker.Stop()
+		defer purge.Stop()
+
+		// Run immediately on startup
+		s.SyncEmails(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("🛑 Email Watcher stopped.")
+				return
+			case <-ticker.C:
+				s.SyncEmails(ctx)
+			case userID := <-s.kicks:
+				s.syncPushed(ctx, userID)
+			case <-purge.C:
+				s.purgeQueue()
+			}
 		}
 	}()
+	return done
 }
 
-// SyncEmails is the main orchestrator
-func (s *EmailService) SyncEmails() {
-	// 1. Timeout Context: Prevent hanging forever (2 minute limit)
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-	defer cancel()
-
-	log.Println("📧 Email Watcher: Starting Sync Cycle...")
+// purgeQueue drops the queued emails that were processed long enough ago
+func (s *EmailService) purgeQueue() {
+	n, err := s.Queue.PurgeDone()
+	if err != nil {
+		log.Printf("❌ Email Watcher: Could not purge the email queue: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("🧹 Email Watcher: Purged %d processed emails from the queue.", n)
+	}
+}
 
-	// 2. Get User State
-	var user models.User
-	if err := s.DB.First(&user).Error; err != nil {
-		// Create default user if missing
-		user = models.User{Email: "default", LastHistoryID: 0}
-		s.DB.Create(&user)
+// SyncEmails runs one cycle for every connected mailbox.
+// Users sync independently, Users at a time, so one expired token or slow inbox doesn't
+// hold up the others.
+func (s *EmailService) SyncEmails(ctx context.Context) {
+	users, err := s.usersWithMail(ctx)
+	if err != nil {
+		log.Printf("❌ Email Watcher: Could not load users: %v", err)
+		return
+	}
+	if len(users) == 0 {
+		log.Println("📭 Email Watcher: No connected mailboxes.")
+		return
 	}
 
-	var messages []*gmail.Message
-	var newHistoryID uint64
-	var err error
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(s.Users, 1))
+	for i := range users {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(user *models.User) {
+			defer func() { <-sem; wg.Done() }()
+			s.syncUser(ctx, user, false)
+		}(&users[i])
+	}
+	wg.Wait()
+}
 
-	// 3. Decide Strategy: Bootstrap (Full) or Incremental
-	if user.LastHistoryID == 0 {
-		log.Println("🆕 First run detected. Running Full Bootstrap Sync...")
-		messages, newHistoryID, err = s.performFullSync(ctx)
-	} else {
-		// Try Incremental Sync
-		messages, newHistoryID, err = s.performIncrementalSync(ctx, user.LastHistoryID)
-
-		// Handle "History Expired" (404) - Google deleted old history
-		if err != nil && isHistoryExpiredError(err) {
-			log.Println("⚠️ History ID expired (too old). Falling back to Full Sync.")
-			messages, newHistoryID, err = s.performFullSync(ctx)
-		} else if err != nil {
-			log.Printf("❌ Sync failed: %v", err)
-			return
+// usersWithMail returns the users with a Gmail token or an IMAP account
+func (s *EmailService) usersWithMail(ctx context.Context) ([]models.User, error) {
+	ids := map[uint]bool{}
+	if s.OAuthConfig != nil {
+		connected, err := s.UserService.Connected(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range connected {
+			ids[u.ID] = true
 		}
 	}
-
-	if len(messages) == 0 {
-		log.Println("✅ No new relevant emails found.")
-		// Update History ID even if empty, so we don't check this window again
-		if newHistoryID > user.LastHistoryID {
-			s.updateUserHistoryID(user.ID, newHistoryID)
+	if s.IMAPAccounts != nil {
+		imapIDs, err := s.IMAPAccounts.UserIDs()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range imapIDs {
+			ids[id] = true
 		}
-		return
 	}
 
-	log.Printf("📥 Processing %d candidate emails...", len(messages))
+	users := []models.User{}
+	if len(ids) == 0 {
+		return users, nil
+	}
+	list := make([]uint, 0, len(ids))
+	for id := range ids {
+		list = append(list, id)
+	}
+	err := s.DB.Where("id IN ?", list).Order("id").Find(&users).Error
+	return users, err
+}
 
-	// 4. Process Messages with Deduplication
-	for _, msg := range messages {
-		// A. Check Dedup Table
-		var count int64
-		s.DB.Model(&models.ProcessedEmail{}).Where("id = ?", msg.Id).Count(&count)
-		if count > 0 {
-			continue // Already processed, skip
-		}
+// mailbox is one of a user's mail sources and where its sync position is kept
+type mailbox struct {
+	key        string // "gmail" | "imap", names its checkpoint
+	name       string // For the logs
+	open       func(ctx context.Context) (mailsource.Source, error)
+	cursor     string // "" = never synced
+	saveCursor func(cursor string) error
+}
 
-		// B. Process the Email (Core Logic)
-		s.processSingleEmail(ctx, msg)
+// mailboxesFor lists the sources the user's mail comes from
+func (s *EmailService) mailboxesFor(ctx context.Context, user *models.User) []mailbox {
+	var boxes []mailbox
 
-		// C. Mark as Processed
-		s.DB.Create(&models.ProcessedEmail{ID: msg.Id})
+	// Gmail: the cursor is the mailbox history ID
+	if s.OAuthConfig != nil && s.UserService.GmailConnected(ctx, user.ID) {
+		cursor := ""
+		if user.LastHistoryID > 0 {
+			cursor = strconv.FormatUint(user.LastHistoryID, 10)
+		}
+		boxes = append(boxes, mailbox{
+			key:  "gmail",
+			name: "gmail",
+			open: func(ctx context.Context) (mailsource.Source, error) {
+				gm, err := s.gmailFor(ctx, user)
+				if err != nil {
+					return nil, err
+				}
+				source := mailsource.NewGmail(gm, bootstrapFilter(user))
+				source.Quota = s.gmailQuota(user.ID)
+				return source, nil
+			},
+			cursor: cursor,
+			saveCursor: func(cursor string) error {
+				id, err := strconv.ParseUint(cursor, 10, 64)
+				if err != nil {
+					return err
+				}
+				return s.updateUserHistoryID(user.ID, id)
+			},
+		})
 	}
 
-	// 5. Update Bookmark (Save State)
-	if newHistoryID > user.LastHistoryID {
-		s.updateUserHistoryID(user.ID, newHistoryID)
-		log.Printf("🔖 History updated to %d", newHistoryID)
+	// IMAP: the cursor is UIDVALIDITY and the last UID
+	if s.IMAPAccounts != nil {
+		account, err := s.IMAPAccounts.Get(user.ID)
+		if err == nil {
+			boxes = append(boxes, mailbox{
+				key:  "imap",
+				name: "imap " + account.Username,
+				open: func(ctx context.Context) (mailsource.Source, error) {
+					source, err := s.IMAPAccounts.Source(account)
+					if err != nil {
+						return nil, err
+					}
+					source.Bootstrap = bootstrapFilter(user)
+					return source, nil
+				},
+				cursor: s.IMAPAccounts.Cursor(account),
+				saveCursor: func(cursor string) error {
+					return s.IMAPAccounts.SaveCursor(account.ID, cursor)
+				},
+			})
+		} else if !errors.Is(err, ErrIMAPNotConfigured) {
+			log.Printf("❌ [%s] Could not load IMAP account: %v", user.Email, err)
+		}
 	}
+	return boxes
 }
 
-// performFullSync scans the last 7 days and resets the History ID (Bootstrap)
-func (s *EmailService) performFullSync(ctx context.Context) ([]*gmail.Message, uint64, error) {
-	var resp *gmail.ListMessagesResponse
+// syncPushed syncs the Gmail of a user whose mailbox changed, see HandlePush
+func (s *EmailService) syncPushed(ctx context.Context, userID uint) {
+	user, err := s.UserService.Get(userID)
+	if err != nil {
+		log.Printf("❌ Email Watcher: Could not load user %d: %v", userID, err)
+		return
+	}
+	s.syncUser(ctx, user, true)
+}
 
-	// Query: Relevant keywords + newer than 7 days
-	q := "subject:(application OR interview OR update OR offer OR rejected OR status) newer_than:7d"
+// syncUser is the per-user orchestrator. A pushed sync only looks at Gmail.
+func (s *EmailService) syncUser(ctx context.Context, user *models.User, pushed bool) {
+	// 1. Timeout Context: Prevent hanging forever (SyncTimeout, 2 minutes by default)
+	ctx, cancel := context.WithTimeout(ctx, s.SyncTimeout)
+	defer cancel()
 
-	err := retry(3, 1*time.Second, func() error {
-		var e error
-		call := s.GmailClient.Users.Messages.List("me").Q(q).MaxResults(50)
-		resp, e = call.Context(ctx).Do()
-		return e
-	})
+	// 2. One sync per user at a time, the next cycle picks up whatever this one skips
+	unlock, ok, err := s.Locks.TryLock(ctx, user.ID)
+	if err != nil {
+		log.Printf("❌ Email Watcher [%s]: Could not take the sync lock: %v", user.Email, err)
+		return
+	}
+	if !ok {
+		log.Printf("⏭️ Email Watcher [%s]: Another sync is running, skipping.", user.Email)
+		return
+	}
+	defer unlock()
 
+	log.Printf("📧 Email Watcher [%s]: Starting Sync Cycle...", user.Email)
+
+	// 3. Every mailbox of the user goes through the same pipeline
+	for _, box := range s.mailboxesFor(ctx, user) {
+		if box.key == "gmail" {
+			if !pushed && !s.gmailPollDue(user.ID) {
+				continue // Pushes tell us when there's something new
+			}
+			s.gmailPolled.Store(user.ID, time.Now())
+			if s.PushTopic != "" {
+				if err := s.ensureWatch(ctx, user); err != nil {
+					log.Printf("❌ [%s gmail] Could not watch the mailbox for pushes: %v", user.Email, err)
+				}
+			}
+		} else if pushed {
+			continue
+		}
+		s.syncMailbox(ctx, user, box)
+	}
+
+	// 4. Process what the mailboxes brought in, and the retries that are due
+	s.processQueue(ctx, user)
+}
+
+// syncMailbox fetches what's new in one mailbox into the processing queue
+func (s *EmailService) syncMailbox(ctx context.Context, user *models.User, box mailbox) {
+	logPrefix := fmt.Sprintf("[%s %s]", user.Email, box.name)
+
+	// 1. Open the mailbox with the user's own credentials
+	source, err := box.open(ctx)
 	if err != nil {
-		return nil, 0, err
+		log.Printf("❌ %s Could not open mailbox: %v", logPrefix, err)
+		return
 	}
+	defer source.Close()
 
-	// Get the CURRENT History ID from Profile to set our new anchor
-	profile, err := s.GmailClient.Users.GetProfile("me").Context(ctx).Do()
+	// 2. Resume what the last cycle didn't get to
+	pending, err := s.loadCheckpoint(user.ID, box.key)
 	if err != nil {
-		return nil, 0, err
+		log.Printf("❌ %s Could not load checkpoint: %v", logPrefix, err)
+		return
+	}
+	if len(pending) > 0 {
+		log.Printf("⏯️ %s Resuming %d emails from the last cycle.", logPrefix, len(pending))
 	}
 
-	// We must fetch full message details for the list we found
-	fullMessages := s.expandMessages(ctx, resp.Messages)
+	// 3. Decide Strategy: Bootstrap (Full) or Incremental
+	if box.cursor == "" {
+		log.Printf("🆕 %s First run detected. Running Full Bootstrap Sync...", logPrefix)
+	}
+	ids, next, err := source.List(ctx, box.cursor)
 
-	return fullMessages, profile.HistoryId, nil
-}
+	// Handle an expired cursor (Gmail deleted old history, IMAP folder was rebuilt)
+	if errors.Is(err, mailsource.ErrCursorExpired) {
+		log.Printf("⚠️ %s Sync position expired (too old). Falling back to Full Sync.", logPrefix)
+		pending = nil // IDs from before may not point at the same messages anymore
+		ids, next, err = source.List(ctx, "")
+	}
+	if err != nil {
+		log.Printf("❌ %s Sync failed: %v", logPrefix, err)
+		if len(pending) == 0 {
+			return
+		}
+		ids, next = nil, box.cursor // Still work off the checkpoint
+	}
 
-// performIncrementalSync asks Google ONLY for what changed since startID
-func (s *EmailService) performIncrementalSync(ctx context.Context, startID uint64) ([]*gmail.Message, uint64, error) {
-	var resp *gmail.ListHistoryResponse
-
-	err := retry(3, 1*time.Second, func() error {
-		var e error
-		call := s.GmailClient.Users.History.List("me").StartHistoryId(startID)
-		// We only care about added messages, not label changes
-		call.HistoryTypes("messageAdded")
-		resp, e = call.Context(ctx).Do()
-		return e
-	})
+	work := appendNew(pending, ids)
+	if len(work) == 0 {
+		log.Printf("✅ %s No new relevant emails found.", logPrefix)
+	} else {
+		log.Printf("📥 %s Fetching %d candidate emails...", logPrefix, len(work))
+	}
 
-	if err != nil {
-		return nil, 0, err
+	// 4. Checkpoint the work, then update the bookmark (Save State), even if nothing came in,
+	// so we don't check this window again. From here on, a cycle that runs out of time resumes
+	// with what's left instead of listing and fetching everything again.
+	if err := s.saveCheckpoint(user.ID, box.key, work); err != nil {
+		log.Printf("❌ %s Could not save checkpoint: %v", logPrefix, err)
+		return
+	}
+	if next != box.cursor {
+		if err := box.saveCursor(next); err != nil {
+			log.Printf("❌ %s Could not save sync position: %v", logPrefix, err)
+			return
+		}
+		log.Printf("🔖 %s Sync position updated to %s", logPrefix, next)
 	}
 
-	// Extract all added messages from the history events
-	var msgHeaders []*gmail.Message
-	for _, h := range resp.History {
-		for _, mAdded := range h.MessagesAdded {
-			if mAdded.Message != nil {
-				msgHeaders = append(msgHeaders, mAdded.Message)
+	// 5. Fetch in batches into the queue, checkpointing after each one
+	var unfetched []string
+	for start := 0; start < len(work); start += syncBatchSize {
+		if ctx.Err() != nil {
+			break
+		}
+		end := min(start+syncBatchSize, len(work))
+		batch := work[start:end]
+		msgs, errs := s.fetchBatch(ctx, source, batch)
+
+		// Messages that failed to load stay in the checkpoint
+		var fetched []*mailsource.Message
+		for i, id := range batch {
+			switch {
+			case errs[i] != nil:
+				log.Printf("❌ %s Could not fetch message %s: %v", logPrefix, id, errs[i])
+				unfetched = append(unfetched, id)
+			case msgs[i] == nil:
+				log.Printf("⚠️ %s Message %s is gone or unreadable, skipping it.", logPrefix, id)
+			default:
+				fetched = append(fetched, msgs[i])
 			}
 		}
+		if err := s.Queue.Enqueue(user.ID, box.key, fetched); err != nil {
+			log.Printf("❌ %s Could not queue emails: %v", logPrefix, err)
+			return
+		}
+		left := append(slices.Clone(unfetched), work[end:]...)
+		if err := s.saveCheckpoint(user.ID, box.key, left); err != nil {
+			log.Printf("❌ %s Could not save checkpoint: %v", logPrefix, err)
+			return
+		}
+		if ctx.Err() != nil || end == len(work) {
+			if len(left) > 0 {
+				log.Printf("⏸️ %s %d emails left to fetch in the next cycle.", logPrefix, len(left))
+			}
+			break
+		}
+	}
+}
+
+// gmailFor builds a Gmail client authorized as the user.
+// Tokens refreshed during the sync are saved back to the user's token store.
+func (s *EmailService) gmailFor(ctx context.Context, user *models.User) (*gmail.Service, error) {
+	httpClient, err := auth.Client(ctx, s.OAuthConfig, s.UserService.Tokens, user.ID)
+	if err != nil {
+		return nil, err
 	}
+	return gmail.NewService(ctx, option.WithHTTPClient(httpClient))
+}
 
-	fullMessages := s.expandMessages(ctx, msgHeaders)
+// Actions an EmailOutcome reports
+const (
+	OutcomeStatusChanged = "status_changed"
+	OutcomeQueued        = "queued_for_review"
+	OutcomeNoChange      = "no_change"
+	OutcomeSkipped       = "skipped"
+	OutcomeDuplicate     = "duplicate"
+	OutcomeOutdated      = "outdated" // Reprocessing only: the job's status changed after the email
+	OutcomeFailed        = "failed"
+)
 
-	return fullMessages, resp.HistoryId, nil
+// skipUnrelated is the reason for mail that matched no company and doesn't look like it's about an application
+const skipUnrelated = "unrelated"
+
+// EmailOutcome is what processing an email did, or in a dry run would have done
+type EmailOutcome struct {
+	MessageID  string        `json:"message_id"`
+	Subject    string        `json:"subject"`
+	From       string        `json:"from"`
+	Action     string        `json:"action"`
+	Reason     string        `json:"reason,omitempty"` // Review reason, or why it was skipped or failed
+	Company    string        `json:"company,omitempty"`
+	JobID      *uint         `json:"job_id,omitempty"`
+	JobTitle   string        `json:"job_title,omitempty"`
+	FromStatus status.Status `json:"from_status,omitempty"`
+	ToStatus   string        `json:"to_status,omitempty"`
+	Summary    string        `json:"summary,omitempty"`
+	Confidence float64       `json:"confidence,omitempty"`
 }
 
-// expandMessages takes a list of IDs and fetches the full body/headers
-func (s *EmailService) expandMessages(ctx context.Context, headers []*gmail.Message) []*gmail.Message {
-	var fullMessages []*gmail.Message
-	for _, h := range headers {
-		// Retry individual message fetches
-		retry(2, 500*time.Millisecond, func() error {
-			msg, err := s.GmailClient.Users.Messages.Get("me", h.Id).Context(ctx).Do()
-			if err == nil {
-				fullMessages = append(fullMessages, msg)
-			}
-			return err
-		})
-	}
-	return fullMessages
+// dryRun makes processSingleEmail decide without writing anything.
+// It remembers the statuses it would have set so later emails see them.
+type dryRun struct {
+	statuses map[uint]status.Status
+}
+
+func newDryRun() *dryRun {
+	return &dryRun{statuses: map[uint]status.Status{}}
 }
 
 // processSingleEmail contains the Business Logic (Matching -> LLM -> DB)
-func (s *EmailService) processSingleEmail(ctx context.Context, msg *gmail.Message) {
-	headers := parseHeaders(msg)
-	subject := headers["Subject"]
-	sender := headers["From"]
+// Anything we are not sure about goes to the review queue instead of being applied or dropped.
+// With a dryRun the LLM is still asked, but no job, event or review is written.
+// A reprocess run passes what it carries from one email to the next, see reprocessRun.
+func (s *EmailService) processSingleEmail(ctx context.Context, user *models.User, msg *mailsource.Message, dry *dryRun, run *reprocessRun) *EmailOutcome {
+	subject := msg.Header("Subject")
+	sender := msg.Header("From")
+	outcome := &EmailOutcome{MessageID: msg.ID, Subject: subject, From: sender}
 
 	// Create a short log prefix so we can track this specific email in the logs
 	// e.g. "[Email: Update on application...]"
-	shortSub := subject
-	if len(shortSub) > 20 {
-		shortSub = shortSub[:20] + "..."
+	shortSub, cut := mailbody.Truncate(subject, 20)
+	if cut {
+		shortSub += "..."
 	}
 	logPrefix := fmt.Sprintf("[Email: %s]", shortSub)
+	if dry != nil {
+		logPrefix += " (dry run)"
+	}
 
 	log.Printf("%s 📥 START processing from: %s", logPrefix, sender)
 
-	// Extract body
-	body := getEmailBody(msg)
-
-	// --- STEP 1: MATCHING ---
-	company := s.MatcherService.FindCompanyFromEmail(subject, sender)
-	if company == nil {
-		log.Printf("%s ❌ SKIPPED: Company match failed. Sender/Subject not in DB.", logPrefix)
-		return
+	// The sources hand over normalized text (mailbody.Part.Text), which is also what's stored
+	// with the email. The LLM only gets what's new in it, not the quoted conversation.
+	reply := mailbody.Reply(msg.Body)
+
+	// Everything we learn along the way, in case a human has to decide
+	review := &models.PendingReview{
+		UserID:    user.ID,
+		MessageID: msg.ID,
+		Subject:   subject,
+		Sender:    sender,
+		Snippet:   snippet(reply, 1000),
+	}
+	queue := func(reason string) *EmailOutcome {
+		outcome.Action, outcome.Reason = OutcomeQueued, reason
+		if dry == nil {
+			s.queueReview(logPrefix, review, reason)
+		}
+		return outcome
+	}
+	skip := func(reason string) *EmailOutcome {
+		outcome.Action, outcome.Reason = OutcomeSkipped, reason
+		return outcome
 	}
-	log.Printf("%s ✅ MATCHED Company: %s", logPrefix, company.Name)
 
-	// --- STEP 2: FIND TARGET JOB ---
-	var jobs []models.Job
-	// Ignore terminal states
-	s.DB.Where("company_id = ? AND status NOT IN ('REJECTED', 'OFFER')", company.ID).Find(&jobs)
+	var links threadLinks
+	if run != nil {
+		links = run.links
+	}
 
-	if len(jobs) == 0 {
-		log.Printf("%s ❌ SKIPPED: No active 'APPLIED' jobs found for %s in DB.", logPrefix, company.Name)
-		return
+	// --- STEP 0: KNOWN CONVERSATION ---
+	// A reply in a thread we already tied to a job goes straight to that job
+	targetJob, err := s.threadJob(user.ID, msg, links)
+	if err != nil {
+		log.Printf("%s ⚠️ Could not look up the thread: %v", logPrefix, err)
+	}
+	if targetJob != nil && dry != nil {
+		if active := dry.apply([]models.Job{*targetJob}); len(active) == 1 {
+			targetJob = &active[0]
+		} else {
+			targetJob = nil
+		}
 	}
 
-	var targetJob *models.Job
-	if len(jobs) == 1 {
-		targetJob = &jobs[0]
-		log.Printf("%s 🎯 Auto-linked to single active job: %s", logPrefix, targetJob.Title)
+	var company *models.Company
+	if targetJob != nil {
+		company = &targetJob.Company
+		log.Printf("%s 🧵 Thread already linked to job: %s at %s", logPrefix, targetJob.Title, company.Name)
+		review.CompanyID = &company.ID
+		review.CandidateJobIDs = jobIDs([]models.Job{*targetJob})
+		outcome.Company = company.Name
 	} else {
-		// Disambiguate with AI
-		var jobTitles []string
-		for _, j := range jobs {
-			jobTitles = append(jobTitles, j.Title)
+		// --- STEP 1: MATCHING ---
+		company = s.MatcherService.FindCompanyFromEmail(user.ID, subject, sender)
+		if company == nil {
+			// Most of these are simply not about an application. A human only sees the ones
+			// whose subject looks like one (the user's sync keywords).
+			if !syncFilter(user).Matches(msg) {
+				log.Printf("%s ⏭️ SKIPPED: No company match and nothing about an application.", logPrefix)
+				return skip(skipUnrelated)
+			}
+			log.Printf("%s ❓ Company match failed. Sender/Subject not in DB.", logPrefix)
+			return queue(ReviewNoCompanyMatch)
+		}
+		log.Printf("%s ✅ MATCHED Company: %s", logPrefix, company.Name)
+		review.CompanyID = &company.ID
+		outcome.Company = company.Name
+
+		// --- STEP 2: FIND TARGET JOB ---
+		jobs, err := s.JobService.ActiveJobs(user.ID, company.ID)
+		if err != nil {
+			log.Printf("%s ❌ FAILED: Could not load the jobs at %s: %v", logPrefix, company.Name, err)
+			outcome.Action, outcome.Reason = OutcomeFailed, err.Error()
+			return outcome
+		}
+		if dry != nil {
+			jobs = dry.apply(jobs)
 		}
 
-		log.Printf("%s ⚠️ Ambiguous: Found %d jobs (%v). Asking LLM to pick...", logPrefix, len(jobs), jobTitles)
-		bestMatchIndex := s.LLMService.IdentifyJobRole(jobTitles, subject, body)
+		if len(jobs) == 0 {
+			log.Printf("%s ❌ SKIPPED: No active jobs found for %s in DB.", logPrefix, company.Name)
+			return skip("no active job at " + company.Name)
+		}
+		review.CandidateJobIDs = jobIDs(jobs)
 
-		if bestMatchIndex != -1 {
-			targetJob = &jobs[bestMatchIndex]
-			log.Printf("%s 🎯 LLM selected job: %s", logPrefix, targetJob.Title)
+		if len(jobs) == 1 {
+			targetJob = &jobs[0]
+			log.Printf("%s 🎯 Auto-linked to single active job: %s", logPrefix, targetJob.Title)
 		} else {
-			log.Printf("%s ❌ SKIPPED: LLM could not determine which job this email is about.", logPrefix)
-			return
+			// Disambiguate with AI
+			var jobTitles []string
+			for _, j := range jobs {
+				jobTitles = append(jobTitles, j.Title)
+			}
+
+			log.Printf("%s ⚠️ Ambiguous: Found %d jobs (%v). Asking LLM to pick...", logPrefix, len(jobs), jobTitles)
+			bestMatchIndex, err := s.LLMService.IdentifyJobRole(ctx, jobTitles, subject, reply)
+
+			if err == nil {
+				targetJob = &jobs[bestMatchIndex]
+				log.Printf("%s 🎯 LLM selected job: %s", logPrefix, targetJob.Title)
+			} else {
+				// We still analyze the email so the reviewer gets a proposed status
+				log.Printf("%s ❓ LLM could not determine which job this email is about: %v", logPrefix, err)
+			}
+		}
+	}
+	if targetJob != nil && links != nil {
+		links[msg.Thread()] = targetJob.ID
+	}
+	if targetJob != nil && dry == nil {
+		if err := linkThread(s.DB, user.ID, msg.Thread(), targetJob.ID); err != nil {
+			log.Printf("%s ⚠️ Could not link the thread to the job: %v", logPrefix, err)
 		}
 	}
 
 	// --- STEP 3: ANALYZE STATUS ---
+	currentStatus := "UNKNOWN"
+	if targetJob != nil {
+		currentStatus = string(targetJob.Status)
+		review.ProposedJobID = &targetJob.ID
+		outcome.JobID, outcome.JobTitle, outcome.FromStatus = &targetJob.ID, targetJob.Title, targetJob.Status
+	}
+
+	// The analyzer reads the email in the light of what the conversation said so far
+	thread := s.threadContext(user.ID, msg)
 	log.Printf("%s 🤖 Analyzing content with LLM...", logPrefix)
-	analysisJSON, err := s.LLMService.AnalyzeEmailStatus(company.Name, subject, body)
+	result, err := s.LLMService.AnalyzeEmailStatus(ctx, company.Name, currentStatus, subject, reply, thread)
+	var outputErr *llm.OutputError
+	if errors.As(err, &outputErr) {
+		// The model answered, just never in a usable shape: a human can still read the email
+		log.Printf("%s ❓ Unusable LLM output: %v", logPrefix, err)
+		review.RawLLMOutput = outputErr.Raw
+		return queue(ReviewParseError)
+	}
 	if err != nil {
 		log.Printf("%s ❌ SKIPPED: LLM Analysis Error: %v", logPrefix, err)
-		return
+		outcome.Action, outcome.Reason = OutcomeFailed, err.Error()
+		return outcome
 	}
-
-	var result struct {
-		Status  string `json:"status"`
-		Summary string `json:"summary"`
-	}
-	if err := json.Unmarshal([]byte(analysisJSON), &result); err != nil {
-		log.Printf("%s ❌ SKIPPED: JSON Parse Error: %v. Raw: %s", logPrefix, err, analysisJSON)
-		return
+	review.ProposedStatus = result.Status
+	review.Summary = result.Summary
+	review.Confidence = result.Confidence
+	outcome.ToStatus, outcome.Summary, outcome.Confidence = result.Status, result.Summary, result.Confidence
+
+	log.Printf("%s 🧠 LLM Decision: Status=%s | Confidence=%.2f | Summary=%s", logPrefix, result.Status, result.Confidence, result.Summary)
+
+	// The email shows up on the job's timeline even when it doesn't change anything, once.
+	// A retry after a failed status change finds the event keyed on the message ID.
+	if targetJob != nil && dry == nil && !s.emailOnJob(user.ID, targetJob.ID, msg.ID) {
+		_, err = s.JobService.RecordEvent(targetJob, models.EventEmailReceived, SourceEmail, models.EmailReceivedPayload{
+			MessageID:      msg.ID,
+			Subject:        subject,
+			From:           sender,
+			Summary:        result.Summary,
+			ProposedStatus: result.Status,
+		})
+		if err != nil {
+			log.Printf("%s ⚠️ Could not log email event: %v", logPrefix, err)
+		}
 	}
 
-	log.Printf("%s 🧠 LLM Decision: Status=%s | Summary=%s", logPrefix, result.Status, result.Summary)
-
 	// --- STEP 4: UPDATE DB ---
 	if result.Status == "NO_CHANGE" || result.Status == "UNKNOWN" {
 		log.Printf("%s ⏹️  No DB Update needed (Status is %s).", logPrefix, result.Status)
-		return
+		outcome.Action = OutcomeNoChange
+		return outcome
+	}
+
+	newStatus, err := status.Parse(result.Status)
+	if err != nil {
+		log.Printf("%s ❓ LLM returned an unknown status: %v", logPrefix, err)
+		return queue(ReviewParseError)
 	}
 
-	if result.Status == targetJob.Status {
+	if targetJob == nil {
+		return queue(ReviewAmbiguousRole)
+	}
+
+	if newStatus == targetJob.Status {
 		log.Printf("%s ⏹️  Status is already %s. Ignoring.", logPrefix, result.Status)
-		return
+		outcome.Action = OutcomeNoChange
+		return outcome
 	}
 
-	// EXECUTE UPDATE
-	log.Printf("%s ⚡ UPDATING DB: %s -> %s", logPrefix, targetJob.Status, result.Status)
-	s.DB.Model(targetJob).Updates(map[string]interface{}{
-		"status": result.Status,
-	})
+	// An email replayed by a reprocess run can't overrule what happened to the job after it
+	if run != nil {
+		outdated, err := s.outdated(run, targetJob, msg)
+		if err != nil {
+			log.Printf("%s ❌ FAILED: Could not load the job's history: %v", logPrefix, err)
+			outcome.Action, outcome.Reason = OutcomeFailed, err.Error()
+			return outcome
+		}
+		if outdated {
+			log.Printf("%s ⏹️  Older than the job's %s status, not applying %s.", logPrefix, targetJob.Status, newStatus)
+			outcome.Action, outcome.Reason = OutcomeOutdated, "older than the job's last status change"
+			return outcome
+		}
+	}
 
-	event := models.JobEvent{
-		JobID:     targetJob.ID,
-		EventType: "EMAIL_UPDATE",
-		Details:   fmt.Sprintf("Status changed to %s. Summary: %s", result.Status, result.Summary),
+	// Risky or uncertain verdicts are only proposals
+	if reason, ok := s.ReviewService.NeedsReview(newStatus, result.Confidence); ok {
+		return queue(reason)
 	}
-	s.DB.Create(&event)
-	log.Printf("%s ✅ Success! Event logged.", logPrefix)
-}
 
-// --- HELPERS ---
+	// A dry run stops here, after checking the state machine would allow it
+	if dry != nil {
+		if err := status.Transition(targetJob.Status, newStatus); err != nil {
+			log.Printf("%s ⛔ BLOCKED: %v.", logPrefix, err)
+			return queue(ReviewIllegalTransition)
+		}
+		log.Printf("%s ⚡ WOULD UPDATE: %s -> %s", logPrefix, targetJob.Status, newStatus)
+		dry.statuses[targetJob.ID] = newStatus
+		outcome.Action = OutcomeStatusChanged
+		return outcome
+	}
 
-// retry executes a function with exponential backoff
-func retry(attempts int, sleep time.Duration, f func() error) error {
-	for i := 0; i < attempts; i++ {
-		err := f()
-		if err == nil {
-			return nil
+	// EXECUTE UPDATE (through the state machine, same as the HTTP API)
+	log.Printf("%s ⚡ UPDATING DB: %s -> %s", logPrefix, targetJob.Status, newStatus)
+	_, err = s.JobService.ChangeStatus(user.ID, targetJob.ID, StatusChange{
+		To:     newStatus,
+		Source: SourceEmail,
+		Note:   result.Summary,
+	})
+	if errors.Is(err, status.ErrIllegalTransition) {
+		log.Printf("%s ⛔ BLOCKED: %v.", logPrefix, err)
+		return queue(ReviewIllegalTransition)
+	}
+	if err != nil {
+		log.Printf("%s ❌ FAILED: Status update error: %v", logPrefix, err)
+		outcome.Action, outcome.Reason = OutcomeFailed, err.Error()
+		return outcome
+	}
+
+	log.Printf("%s ✅ Success! Status changed and event logged.", logPrefix)
+	outcome.Action = OutcomeStatusChanged
+	return outcome
+}
+
+// apply swaps in the statuses the dry run would have set, dropping jobs that became terminal
+func (d *dryRun) apply(jobs []models.Job) []models.Job {
+	active := jobs[:0]
+	for _, j := range jobs {
+		if st, ok := d.statuses[j.ID]; ok {
+			j.Status = st
 		}
-		// If 404 (History Expired), fail fast so we can switch to Full Sync
-		if isHistoryExpiredError(err) {
-			return err
+		if !j.Status.IsTerminal() {
+			active = append(active, j)
 		}
-
-		log.Printf("⚠️ API Error: %v. Retrying in %v...", err, sleep)
-		time.Sleep(sleep)
-		sleep *= 2
 	}
-	return fmt.Errorf("failed after %d attempts", attempts)
+	return active
 }
 
-func isHistoryExpiredError(err error) bool {
-	if gErr, ok := err.(*googleapi.Error); ok {
-		return gErr.Code == 404
+// queueReview parks the email in the review queue for a human to approve or dismiss
+func (s *EmailService) queueReview(logPrefix string, review *models.PendingReview, reason string) {
+	review.Reason = reason
+	if err := s.ReviewService.Queue(review); err != nil {
+		log.Printf("%s ❌ FAILED: Could not queue review: %v", logPrefix, err)
+		return
 	}
-	return false
+	log.Printf("%s 📝 QUEUED for review (%s).", logPrefix, reason)
 }
 
-func (s *EmailService) updateUserHistoryID(userID uint, newID uint64) {
-	s.DB.Model(&models.User{}).Where("id = ?", userID).Update("last_history_id", newID)
+// --- HELPERS ---
+
+// alreadyProcessed checks the dedup table for any of the message's keys
+func (s *EmailService) alreadyProcessed(userID uint, msg *mailsource.Message) bool {
+	var count int64
+	s.DB.Model(&models.ProcessedEmail{}).Where("user_id = ? AND message_id IN ?", userID, msg.DedupKeys()).Count(&count)
+	return count > 0
 }
 
-func parseHeaders(msg *gmail.Message) map[string]string {
-	res := make(map[string]string)
-	for _, h := range msg.Payload.Headers {
-		res[h.Name] = h.Value
+// markProcessed records every key of the message, so the same email coming
+// from another source (a Takeout import of a synced Gmail) is skipped
+func (s *EmailService) markProcessed(userID uint, msg *mailsource.Message) {
+	for _, key := range msg.DedupKeys() {
+		s.DB.Where(models.ProcessedEmail{UserID: userID, MessageID: key}).FirstOrCreate(&models.ProcessedEmail{})
 	}
-	return res
 }
 
-func getEmailBody(msg *gmail.Message) string {
-	if msg.Payload.Body != nil && msg.Payload.Body.Data != "" {
-		d, _ := base64.URLEncoding.DecodeString(msg.Payload.Body.Data)
-		return string(d)
-	}
-	for _, part := range msg.Payload.Parts {
-		if part.MimeType == "text/plain" && part.Body.Data != "" {
-			d, _ := base64.URLEncoding.DecodeString(part.Body.Data)
-			return string(d)
+// saveEmail stores the email with what processing made of it, replacing an earlier verdict.
+// Mail that matched no company is none of our business: it's only kept when it went to
+// review, and never with its body.
+func (s *EmailService) saveEmail(userID uint, msg *mailsource.Message, outcome *EmailOutcome) {
+	body := msg.Body
+	if outcome.Company == "" {
+		if outcome.Action != OutcomeQueued {
+			return
 		}
+		body = ""
 	}
-	for _, part := range msg.Payload.Parts {
-		if part.MimeType == "text/html" && part.Body.Data != "" {
-			d, _ := base64.URLEncoding.DecodeString(part.Body.Data)
-			return string(d)
-		}
+	headers, err := models.NewJSON(msg.Headers)
+	if err != nil {
+		log.Printf("⚠️ Could not store email %s: %v", msg.ID, err)
+		return
+	}
+	email := &models.Email{
+		UserID:    userID,
+		JobID:     outcome.JobID,
+		MessageID: msg.ID,
+		ThreadID:  msg.Thread(),
+		Subject:   msg.Header("Subject"),
+		Sender:    msg.Header("From"),
+		Headers:   headers,
+		Body:      body,
+		Classification: models.EmailClassification{
+			Action:     outcome.Action,
+			Reason:     outcome.Reason,
+			Status:     outcome.ToStatus,
+			Summary:    outcome.Summary,
+			Confidence: outcome.Confidence,
+			Model:      s.LLMService.ModelVersion(),
+			At:         time.Now(),
+		},
+	}
+	if !msg.Date.IsZero() {
+		email.ReceivedAt = &msg.Date
+	}
+	err = s.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "message_id"}},
+		UpdateAll: true,
+	}).Create(email).Error
+	if err != nil {
+		log.Printf("⚠️ Could not store email %s: %v", msg.ID, err)
+	}
+}
+
+// emailOnJob reports whether an earlier run stored the email as being about the job,
+// so reprocessing doesn't put it on the job's timeline twice
+func (s *EmailService) emailOnJob(userID, jobID uint, messageID string) bool {
+	var count int64
+	s.DB.Model(&models.Email{}).Where("user_id = ? AND job_id = ? AND message_id = ?", userID, jobID, messageID).Count(&count)
+	return count > 0
+}
+
+func (s *EmailService) updateUserHistoryID(userID uint, newID uint64) error {
+	return s.DB.Model(&models.User{}).Where("id = ?", userID).Update("last_history_id", newID).Error
+}
+
+// snippet keeps the first n characters of the body without cutting a character in half
+func snippet(body string, n int) string {
+	if s, cut := mailbody.Truncate(body, n); cut {
+		return s + "..."
+	}
+	return body
+}
+
+// jobIDs collects the IDs as a JSON array for PendingReview.CandidateJobIDs
+func jobIDs(jobs []models.Job) models.JSON {
+	ids := make([]uint, 0, len(jobs))
+	for _, j := range jobs {
+		ids = append(ids, j.ID)
 	}
-	return ""
+	data, _ := models.NewJSON(ids)
+	return data
 }
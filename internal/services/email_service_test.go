@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/llm"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailsource"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+// emailService wires the email pipeline with the rules analyzer, no Gmail
+func emailService(db *gorm.DB) *EmailService {
+	jobs := NewJobService(db)
+	return NewEmailService(db, NewLLMService(llm.NewRules()), nil, NewUserService(db, nil), NewMatcherService(db), jobs, NewReviewService(db, jobs))
+}
+
+func loadUser(t *testing.T, db *gorm.DB, id uint) *models.User {
+	t.Helper()
+	var user models.User
+	if err := db.First(&user, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &user
+}
+
+func TestUnmatchedEmail(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		svc := emailService(db)
+		me := loadUser(t, db, createUser(t, db, "me@example.com"))
+		ctx := context.Background()
+		count := func(model interface{}, messageID string) int64 {
+			var n int64
+			db.Model(model).Where("user_id = ? AND message_id = ?", me.ID, messageID).Count(&n)
+			return n
+		}
+
+		// 1. Mail from nobody we know about nothing we look for leaves no trace but the processed mark
+		lunch := &mailsource.Message{
+			ID:      "lunch",
+			Headers: map[string]string{"Subject": "Lunch on Friday?", "From": "friend@example.org"},
+			Body:    "Are you free? My treat.",
+		}
+		if err := svc.processOnce(ctx, me, lunch); err != nil {
+			t.Fatal(err)
+		}
+		if count(&models.PendingReview{}, "lunch") != 0 || count(&models.Email{}, "lunch") != 0 {
+			t.Error("an unrelated email was queued or stored")
+		}
+		if !svc.alreadyProcessed(me.ID, lunch) {
+			t.Error("the unrelated email wasn't marked processed")
+		}
+
+		// 2. One about an application goes to a human, stored without its body
+		application := &mailsource.Message{
+			ID:      "application",
+			Headers: map[string]string{"Subject": "Your application at Initech", "From": "jobs@initech.example"},
+			Body:    "We received your application.",
+		}
+		if err := svc.processOnce(ctx, me, application); err != nil {
+			t.Fatal(err)
+		}
+		var review models.PendingReview
+		if err := db.Where("user_id = ? AND message_id = ?", me.ID, "application").First(&review).Error; err != nil {
+			t.Fatal(err)
+		}
+		if review.Reason != ReviewNoCompanyMatch || review.Snippet == "" {
+			t.Errorf("got %+v", review)
+		}
+		var email models.Email
+		if err := db.Where("user_id = ? AND message_id = ?", me.ID, "application").First(&email).Error; err != nil {
+			t.Fatal(err)
+		}
+		if email.Body != "" || email.Subject != "Your application at Initech" {
+			t.Errorf("stored %q with body %q", email.Subject, email.Body)
+		}
+	})
+}
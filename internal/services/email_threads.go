@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailbody"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailsource"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// How much of a conversation the analyzer gets besides the email itself
+const (
+	threadContextEmails = 10   // The latest ones
+	threadContextChars  = 2000 // In total, the latest emails win
+	threadEmailChars    = 800  // Per email
+)
+
+// threadKeys are the names a message's conversation may be stored under: its thread, and
+// for mail without a mailbox thread ID every message it replies to (a client may have cut
+// the References header short) and the threads we stored those in
+func (s *EmailService) threadKeys(userID uint, msg *mailsource.Message) []string {
+	keys := []string{msg.Thread()}
+	if msg.ThreadID != "" {
+		return keys
+	}
+	refs := append(strings.Fields(msg.Header("References")), strings.Fields(msg.Header("In-Reply-To"))...)
+	if len(refs) == 0 {
+		return keys
+	}
+	var threads []string
+	s.DB.Model(&models.Email{}).Where("user_id = ? AND message_id IN ?", userID, refs).Distinct().Pluck("thread_id", &threads)
+	keys = append(keys, refs...)
+	keys = append(keys, threads...)
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
+
+// inThread reports whether the message continues a conversation rather than starting one
+func inThread(msg *mailsource.Message) bool {
+	if msg.ThreadID != "" {
+		return msg.ThreadID != msg.ID // Gmail names a thread after its first message
+	}
+	return msg.Header("References") != "" || msg.Header("In-Reply-To") != ""
+}
+
+// threadLinks are the threads a reprocess run has resolved so far, by thread ID. The links
+// stored before the run came from the verdicts being redone, so it doesn't follow those.
+type threadLinks map[string]uint
+
+// threadJob is the job an earlier email of the conversation was resolved to. Nil when the
+// thread is new, or its job was deleted or has ended (nothing an email says moves it).
+// With links only those count, not the stored ones.
+func (s *EmailService) threadJob(userID uint, msg *mailsource.Message, links threadLinks) (*models.Job, error) {
+	keys := s.threadKeys(userID, msg)
+	var jobID uint
+	if links != nil {
+		for _, key := range keys {
+			if id, ok := links[key]; ok {
+				jobID = id
+				break
+			}
+		}
+	} else {
+		var link models.EmailThread
+		err := s.DB.Where("user_id = ? AND thread_id IN ?", userID, keys).
+			Order("updated_at DESC").
+			First(&link).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		jobID = link.JobID
+	}
+	if jobID == 0 {
+		return nil, nil
+	}
+
+	var job models.Job
+	err := s.DB.Preload("Company").Where("user_id = ?", userID).First(&job, jobID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The job is gone, the conversation is matched from scratch and linked anew
+		return nil, nil
+	}
+	if err != nil || job.Status.IsTerminal() {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// linkThread remembers that the conversation is about the job, a later link replaces it
+func linkThread(db *gorm.DB, userID uint, threadID string, jobID uint) error {
+	if threadID == "" {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "thread_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"job_id", "updated_at"}),
+	}).Create(&models.EmailThread{UserID: userID, ThreadID: threadID, JobID: jobID}).Error
+}
+
+// threadContext is what the conversation said before the message, oldest first: the new
+// part of the latest stored emails of the thread, as much as fits into threadContextChars
+func (s *EmailService) threadContext(userID uint, msg *mailsource.Message) string {
+	tx := s.DB.Where("user_id = ? AND thread_id IN ? AND message_id <> ?", userID, s.threadKeys(userID, msg), msg.ID)
+	if !msg.Date.IsZero() {
+		tx = tx.Where("received_at < ?", msg.Date)
+	}
+	var earlier []models.Email
+	if err := tx.Order("received_at DESC, id DESC").Limit(threadContextEmails).Find(&earlier).Error; err != nil {
+		return ""
+	}
+
+	var entries []string
+	left := threadContextChars
+	for _, email := range earlier {
+		text, cut := mailbody.Truncate(mailbody.Reply(email.Body), threadEmailChars)
+		if cut {
+			text += "..."
+		}
+		entry := fmt.Sprintf("From: %s\nSubject: %s\n%s", email.Sender, email.Subject, text)
+		if email.ReceivedAt != nil {
+			entry = "Date: " + email.ReceivedAt.Format("2006-01-02") + "\n" + entry
+		}
+		if left -= len([]rune(entry)); left < 0 {
+			break
+		}
+		entries = append(entries, entry)
+	}
+	slices.Reverse(entries)
+	return strings.Join(entries, "\n\n")
+}
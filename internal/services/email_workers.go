@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailsource"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"golang.org/x/time/rate"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// syncBatchSize is how many messages are fetched before they're queued and checkpointed,
+// and how many queued ones are processed at a time
+const syncBatchSize = 50
+
+// fetchBatch loads the messages in the order of ids. A nil message without an error was deleted.
+// Sources that fetch in bulk (IMAP) get one request, the others Workers requests at a time.
+func (s *EmailService) fetchBatch(ctx context.Context, source mailsource.Source, ids []string) ([]*mailsource.Message, []error) {
+	msgs := make([]*mailsource.Message, len(ids))
+	errs := make([]error, len(ids))
+	if len(ids) == 0 {
+		return msgs, errs
+	}
+
+	if bulk, ok := source.(mailsource.BatchFetcher); ok {
+		found, err := bulk.FetchBatch(ctx, ids)
+		for i, id := range ids {
+			msgs[i], errs[i] = found[id], err
+		}
+		return msgs, errs
+	}
+
+	sem := make(chan struct{}, s.workers())
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, id string) {
+			defer func() { <-sem; wg.Done() }()
+			msgs[i], errs[i] = source.Fetch(ctx, id)
+		}(i, id)
+	}
+	wg.Wait()
+	return msgs, errs
+}
+
+// processQueue works off the user's due emails a batch at a time, until none are left or time runs out
+func (s *EmailService) processQueue(ctx context.Context, user *models.User) {
+	// 1. Emails a dead process left PROCESSING are ours, the sync lock says nobody else works on them
+	if err := s.Queue.Recover(user.ID); err != nil {
+		log.Printf("❌ [%s] Could not recover the email queue: %v", user.Email, err)
+		return
+	}
+
+	for ctx.Err() == nil {
+		// 2. Claim the next batch
+		items, err := s.Queue.Claim(user.ID, syncBatchSize)
+		if err != nil {
+			log.Printf("❌ [%s] Could not read the email queue: %v", user.Email, err)
+			return
+		}
+		if len(items) == 0 {
+			return
+		}
+		msgs := make([]*mailsource.Message, len(items))
+		for i := range items {
+			var msg mailsource.Message
+			if err := items[i].Message.Decode(&msg); err != nil {
+				s.failQueued(user, &items[i], err)
+				continue
+			}
+			msgs[i] = &msg
+		}
+
+		// 3. Process it and record how each email went
+		errs := s.processBatch(ctx, user, msgs)
+		for i := range items {
+			item := &items[i]
+			var err error
+			switch {
+			case msgs[i] == nil:
+				continue
+			case errs[i] == nil:
+				err = s.Queue.Finish(item)
+			case ctx.Err() != nil:
+				err = s.Queue.Release(item) // Out of time, not the email's fault
+			default:
+				s.failQueued(user, item, errs[i])
+				continue
+			}
+			if err != nil {
+				log.Printf("❌ [%s] Could not update queued email %d: %v", user.Email, item.ID, err)
+			}
+		}
+	}
+}
+
+// failQueued records a failed attempt at a queued email
+func (s *EmailService) failQueued(user *models.User, item *models.QueuedEmail, cause error) {
+	if err := s.Queue.Fail(item, cause); err != nil {
+		log.Printf("❌ [%s] Could not update queued email %d: %v", user.Email, item.ID, err)
+		return
+	}
+	if item.State == models.QueueStateFailed {
+		log.Printf("☠️ [%s] Giving up on %q after %d attempts: %v", user.Email, item.Subject, item.Attempts, cause)
+	} else {
+		log.Printf("🔁 [%s] %q failed (attempt %d), retrying after %s", user.Email, item.Subject, item.Attempts, item.NextAttemptAt.Format(time.RFC3339))
+	}
+}
+
+// processBatch runs the messages through the pipeline, Workers at a time. Mail from the same
+// sender stays in order, so a rejection can't overtake the interview invite that came before it.
+// A message's error is nil once it's finished. Mail the context cut off gets the context's error.
+func (s *EmailService) processBatch(ctx context.Context, user *models.User, msgs []*mailsource.Message) []error {
+	errs := make([]error, len(msgs))
+
+	// 1. One queue per sender, in arrival order
+	var order []string
+	queues := map[string][]int{}
+	for i, msg := range msgs {
+		if msg == nil {
+			continue
+		}
+		key := senderKey(msg.Header("From"))
+		if _, ok := queues[key]; !ok {
+			order = append(order, key)
+		}
+		queues[key] = append(queues[key], i)
+	}
+
+	// 2. Queues run in parallel, each one sequentially
+	sem := make(chan struct{}, s.workers())
+	var wg sync.WaitGroup
+	for _, key := range order {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(queue []int) {
+			defer func() { <-sem; wg.Done() }()
+			for _, i := range queue {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				errs[i] = s.processOnce(ctx, user, msgs[i])
+			}
+		}(queues[key])
+	}
+	wg.Wait()
+	return errs
+}
+
+// processOnce processes a message unless it was already, and marks it processed.
+// A failed attempt returns why and leaves the message unmarked, to be tried again.
+func (s *EmailService) processOnce(ctx context.Context, user *models.User, msg *mailsource.Message) error {
+	// A. Check Dedup Table
+	if s.alreadyProcessed(user.ID, msg) {
+		return nil
+	}
+
+	// B. Process the Email (Core Logic)
+	outcome := s.processSingleEmail(ctx, user, msg, nil, nil)
+	if outcome.Action == OutcomeFailed {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return errors.New(outcome.Reason)
+	}
+
+	// C. Keep the email and mark it processed
+	s.saveEmail(user.ID, msg, outcome)
+	s.markProcessed(user.ID, msg)
+	return nil
+}
+
+// senderKey groups mail by the sender's domain, or the raw header when it doesn't parse
+func senderKey(from string) string {
+	if addr, err := mail.ParseAddress(from); err == nil {
+		from = addr.Address
+	}
+	from = strings.ToLower(from)
+	if _, domain, ok := strings.Cut(from, "@"); ok {
+		return domain
+	}
+	return from
+}
+
+func (s *EmailService) workers() int {
+	return max(s.Workers, 1)
+}
+
+// gmailQuota is the user's Gmail quota limiter. Gmail's limit is per user,
+// so every source of the user shares it, across cycles.
+func (s *EmailService) gmailQuota(userID uint) *rate.Limiter {
+	if s.GmailQuota <= 0 {
+		return nil
+	}
+	l, _ := s.quotas.LoadOrStore(userID, rate.NewLimiter(rate.Limit(s.GmailQuota), s.GmailQuota))
+	return l.(*rate.Limiter)
+}
+
+// appendNew adds the IDs that aren't in list yet
+func appendNew(list, ids []string) []string {
+	seen := make(map[string]bool, len(list))
+	for _, id := range list {
+		seen[id] = true
+	}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			list = append(list, id)
+		}
+	}
+	return list
+}
+
+// loadCheckpoint returns the message IDs the last cycle of the mailbox left unfinished
+func (s *EmailService) loadCheckpoint(userID uint, mailbox string) ([]string, error) {
+	var cp models.SyncCheckpoint
+	err := s.DB.Where("user_id = ? AND mailbox = ?", userID, mailbox).First(&cp).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	err = cp.Pending.Decode(&ids)
+	return ids, err
+}
+
+// saveCheckpoint replaces the mailbox's unfinished IDs, none deletes the checkpoint
+func (s *EmailService) saveCheckpoint(userID uint, mailbox string, ids []string) error {
+	if len(ids) == 0 {
+		return s.DB.Where("user_id = ? AND mailbox = ?", userID, mailbox).Delete(&models.SyncCheckpoint{}).Error
+	}
+	pending, err := models.NewJSON(ids)
+	if err != nil {
+		return err
+	}
+	return s.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&models.SyncCheckpoint{
+		UserID:  userID,
+		Mailbox: mailbox,
+		Pending: pending,
+	}).Error
+}
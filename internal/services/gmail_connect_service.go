@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/auth"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+	"gorm.io/gorm"
+)
+
+// googleRevokeURL invalidates a token (and its refresh token) at Google
+const googleRevokeURL = "https://oauth2.googleapis.com/revoke"
+
+// defaultStateTTL is how long the user has to finish the consent screen
+const defaultStateTTL = 10 * time.Minute
+
+var (
+	ErrGmailDisabled = errors.New("gmail is not configured on this server (missing OAuth client secret)")
+	ErrInvalidState  = errors.New("unknown or expired OAuth state, start the connection again")
+)
+
+// GmailStatus reports the health of a user's Gmail connection
+type GmailStatus struct {
+	Connected       bool       `json:"connected"`
+	Valid           bool       `json:"valid"`
+	Mailbox         string     `json:"mailbox,omitempty"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"` // Of the current access token, refreshed automatically
+	HasRefreshToken bool       `json:"has_refresh_token"`
+	Error           string     `json:"error,omitempty"`
+}
+
+// GmailConnectService runs the browser OAuth flow (random state + PKCE) that connects a user's mailbox
+type GmailConnectService struct {
+	DB          *gorm.DB
+	OAuthConfig *oauth2.Config
+	UserService *UserService
+	StateTTL    time.Duration
+	// HTTPClient is used for token exchange and revocation (nil = http.DefaultClient)
+	HTTPClient *http.Client
+}
+
+func NewGmailConnectService(db *gorm.DB, oauthConfig *oauth2.Config, users *UserService) *GmailConnectService {
+	return &GmailConnectService{
+		DB:          db,
+		OAuthConfig: oauthConfig,
+		UserService: users,
+		StateTTL:    defaultStateTTL,
+	}
+}
+
+// Begin starts a connect flow for the user and returns the Google consent URL and its state
+func (s *GmailConnectService) Begin(userID uint) (string, string, error) {
+	if s.OAuthConfig == nil {
+		return "", "", ErrGmailDisabled
+	}
+
+	// 1. Random state, bound to the user and a fresh PKCE verifier
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	st := &models.OAuthState{
+		State:     base64.RawURLEncoding.EncodeToString(raw),
+		UserID:    userID,
+		Verifier:  oauth2.GenerateVerifier(),
+		ExpiresAt: time.Now().Add(s.StateTTL),
+	}
+
+	// 2. Drop abandoned flows while we're here
+	s.DB.Where("expires_at < ?", time.Now()).Delete(&models.OAuthState{})
+	if err := s.DB.Create(st).Error; err != nil {
+		return "", "", err
+	}
+
+	// 3. Offline access + forced consent so Google always hands out a refresh token
+	authURL := s.OAuthConfig.AuthCodeURL(st.State,
+		oauth2.AccessTypeOffline,
+		oauth2.ApprovalForce,
+		oauth2.S256ChallengeOption(st.Verifier),
+	)
+	return authURL, st.State, nil
+}
+
+// Complete exchanges the code from the callback and stores the token on the user who started the flow
+func (s *GmailConnectService) Complete(ctx context.Context, state, code string) (*models.User, error) {
+	if s.OAuthConfig == nil {
+		return nil, ErrGmailDisabled
+	}
+
+	// 1. The state is single use: delete it in the same step we read it
+	var st models.OAuthState
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state = ?", state).First(&st).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&st).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidState
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(st.ExpiresAt) {
+		return nil, ErrInvalidState
+	}
+
+	// 2. Exchange the code, proving we started the flow with the PKCE verifier
+	tok, err := s.OAuthConfig.Exchange(s.clientContext(ctx), code, oauth2.VerifierOption(st.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchanging authorization code: %w", err)
+	}
+
+	// 3. Store it. A new mailbox starts with a fresh bootstrap sync.
+	err = s.DB.Model(&models.User{}).Where("id = ?", st.UserID).Update("last_history_id", 0).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := s.UserService.SaveToken(ctx, st.UserID, tok); err != nil {
+		return nil, err
+	}
+	return s.UserService.Get(st.UserID)
+}
+
+// Disconnect forgets the user's token and revokes it at Google.
+// It returns whether Google confirmed the revocation; the local token is removed either way.
+func (s *GmailConnectService) Disconnect(ctx context.Context, user *models.User) (bool, error) {
+	tok, err := s.UserService.Token(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+
+	revoked := s.revoke(ctx, tok) == nil
+	return revoked, s.UserService.DeleteToken(ctx, user.ID)
+}
+
+// Status checks the stored token by refreshing it if needed and reading the mailbox profile
+func (s *GmailConnectService) Status(ctx context.Context, user *models.User) *GmailStatus {
+	st := &GmailStatus{}
+	tok, err := s.UserService.Token(ctx, user.ID)
+	if err != nil {
+		if !errors.Is(err, ErrGmailNotConnected) {
+			st.Error = err.Error()
+		}
+		return st
+	}
+	st.Connected = true
+	st.HasRefreshToken = tok.RefreshToken != ""
+	if s.OAuthConfig == nil {
+		st.Error = ErrGmailDisabled.Error()
+		return st
+	}
+
+	// 1. Refresh if expired: this is where a revoked or broken token shows up (a new one is saved)
+	ts, err := auth.TokenSource(s.clientContext(ctx), s.OAuthConfig, s.UserService.Tokens, user.ID)
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	fresh, err := ts.Token()
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	if !fresh.Expiry.IsZero() {
+		st.ExpiresAt = &fresh.Expiry
+	}
+
+	// 2. And make sure Gmail accepts it
+	gm, err := gmail.NewService(ctx, option.WithHTTPClient(oauth2.NewClient(s.clientContext(ctx), ts)))
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	profile, err := gm.Users.GetProfile("me").Context(ctx).Do()
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	st.Valid = true
+	st.Mailbox = profile.EmailAddress
+	return st
+}
+
+// revoke asks Google to invalidate the token. Revoking the refresh token also kills its access tokens.
+func (s *GmailConnectService) revoke(ctx context.Context, tok *oauth2.Token) error {
+	token := tok.RefreshToken
+	if token == "" {
+		token = tok.AccessToken
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, googleRevokeURL,
+		strings.NewReader(url.Values{"token": {token}}.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("revoke returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *GmailConnectService) httpClient() *http.Client {
+	if s.HTTPClient != nil {
+		return s.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// clientContext makes the oauth2 package use our HTTP client
+func (s *GmailConnectService) clientContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, s.httpClient())
+}
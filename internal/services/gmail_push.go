@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"google.golang.org/api/gmail/v1"
+	"gorm.io/gorm/clause"
+)
+
+// watchRenewBefore renews a watch once it has less than this left. Watches last 7 days,
+// Google recommends renewing them every day.
+const watchRenewBefore = 6 * 24 * time.Hour
+
+// kickQueueSize bounds the pushed syncs waiting for the watcher, more are dropped (polling catches up)
+const kickQueueSize = 64
+
+var ErrUnknownPushAddress = errors.New("no user watches this Gmail address")
+
+// PushNotification is the data of the Pub/Sub message Gmail publishes for a mailbox change
+type PushNotification struct {
+	EmailAddress string `json:"emailAddress"`
+	HistoryID    uint64 `json:"historyId"`
+}
+
+// ensureWatch (re)creates the user's users.watch when it is missing, about to expire
+// or on another topic than the configured one
+func (s *EmailService) ensureWatch(ctx context.Context, user *models.User) error {
+	var w models.GmailWatch
+	if err := s.DB.Where("user_id = ?", user.ID).Limit(1).Find(&w).Error; err != nil {
+		return err
+	}
+	if w.UserID != 0 && w.Topic == s.PushTopic && time.Until(w.Expiration) > watchRenewBefore {
+		return nil
+	}
+
+	gm, err := s.gmailFor(ctx, user)
+	if err != nil {
+		return err
+	}
+	resp, err := gm.Users.Watch("me", &gmail.WatchRequest{TopicName: s.PushTopic}).Context(ctx).Do()
+	if err != nil {
+		return err
+	}
+	// Pushes name the mailbox by its address, which needn't be the user's login email
+	profile, err := gm.Users.GetProfile("me").Context(ctx).Do()
+	if err != nil {
+		return err
+	}
+
+	w = models.GmailWatch{
+		UserID:       user.ID,
+		EmailAddress: strings.ToLower(profile.EmailAddress),
+		Topic:        s.PushTopic,
+		HistoryID:    resp.HistoryId,
+		Expiration:   time.UnixMilli(resp.Expiration),
+	}
+	if err := s.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&w).Error; err != nil {
+		return err
+	}
+	log.Printf("📡 [%s] Gmail push watch on %s renewed until %s", user.Email, w.EmailAddress, w.Expiration.Format(time.RFC3339))
+	return nil
+}
+
+// HandlePush starts an incremental sync of the mailbox a push is about, unless the
+// watcher is already past that history ID. Pushes may repeat and arrive out of order.
+func (s *EmailService) HandlePush(ctx context.Context, n PushNotification) error {
+	var w models.GmailWatch
+	err := s.DB.Where("email_address = ?", strings.ToLower(n.EmailAddress)).Limit(1).Find(&w).Error
+	if err != nil {
+		return err
+	}
+	if w.UserID == 0 {
+		return ErrUnknownPushAddress
+	}
+	if n.HistoryID > w.HistoryID {
+		err = s.DB.Model(&w).Where("history_id < ?", n.HistoryID).Update("history_id", n.HistoryID).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	user, err := s.UserService.Get(w.UserID)
+	if err != nil {
+		return err
+	}
+	if n.HistoryID <= user.LastHistoryID {
+		return nil // Synced already
+	}
+	s.kick(user.ID)
+	return nil
+}
+
+// kick asks the watcher to sync the user's Gmail now
+func (s *EmailService) kick(userID uint) {
+	if s.kicks == nil {
+		log.Printf("⚠️ Email Watcher: push for user %d ignored, the watcher isn't running.", userID)
+		return
+	}
+	select {
+	case s.kicks <- userID:
+	default:
+		log.Printf("⚠️ Email Watcher: push for user %d dropped, too many waiting. Polling will catch up.", userID)
+	}
+}
+
+// gmailPollDue tells the ticker whether to poll the user's Gmail. With push, Gmail
+// is only polled every PushFallback in case pushes go missing.
+func (s *EmailService) gmailPollDue(userID uint) bool {
+	if s.PushTopic == "" {
+		return true
+	}
+	last, ok := s.gmailPolled.Load(userID)
+	return !ok || time.Since(last.(time.Time)) >= s.PushFallback
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/auth"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailsource"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrIMAPNotConfigured = errors.New("no IMAP account is configured for this user")
+
+// IMAPAccountService manages the users' IMAP mailboxes. Passwords are kept sealed
+// with the same key as the Gmail tokens.
+type IMAPAccountService struct {
+	DB     *gorm.DB
+	Cipher *auth.Cipher
+}
+
+func NewIMAPAccountService(db *gorm.DB, c *auth.Cipher) *IMAPAccountService {
+	return &IMAPAccountService{DB: db, Cipher: c}
+}
+
+// Set checks the account can log in and saves it, replacing the user's previous one.
+// The sync starts over with a bootstrap of the recent mail.
+func (s *IMAPAccountService) Set(ctx context.Context, userID uint, account *models.IMAPAccount, password string) error {
+	// 1. Make sure the server takes the credentials before storing them
+	if account.Mailbox == "" {
+		account.Mailbox = "INBOX"
+	}
+	source := &mailsource.IMAP{Addr: account.Addr, TLS: account.TLS, Username: account.Username, Password: password, Mailbox: account.Mailbox}
+	if err := source.Check(ctx); err != nil {
+		return err
+	}
+	source.Close()
+
+	// 2. Seal the password and upsert
+	sealed, err := s.Cipher.Seal([]byte(password), imapOwner(userID))
+	if err != nil {
+		return err
+	}
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.IMAPAccount{}).Error; err != nil {
+			return err
+		}
+		account.ID = 0
+		account.UserID = userID
+		account.Password = sealed
+		account.UIDValidity, account.LastUID = 0, 0
+		return tx.Create(account).Error
+	})
+}
+
+// Get loads the user's account
+func (s *IMAPAccountService) Get(userID uint) (*models.IMAPAccount, error) {
+	var account models.IMAPAccount
+	err := s.DB.Where("user_id = ?", userID).First(&account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrIMAPNotConfigured
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Delete removes the user's account
+func (s *IMAPAccountService) Delete(userID uint) error {
+	res := s.DB.Where("user_id = ?", userID).Delete(&models.IMAPAccount{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrIMAPNotConfigured
+	}
+	return nil
+}
+
+// UserIDs lists the users with an IMAP account
+func (s *IMAPAccountService) UserIDs() ([]uint, error) {
+	ids := []uint{}
+	err := s.DB.Model(&models.IMAPAccount{}).Order("user_id").Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// Source opens the account for syncing
+func (s *IMAPAccountService) Source(account *models.IMAPAccount) (*mailsource.IMAP, error) {
+	password, err := s.Cipher.Open(account.Password, imapOwner(account.UserID))
+	if err != nil {
+		return nil, fmt.Errorf("IMAP password: %w", err)
+	}
+	return &mailsource.IMAP{
+		Addr:     account.Addr,
+		TLS:      account.TLS,
+		Username: account.Username,
+		Password: string(password),
+		Mailbox:  account.Mailbox,
+	}, nil
+}
+
+// Cursor is the account's sync position for mailsource.IMAP ("" = never synced)
+func (s *IMAPAccountService) Cursor(account *models.IMAPAccount) string {
+	if account.UIDValidity == 0 {
+		return ""
+	}
+	return mailsource.IMAPCursor(account.UIDValidity, account.LastUID)
+}
+
+// SaveCursor stores the position the next sync continues from
+func (s *IMAPAccountService) SaveCursor(accountID uint, cursor string) error {
+	validity, uid, err := mailsource.ParseIMAPCursor(cursor)
+	if err != nil {
+		return err
+	}
+	return s.DB.Model(&models.IMAPAccount{}).Where("id = ?", accountID).
+		Updates(map[string]interface{}{"uid_validity": validity, "last_uid": uid}).Error
+}
+
+func imapOwner(userID uint) string {
+	return fmt.Sprintf("imap:%d", userID)
+}
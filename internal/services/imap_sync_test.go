@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap/backend/memory"
+	"github.com/emersion/go-imap/server"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/auth"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+)
+
+// imapServer serves a go-imap memory mailbox (user "username", password "password") on a local port
+func imapServer(t *testing.T) (string, *memory.Backend) {
+	t.Helper()
+	be := memory.New()
+	srv := server.New(be)
+	srv.AllowInsecureAuth = true
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+	return l.Addr().String(), be
+}
+
+func deliver(t *testing.T, be *memory.Backend, raw string) {
+	t.Helper()
+	user, err := be.Login(nil, "username", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inbox, err := user.GetMailbox("INBOX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := inbox.CreateMessage(nil, time.Now(), strings.NewReader(raw)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIMAPSync(t *testing.T) {
+	addr, be := imapServer(t)
+	db := sqliteDB(t)
+	ctx := context.Background()
+	me := createUser(t, db, "me@example.com")
+
+	cipher, err := auth.NewCipher("0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts := NewIMAPAccountService(db, cipher)
+	svc := emailService(db)
+	svc.IMAPAccounts = accounts
+
+	processed := func(messageID string) int64 {
+		var count int64
+		db.Model(&models.ProcessedEmail{}).Where("user_id = ? AND message_id = ?", me, messageID).Count(&count)
+		return count
+	}
+	cursor := func() string {
+		account, err := accounts.Get(me)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return accounts.Cursor(account)
+	}
+
+	// 1. An account the server turns down isn't saved
+	err = accounts.Set(ctx, me, &models.IMAPAccount{Addr: addr, Username: "username"}, "wrong")
+	if err == nil {
+		t.Fatal("saved an account with a wrong password")
+	}
+	if _, err := accounts.Get(me); !errors.Is(err, ErrIMAPNotConfigured) {
+		t.Fatalf("got %v, want no account", err)
+	}
+	if err := accounts.Set(ctx, me, &models.IMAPAccount{Addr: addr, Username: "username"}, "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2. The first sync bootstraps: the recent mail about applications, not the rest
+	// (the memory backend's own message, UID 6, isn't about one)
+	deliver(t, be, "From: Acme <jobs@acme.com>\r\nSubject: Your application\r\nMessage-ID: <m1@acme.com>\r\n\r\nThanks for applying\r\n")
+	svc.SyncEmails(ctx)
+	if got := cursor(); got != "1:7" {
+		t.Errorf("cursor %q after the bootstrap, want 1:7", got)
+	}
+	if processed("<m1@acme.com>") != 1 || processed("<0000000@localhost/>") != 0 {
+		t.Error("bootstrap didn't pick exactly the application")
+	}
+
+	// 3. Then only what arrived since, by UID
+	deliver(t, be, "From: Acme <jobs@acme.com>\r\nSubject: Next steps\r\nMessage-ID: <m2@acme.com>\r\n\r\nWe'd like to interview you\r\n")
+	svc.SyncEmails(ctx)
+	if got := cursor(); got != "1:8" || processed("<m2@acme.com>") != 1 {
+		t.Errorf("cursor %q after new mail, want 1:8 and the mail processed", got)
+	}
+
+	// 4. The folder's UIDVALIDITY changed: the old UIDs mean nothing, the sync starts over
+	// from a bootstrap without processing the same mail twice
+	account, _ := accounts.Get(me)
+	if err := accounts.SaveCursor(account.ID, "99:3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.saveCheckpoint(me, "imap", []string{"4", "5"}); err != nil {
+		t.Fatal(err)
+	}
+	deliver(t, be, "From: Acme <jobs@acme.com>\r\nSubject: Interview update\r\nMessage-ID: <m3@acme.com>\r\n\r\nSee you Thursday\r\n")
+	svc.SyncEmails(ctx)
+	if got := cursor(); got != "1:9" {
+		t.Errorf("cursor %q after the reset, want 1:9", got)
+	}
+	if processed("<m1@acme.com>") != 1 || processed("<m3@acme.com>") != 1 {
+		t.Error("the bootstrap after the reset didn't pick up the mail")
+	}
+	if pending, _ := svc.loadCheckpoint(me, "imap"); len(pending) != 0 {
+		t.Errorf("checkpoint %v from before the reset was kept", pending)
+	}
+	var unfinished int64
+	db.Model(&models.QueuedEmail{}).Where("state <> ?", models.QueueStateDone).Count(&unfinished)
+	if unfinished != 0 {
+		t.Errorf("%d queued emails aren't done", unfinished)
+	}
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrEmailNotFound = errors.New("email not found")
+
+// Emails returns the emails the pipeline linked to the job, newest first
+func (s *JobService) Emails(userID, jobID uint) ([]models.Email, error) {
+	if _, err := s.GetJob(userID, jobID); err != nil {
+		return nil, err
+	}
+	emails := []models.Email{}
+	err := s.DB.Where("job_id = ? AND user_id = ?", jobID, userID).
+		Order("received_at DESC, id DESC").
+		Find(&emails).Error
+	return emails, err
+}
+
+// GetEmail returns one of the user's processed emails
+func (s *JobService) GetEmail(userID, id uint) (*models.Email, error) {
+	var email models.Email
+	err := s.DB.Where("id = ? AND user_id = ?", id, userID).First(&email).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrEmailNotFound
+	}
+	return &email, err
+}
@@ -1,11 +1,47 @@
 package services
 
 import (
+	"errors"
+	"strings"
+	"time"
+
 	"github.com/justsurfingit/Agentic-Job-Tracker/internal/dtos"
 	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/status"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrJobNotFound is returned when the job does not exist (or was soft deleted)
+	ErrJobNotFound = errors.New("job not found")
+	// ErrInvalidWorkMode is returned for a work_mode outside REMOTE / HYBRID / ONSITE
+	ErrInvalidWorkMode = errors.New("work_mode must be one of REMOTE, HYBRID, ONSITE")
+	// ErrBlankCompanyName is returned when an update sets company_name to nothing
+	ErrBlankCompanyName = errors.New("company_name must not be empty")
+	// ErrStatusConflict is returned when the job's status changed while we were updating it
+	ErrStatusConflict = errors.New("job status was changed concurrently, retry")
+)
+
+// Where a status change came from. Every caller goes through ChangeStatus with one of these.
+const (
+	SourceManual = "manual"
+	SourceEmail  = "email"
 )
 
+// StatusChange is a request to move a job to a new stage
+type StatusChange struct {
+	To     status.Status
+	Source string // SourceManual, SourceEmail, ...
+	Note   string // Optional reason, e.g. the LLM's summary of the email
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// JobService methods take the owner's userID: a user can only see and touch their own jobs.
 type JobService struct {
 	DB *gorm.DB
 }
@@ -15,38 +51,78 @@ func NewJobService(db *gorm.DB) *JobService {
 		DB: db,
 	}
 }
-func (s *JobService) CreateJob(req *dtos.JobCreationRequest) (*models.Job, error) {
+func (s *JobService) CreateJob(userID uint, req *dtos.JobCreationRequest) (*models.Job, error) {
 	// 1. Find or Create the Company
-	// We search by Name. If it doesn't exist, GORM creates it.
-	var company models.Company
-	// Using Where(...) with FirstOrCreate is safer to ensure the Name is set on creation
-	err := s.DB.Where(models.Company{Name: req.CompanyName}).
-		Attrs(models.Company{Name: req.CompanyName}). // Ensure Name is set if creating new
-		FirstOrCreate(&company).Error
+	company, err := s.findOrCreateCompany(s.DB, userID, req.CompanyName)
 	if err != nil {
 		return nil, err
 	}
 
 	// 2. Prepare the Job Object
-	// Set default status if the request didn't provide one
-	status := req.Status
-	if status == "" {
-		status = "APPLIED"
+	// Set default status if the request didn't provide one.
+	// A job can be created in any stage (e.g. back-filling an interview you already had).
+	initialStatus := status.Applied
+	if req.Status != "" {
+		initialStatus, err = status.Parse(req.Status)
+		if err != nil {
+			return nil, err
+		}
+	}
+	interviewRound := 0
+	if initialStatus == status.Interview {
+		interviewRound = 1
+	}
+
+	// Explicit work mode wins, otherwise we guess it from the location ("Remote - US")
+	workMode, ok := NormalizeWorkMode(req.WorkMode)
+	if !ok {
+		return nil, ErrInvalidWorkMode
+	}
+	guessed := workMode == ""
+	if guessed {
+		workMode = ParseWorkMode(req.Location)
+	}
+
+	technologies, err := s.findOrCreateTechnologies(s.DB, req.TechStack)
+	if err != nil {
+		return nil, err
 	}
 
 	job := &models.Job{
-		CompanyID:   company.ID,
-		Title:       req.Title,
-		Description: req.Description,
-		JobLink:     req.JobLink,
-		ResumeLink:  req.ResumeLink,
-		Status:      status,
-		// Note: If you added Location/Salary to your Job Model, map them here too.
-		// e.g. Location: req.Location,
+		UserID:          userID,
+		CompanyID:       company.ID,
+		Title:           req.Title,
+		Description:     req.Description,
+		JobLink:         req.JobLink,
+		ResumeLink:      req.ResumeLink,
+		Status:          initialStatus,
+		InterviewRound:  interviewRound,
+		Location:        strings.TrimSpace(req.Location),
+		WorkMode:        workMode,
+		WorkModeGuessed: guessed && workMode != "",
+		Salary:          ParseSalary(req.SalaryRange),
+		Technologies:    technologies,
 	}
 
-	// 3. Save Job to Database
-	err = s.DB.Create(job).Error
+	// 3. Save Job to Database together with its "created" timeline event
+	// The technologies already have IDs, so GORM only writes the job_technologies join rows
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		err := recordEvent(tx, job, models.EventCreated, SourceManual, models.CreatedPayload{
+			Title:   job.Title,
+			Company: company.Name,
+			Status:  job.Status,
+		})
+		if err != nil {
+			return err
+		}
+		if initialStatus == status.Interview {
+			return recordEvent(tx, job, models.EventInterviewScheduled, SourceManual, models.InterviewScheduledPayload{Round: 1})
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -55,7 +131,449 @@ func (s *JobService) CreateJob(req *dtos.JobCreationRequest) (*models.Job, error
 	// GORM's Create() sets the ID but leaves the 'Company' struct empty.
 	// We plug the company we found earlier back into the job object
 	// so the frontend gets the full data immediately.
-	job.Company = company
+	job.Company = *company
 
 	return job, nil
 }
+
+// ListJobs returns one page of jobs matching the filters, newest first
+func (s *JobService) ListJobs(userID uint, q *dtos.JobListQuery) (*dtos.JobListResponse, error) {
+	page := q.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := q.PageSize
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	// 1. Build the filtered query
+	// LOWER(...) LIKE is used instead of ILIKE so the SQL stays portable across engines.
+	query := s.DB.Model(&models.Job{}).Where("jobs.user_id = ?", userID)
+	if q.Status != "" {
+		query = query.Where("jobs.status = ?", strings.ToUpper(q.Status))
+	}
+	if q.Company != "" {
+		query = query.Joins("JOIN companies ON companies.id = jobs.company_id").
+			Where("LOWER(companies.name) LIKE ? ESCAPE '\\'", likePattern(q.Company))
+	}
+	if !q.From.IsZero() {
+		query = query.Where("jobs.created_at >= ?", q.From)
+	}
+	if !q.To.IsZero() {
+		// "to" is inclusive, so we compare against the start of the next day
+		query = query.Where("jobs.created_at < ?", q.To.Add(24*time.Hour))
+	}
+	if q.Query != "" {
+		pattern := likePattern(q.Query)
+		query = query.Where("(LOWER(jobs.title) LIKE ? ESCAPE '\\' OR LOWER(jobs.description) LIKE ? ESCAPE '\\')", pattern, pattern)
+	}
+	if q.Location != "" {
+		query = query.Where("LOWER(jobs.location) LIKE ? ESCAPE '\\'", likePattern(q.Location))
+	}
+	if q.WorkMode != "" {
+		query = query.Where("jobs.work_mode = ?", strings.ToUpper(q.WorkMode))
+	}
+	if q.Tech != "" {
+		query = query.Where("jobs.id IN (?)", s.DB.Table("job_technologies").
+			Select("job_technologies.job_id").
+			Joins("JOIN technologies ON technologies.id = job_technologies.technology_id").
+			Where("technologies.slug = ?", techSlug(q.Tech)))
+	}
+	if q.SalaryMin > 0 {
+		// A range "reaches" the amount if its top (or its only bound) is at least that much
+		query = query.Where("(jobs.salary_max >= ? OR (jobs.salary_max = 0 AND jobs.salary_min >= ?))", q.SalaryMin, q.SalaryMin)
+	}
+	if q.Currency != "" {
+		query = query.Where("jobs.salary_currency = ?", strings.ToUpper(q.Currency))
+	}
+
+	// A new Session lets us reuse the same filters for both Count and Find
+	query = query.Session(&gorm.Session{})
+
+	// 2. Count before paginating so the client knows how many pages exist
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	// 3. Fetch the page
+	jobs := []models.Job{}
+	err := query.Preload("Company").Preload("Technologies").
+		Order("jobs.created_at DESC, jobs.id DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &dtos.JobListResponse{
+		Jobs:     jobs,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// GetJob loads a single job with its Company and Technologies preloaded
+func (s *JobService) GetJob(userID, id uint) (*models.Job, error) {
+	var job models.Job
+	err := s.DB.Preload("Company").Preload("Technologies").
+		Where("user_id = ?", userID).
+		First(&job, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ActiveJobs returns the user's jobs at the company that can still move, oldest first.
+// Terminal ones (REJECTED, ACCEPTED, ...) are left out, nothing an email says changes them.
+func (s *JobService) ActiveJobs(userID, companyID uint) ([]models.Job, error) {
+	jobs := []models.Job{}
+	err := s.DB.Where("user_id = ? AND company_id = ? AND status NOT IN ?", userID, companyID, status.Terminal()).
+		Order("id").
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// UpdateJob applies a partial update. Only the fields present in the request are touched.
+// Everything is validated first and written in one transaction, so a rejected status
+// move leaves the other fields alone too.
+func (s *JobService) UpdateJob(userID, id uint, req *dtos.JobUpdateRequest) (*models.Job, error) {
+	job, err := s.GetJob(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Validate
+	// Status goes through the state machine like every other caller.
+	// PATCH is idempotent, so sending the current status again is a no-op (use ChangeStatus for a new round).
+	var change *StatusChange
+	if req.Status != nil {
+		to, err := status.Parse(*req.Status)
+		if err != nil {
+			return nil, err
+		}
+		if to != job.Status {
+			if err := status.Transition(job.Status, to); err != nil {
+				return nil, err
+			}
+			change = &StatusChange{To: to, Source: SourceManual}
+		}
+	}
+
+	updates := map[string]interface{}{}
+	if req.Title != nil {
+		updates["title"] = *req.Title
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.JobLink != nil {
+		updates["job_link"] = *req.JobLink
+	}
+	if req.ResumeLink != nil {
+		updates["resume_link"] = *req.ResumeLink
+	}
+	if req.CompanyName != nil {
+		name := strings.TrimSpace(*req.CompanyName)
+		if name == "" {
+			return nil, ErrBlankCompanyName
+		}
+		req.CompanyName = &name
+	}
+	if req.Location != nil {
+		updates["location"] = strings.TrimSpace(*req.Location)
+		// Re-guess the work mode, unless the user chose it (before or in this request, below)
+		if job.WorkMode == "" || job.WorkModeGuessed {
+			workMode := ParseWorkMode(*req.Location)
+			updates["work_mode"] = workMode
+			updates["work_mode_guessed"] = workMode != ""
+		}
+	}
+	if req.WorkMode != nil {
+		workMode, ok := NormalizeWorkMode(*req.WorkMode)
+		if !ok {
+			return nil, ErrInvalidWorkMode
+		}
+		updates["work_mode"] = workMode
+		updates["work_mode_guessed"] = false
+	}
+	if req.SalaryRange != nil {
+		salary := ParseSalary(*req.SalaryRange)
+		updates["salary_min"] = salary.Min
+		updates["salary_max"] = salary.Max
+		updates["salary_currency"] = salary.Currency
+		updates["salary_period"] = salary.Period
+		updates["salary_raw"] = salary.Raw
+	}
+
+	// 2. Write the fields, the technologies and the status move together
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if req.CompanyName != nil && *req.CompanyName != job.Company.Name {
+			company, err := s.findOrCreateCompany(tx, userID, *req.CompanyName)
+			if err != nil {
+				return err
+			}
+			updates["company_id"] = company.ID
+		}
+		if len(updates) > 0 {
+			// Not through Model(job): GORM would save the preloaded Company back over company_id
+			if err := tx.Model(&models.Job{}).Where("id = ?", job.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		if req.TechStack != nil {
+			technologies, err := s.findOrCreateTechnologies(tx, *req.TechStack)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(job).Association("Technologies").Replace(technologies); err != nil {
+				return err
+			}
+		}
+		if change != nil {
+			return changeStatus(tx, job, *change)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Reload so the response carries the (possibly new) Company association
+	return s.GetJob(userID, id)
+}
+
+// ChangeStatus is the single entry point for moving a job through the pipeline.
+// It validates the move against the status graph, so e.g. a late "thanks for applying"
+// email can never drag an OFFER back to APPLIED. Re-entering INTERVIEW starts a new round.
+func (s *JobService) ChangeStatus(userID, id uint, change StatusChange) (*models.Job, error) {
+	job, err := s.GetJob(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := status.Transition(job.Status, change.To); err != nil {
+		return nil, err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		return changeStatus(tx, job, change)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetJob(userID, id)
+}
+
+// changeStatus writes a validated status move of the job inside the caller's transaction
+func changeStatus(tx *gorm.DB, job *models.Job, change StatusChange) error {
+	from := job.Status
+	updates := map[string]interface{}{"status": change.To}
+	round := job.InterviewRound
+	if change.To == status.Interview {
+		round++
+		updates["interview_round"] = round
+	}
+
+	// Compare-and-swap on the old status so two concurrent writers (HTTP + watcher)
+	// can't both apply a transition computed from the same starting point
+	res := tx.Model(&models.Job{}).
+		Where("id = ? AND user_id = ? AND status = ?", job.ID, job.UserID, from).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrStatusConflict
+	}
+
+	// Every status mutation lands on the timeline, whoever made it
+	err := recordEvent(tx, job, models.EventStatusChanged, change.Source, models.StatusChangedPayload{
+		From: from,
+		To:   change.To,
+		Note: change.Note,
+	})
+	if err != nil {
+		return err
+	}
+	if change.To == status.Interview {
+		return recordEvent(tx, job, models.EventInterviewScheduled, change.Source, models.InterviewScheduledPayload{
+			Round: round,
+			Note:  change.Note,
+		})
+	}
+	return nil
+}
+
+// AddNote attaches a free-text note to the job's timeline
+func (s *JobService) AddNote(userID, id uint, text string) (*models.JobEvent, error) {
+	job, err := s.GetJob(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.RecordEvent(job, models.EventNoteAdded, SourceManual, models.NoteAddedPayload{Text: text})
+}
+
+// RecordEvent appends an event to the job's timeline (used by the email watcher for email_received).
+// The job must come from GetJob or another owner-scoped query.
+func (s *JobService) RecordEvent(job *models.Job, kind models.EventKind, source string, payload interface{}) (*models.JobEvent, error) {
+	event, err := newEvent(job, kind, source, payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := createEvent(s.DB, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// StatusSince returns when the job got its current status: its last status change, or
+// its creation when it never moved
+func (s *JobService) StatusSince(job *models.Job) (time.Time, error) {
+	var event models.JobEvent
+	err := s.DB.Select("created_at").
+		Where("job_id = ? AND user_id = ? AND kind IN ?", job.ID, job.UserID, []models.EventKind{models.EventCreated, models.EventStatusChanged}).
+		Order("created_at DESC, id DESC").
+		First(&event).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return job.CreatedAt, nil
+	}
+	return event.CreatedAt, err
+}
+
+// Timeline returns the job's events, oldest first
+func (s *JobService) Timeline(userID, id uint) ([]models.JobEvent, error) {
+	if _, err := s.GetJob(userID, id); err != nil {
+		return nil, err
+	}
+	events := []models.JobEvent{}
+	err := s.DB.Where("job_id = ? AND user_id = ?", id, userID).
+		Order("created_at ASC, id ASC").
+		Find(&events).Error
+	return events, err
+}
+
+// DeleteJob soft deletes the job (GORM sets deleted_at because of gorm.DeletedAt)
+func (s *JobService) DeleteJob(userID, id uint) error {
+	res := s.DB.Where("user_id = ?", userID).Delete(&models.Job{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+// findOrCreateCompany searches the user's companies by Name. If it doesn't exist, GORM creates it.
+func (s *JobService) findOrCreateCompany(db *gorm.DB, userID uint, name string) (*models.Company, error) {
+	var company models.Company
+	// Using Where(...) with FirstOrCreate is safer to ensure the Name is set on creation
+	err := db.Where("user_id = ? AND name = ?", userID, name).
+		Attrs(models.Company{UserID: userID, Name: name}). // Ensure owner and Name are set if creating new
+		FirstOrCreate(&company).Error
+	if err != nil {
+		return nil, err
+	}
+	return &company, nil
+}
+
+// ListTechnologies returns the technologies used by the user's (non-deleted) jobs with their job count.
+// The technology rows are shared between users, so unused ones are left out rather than leaking other users' stacks.
+func (s *JobService) ListTechnologies(userID uint) ([]dtos.TechnologyStat, error) {
+	stats := []dtos.TechnologyStat{}
+	err := s.DB.Table("technologies").
+		Select("technologies.name, technologies.slug, COUNT(jobs.id) AS job_count").
+		Joins("JOIN job_technologies ON job_technologies.technology_id = technologies.id").
+		Joins("JOIN jobs ON jobs.id = job_technologies.job_id AND jobs.deleted_at IS NULL AND jobs.user_id = ?", userID).
+		Group("technologies.id, technologies.name, technologies.slug").
+		Order("job_count DESC, technologies.slug").
+		Scan(&stats).Error
+	return stats, err
+}
+
+// findOrCreateTechnologies maps ["Go", "golang "," React"] to Technology rows, creating missing ones.
+// Duplicates (by slug) are collapsed so a job never links the same technology twice.
+func (s *JobService) findOrCreateTechnologies(db *gorm.DB, names []string) ([]models.Technology, error) {
+	var technologies []models.Technology
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		slug := techSlug(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+
+		var tech models.Technology
+		err := db.Where(models.Technology{Slug: slug}).
+			Attrs(models.Technology{Name: name}).
+			FirstOrCreate(&tech).Error
+		if err != nil {
+			return nil, err
+		}
+		technologies = append(technologies, tech)
+	}
+	return technologies, nil
+}
+
+// recordEvent writes a timeline event inside the caller's transaction
+func recordEvent(tx *gorm.DB, job *models.Job, kind models.EventKind, source string, payload interface{}) error {
+	event, err := newEvent(job, kind, source, payload)
+	if err != nil {
+		return err
+	}
+	return createEvent(tx, event)
+}
+
+// createEvent inserts the event. An email already on the job's timeline is skipped,
+// the event then keeps ID 0.
+func createEvent(db *gorm.DB, event *models.JobEvent) error {
+	if event.MessageID != nil {
+		db = db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "job_id"}, {Name: "message_id"}},
+			DoNothing: true,
+		})
+	}
+	return db.Create(event).Error
+}
+
+func newEvent(job *models.Job, kind models.EventKind, source string, payload interface{}) (*models.JobEvent, error) {
+	data, err := models.NewJSON(payload)
+	if err != nil {
+		return nil, err
+	}
+	event := &models.JobEvent{
+		UserID:  job.UserID,
+		JobID:   job.ID,
+		Kind:    kind,
+		Source:  source,
+		Payload: data,
+	}
+	if email, ok := payload.(models.EmailReceivedPayload); ok && email.MessageID != "" {
+		event.MessageID = &email.MessageID
+	}
+	return event, nil
+}
+
+// techSlug is the matching key of a technology name
+func techSlug(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// likePattern turns user input into a lower-case "contains" pattern
+func likePattern(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+	return "%" + s + "%"
+}
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/dtos"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/status"
+	"gorm.io/gorm"
+)
+
+func newJob(company, title string) *dtos.JobCreationRequest {
+	return &dtos.JobCreationRequest{CompanyName: company, Title: title, JobLink: "https://jobs.example.com", Description: "A job"}
+}
+
+func mustCreate(t *testing.T, s *JobService, userID uint, req *dtos.JobCreationRequest) *models.Job {
+	t.Helper()
+	job, err := s.CreateJob(userID, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+// eventKinds lists the job's timeline
+func eventKinds(t *testing.T, s *JobService, userID, jobID uint) []models.EventKind {
+	t.Helper()
+	events, err := s.Timeline(userID, jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make([]models.EventKind, len(events))
+	for i, e := range events {
+		kinds[i] = e.Kind
+	}
+	return kinds
+}
+
+func sameKinds(got []models.EventKind, want ...models.EventKind) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCreateJob(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		s := NewJobService(db)
+		me := createUser(t, db, "me@example.com")
+
+		req := newJob("Acme", "Backend Engineer")
+		req.Location = "Remote - US"
+		req.SalaryRange = "$100k - $150k"
+		req.TechStack = []string{"Go", " go ", "Postgres"}
+		job := mustCreate(t, s, me, req)
+		if job.Status != status.Applied || job.InterviewRound != 0 || job.Company.Name != "Acme" {
+			t.Errorf("got %s round %d at %q", job.Status, job.InterviewRound, job.Company.Name)
+		}
+		if job.WorkMode != models.WorkModeRemote {
+			t.Errorf("work mode %q, want it guessed from the location", job.WorkMode)
+		}
+		if job.Salary.Min != 100000 || job.Salary.Max != 150000 || job.Salary.Currency != "USD" {
+			t.Errorf("salary %+v", job.Salary)
+		}
+		if len(job.Technologies) != 2 {
+			t.Errorf("got %d technologies, want go and postgres once each", len(job.Technologies))
+		}
+		if kinds := eventKinds(t, s, me, job.ID); !sameKinds(kinds, models.EventCreated) {
+			t.Errorf("timeline %v", kinds)
+		}
+
+		// Same company again is reused, an interview starts round 1
+		req = newJob("Acme", "Frontend Engineer")
+		req.Status = "interview"
+		second := mustCreate(t, s, me, req)
+		if second.CompanyID != job.CompanyID {
+			t.Errorf("company %d, want %d reused", second.CompanyID, job.CompanyID)
+		}
+		if second.Status != status.Interview || second.InterviewRound != 1 {
+			t.Errorf("got %s round %d", second.Status, second.InterviewRound)
+		}
+		if kinds := eventKinds(t, s, me, second.ID); !sameKinds(kinds, models.EventCreated, models.EventInterviewScheduled) {
+			t.Errorf("timeline %v", kinds)
+		}
+
+		req = newJob("Acme", "SRE")
+		req.WorkMode = "sometimes"
+		if _, err := s.CreateJob(me, req); !errors.Is(err, ErrInvalidWorkMode) {
+			t.Errorf("got %v, want ErrInvalidWorkMode", err)
+		}
+		req = newJob("Acme", "SRE")
+		req.Status = "HIRED?"
+		if _, err := s.CreateJob(me, req); !errors.Is(err, status.ErrUnknownStatus) {
+			t.Errorf("got %v, want ErrUnknownStatus", err)
+		}
+	})
+}
+
+func TestUpdateJob(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		s := NewJobService(db)
+		me := createUser(t, db, "me@example.com")
+		req := newJob("Acme", "Backend Engineer")
+		req.TechStack = []string{"Go"}
+		job := mustCreate(t, s, me, req)
+
+		title, location, company := "Staff Engineer", "Berlin (hybrid)", "Globex"
+		tech := []string{"Rust", "Kafka"}
+		got, err := s.UpdateJob(me, job.ID, &dtos.JobUpdateRequest{Title: &title, Location: &location, CompanyName: &company, TechStack: &tech})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Title != title || got.Description != "A job" || got.WorkMode != models.WorkModeHybrid {
+			t.Errorf("got %q %q %q", got.Title, got.Description, got.WorkMode)
+		}
+		if got.Company.Name != "Globex" || got.CompanyID == job.CompanyID {
+			t.Errorf("company %d %q, want it moved to Globex", got.CompanyID, got.Company.Name)
+		}
+		if len(got.Technologies) != 2 {
+			t.Errorf("got %d technologies, want the stack replaced", len(got.Technologies))
+		}
+
+		// A status move writes an event, sending the current status again doesn't
+		next := "SCREEN"
+		got, err = s.UpdateJob(me, job.ID, &dtos.JobUpdateRequest{Status: &next})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != status.Screen {
+			t.Errorf("status %s", got.Status)
+		}
+		if _, err := s.UpdateJob(me, job.ID, &dtos.JobUpdateRequest{Status: &next}); err != nil {
+			t.Fatal(err)
+		}
+		if kinds := eventKinds(t, s, me, job.ID); !sameKinds(kinds, models.EventCreated, models.EventStatusChanged) {
+			t.Errorf("timeline %v", kinds)
+		}
+
+		// An illegal move fails before anything is written
+		back, other := "WISHLIST", "Initech"
+		_, err = s.UpdateJob(me, job.ID, &dtos.JobUpdateRequest{Status: &back, Title: &other, CompanyName: &other})
+		if !errors.Is(err, status.ErrIllegalTransition) {
+			t.Fatalf("got %v, want ErrIllegalTransition", err)
+		}
+		got, _ = s.GetJob(me, job.ID)
+		if got.Title != title || got.Company.Name != "Globex" {
+			t.Errorf("got %q at %q after a failed update", got.Title, got.Company.Name)
+		}
+		var companies int64
+		db.Model(&models.Company{}).Where("name = ?", other).Count(&companies)
+		if companies != 0 {
+			t.Error("a failed update created its company")
+		}
+
+		if _, err := s.UpdateJob(me+1, job.ID, &dtos.JobUpdateRequest{Title: &other}); !errors.Is(err, ErrJobNotFound) {
+			t.Errorf("another user's update: got %v, want ErrJobNotFound", err)
+		}
+
+		blank := "  "
+		if _, err := s.UpdateJob(me, job.ID, &dtos.JobUpdateRequest{CompanyName: &blank}); !errors.Is(err, ErrBlankCompanyName) {
+			t.Errorf("blank company: got %v, want ErrBlankCompanyName", err)
+		}
+		padded := " Globex "
+		if _, err := s.UpdateJob(me, job.ID, &dtos.JobUpdateRequest{CompanyName: &padded}); err != nil {
+			t.Fatal(err)
+		}
+		db.Model(&models.Company{}).Where("name LIKE ?", "%Globex%").Count(&companies)
+		if companies != 1 {
+			t.Errorf("%d Globex companies, want the padded name trimmed to the existing one", companies)
+		}
+	})
+}
+
+func TestUpdateJobWorkMode(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		s := NewJobService(db)
+		me := createUser(t, db, "me@example.com")
+		update := func(job *models.Job, req *dtos.JobUpdateRequest) *models.Job {
+			t.Helper()
+			got, err := s.UpdateJob(me, job.ID, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return got
+		}
+		str := func(s string) *string { return &s }
+
+		// A guess follows the location
+		req := newJob("Acme", "Backend Engineer")
+		req.Location = "Remote - EU"
+		guessed := mustCreate(t, s, me, req)
+		if guessed.WorkMode != models.WorkModeRemote || !guessed.WorkModeGuessed {
+			t.Fatalf("created with %q guessed=%v", guessed.WorkMode, guessed.WorkModeGuessed)
+		}
+		if got := update(guessed, &dtos.JobUpdateRequest{Location: str("Berlin (hybrid)")}); got.WorkMode != models.WorkModeHybrid {
+			t.Errorf("work mode %q after a new location, want the guess redone", got.WorkMode)
+		}
+
+		// What the user chose stays, whatever the location says
+		req = newJob("Acme", "Frontend Engineer")
+		req.Location, req.WorkMode = "Berlin", "remote"
+		chosen := mustCreate(t, s, me, req)
+		if chosen.WorkMode != models.WorkModeRemote || chosen.WorkModeGuessed {
+			t.Fatalf("created with %q guessed=%v", chosen.WorkMode, chosen.WorkModeGuessed)
+		}
+		if got := update(chosen, &dtos.JobUpdateRequest{Location: str("Munich office")}); got.WorkMode != models.WorkModeRemote || got.Location != "Munich office" {
+			t.Errorf("got %q at %q, want the chosen work mode kept", got.WorkMode, got.Location)
+		}
+
+		// Choosing one later stops the guessing, in the same request as a new location too
+		update(guessed, &dtos.JobUpdateRequest{Location: str("Anywhere"), WorkMode: str("ONSITE")})
+		if got := update(guessed, &dtos.JobUpdateRequest{Location: str("Remote")}); got.WorkMode != models.WorkModeOnsite || got.WorkModeGuessed {
+			t.Errorf("got %q guessed=%v, want ONSITE as chosen", got.WorkMode, got.WorkModeGuessed)
+		}
+
+		// Nothing to keep: an unknown work mode is guessed
+		unknown := mustCreate(t, s, me, newJob("Acme", "Data Engineer"))
+		if got := update(unknown, &dtos.JobUpdateRequest{Location: str("Remote (US)")}); got.WorkMode != models.WorkModeRemote || !got.WorkModeGuessed {
+			t.Errorf("got %q guessed=%v, want REMOTE guessed", got.WorkMode, got.WorkModeGuessed)
+		}
+	})
+}
+
+func TestChangeStatus(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		s := NewJobService(db)
+		me := createUser(t, db, "me@example.com")
+		job := mustCreate(t, s, me, newJob("Acme", "Backend Engineer"))
+
+		steps := []status.Status{status.Screen, status.Interview, status.Interview, status.Offer, status.Accepted}
+		for _, to := range steps {
+			got, err := s.ChangeStatus(me, job.ID, StatusChange{To: to, Source: SourceManual})
+			if err != nil {
+				t.Fatalf("to %s: %v", to, err)
+			}
+			job = got
+		}
+		if job.InterviewRound != 2 {
+			t.Errorf("round %d, want 2", job.InterviewRound)
+		}
+		want := []models.EventKind{models.EventCreated,
+			models.EventStatusChanged,
+			models.EventStatusChanged, models.EventInterviewScheduled,
+			models.EventStatusChanged, models.EventInterviewScheduled,
+			models.EventStatusChanged,
+			models.EventStatusChanged}
+		if kinds := eventKinds(t, s, me, job.ID); !sameKinds(kinds, want...) {
+			t.Errorf("timeline %v", kinds)
+		}
+
+		// Accepted is terminal
+		if _, err := s.ChangeStatus(me, job.ID, StatusChange{To: status.Rejected, Source: SourceEmail}); !errors.Is(err, status.ErrIllegalTransition) {
+			t.Errorf("got %v, want ErrIllegalTransition", err)
+		}
+	})
+}
+
+func TestChangeStatusConflict(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		s := NewJobService(db)
+		me := createUser(t, db, "me@example.com")
+		job := mustCreate(t, s, me, newJob("Acme", "Backend Engineer"))
+
+		// Someone else moved the job after we read it
+		stale, _ := s.GetJob(me, job.ID)
+		if _, err := s.ChangeStatus(me, job.ID, StatusChange{To: status.Rejected, Source: SourceEmail}); err != nil {
+			t.Fatal(err)
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return changeStatus(tx, stale, StatusChange{To: status.Interview, Source: SourceManual})
+		})
+		if !errors.Is(err, ErrStatusConflict) {
+			t.Fatalf("got %v, want ErrStatusConflict", err)
+		}
+		got, _ := s.GetJob(me, job.ID)
+		if got.Status != status.Rejected || got.InterviewRound != 0 {
+			t.Errorf("got %s round %d", got.Status, got.InterviewRound)
+		}
+	})
+}
+
+func TestListJobs(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		s := NewJobService(db)
+		me := createUser(t, db, "me@example.com")
+		other := createUser(t, db, "other@example.com")
+
+		create := func(company, title, location, salary string, tech ...string) *models.Job {
+			req := newJob(company, title)
+			req.Location, req.SalaryRange, req.TechStack = location, salary, tech
+			return mustCreate(t, s, me, req)
+		}
+		backend := create("Acme", "Backend Engineer", "Remote - US", "$100k - $150k", "Go", "Postgres")
+		frontend := create("Acme", "Frontend Engineer", "London, onsite", "£60k", "React")
+		data := create("100%_Data", "Data Engineer", "Berlin (hybrid)", "€90k - €110k", "Go")
+		mustCreate(t, s, other, newJob("Acme", "Backend Engineer"))
+
+		// Spread the creation days: backend on the 1st, frontend on the 10th, data on the 20th
+		for job, day := range map[*models.Job]int{backend: 1, frontend: 10, data: 20} {
+			at := time.Date(2026, 3, day, 12, 0, 0, 0, time.UTC)
+			if err := db.Model(&models.Job{}).Where("id = ?", job.ID).UpdateColumn("created_at", at).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := s.ChangeStatus(me, frontend.ID, StatusChange{To: status.Interview, Source: SourceManual}); err != nil {
+			t.Fatal(err)
+		}
+
+		day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+		cases := []struct {
+			name  string
+			query dtos.JobListQuery
+			want  []*models.Job
+		}{
+			{"all, newest first", dtos.JobListQuery{}, []*models.Job{data, frontend, backend}},
+			{"status", dtos.JobListQuery{Status: "interview"}, []*models.Job{frontend}},
+			{"company", dtos.JobListQuery{Company: "acm"}, []*models.Job{frontend, backend}},
+			{"company with LIKE characters", dtos.JobListQuery{Company: "0%_d"}, []*models.Job{data}},
+			{"percent is literal", dtos.JobListQuery{Company: "%"}, []*models.Job{data}},
+			{"from", dtos.JobListQuery{From: day(10)}, []*models.Job{data, frontend}},
+			{"to is inclusive", dtos.JobListQuery{To: day(10)}, []*models.Job{frontend, backend}},
+			{"text", dtos.JobListQuery{Query: "FRONTEND"}, []*models.Job{frontend}},
+			{"location", dtos.JobListQuery{Location: "berlin"}, []*models.Job{data}},
+			{"work mode", dtos.JobListQuery{WorkMode: "remote"}, []*models.Job{backend}},
+			{"tech", dtos.JobListQuery{Tech: " GO "}, []*models.Job{data, backend}},
+			{"salary", dtos.JobListQuery{SalaryMin: 100000}, []*models.Job{data, backend}},
+			{"salary in a currency", dtos.JobListQuery{SalaryMin: 100000, Currency: "usd"}, []*models.Job{backend}},
+			{"combined", dtos.JobListQuery{Tech: "go", From: day(2)}, []*models.Job{data}},
+			{"nothing", dtos.JobListQuery{Company: "Globex"}, nil},
+		}
+		for _, c := range cases {
+			got, err := s.ListJobs(me, &c.query)
+			if err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			}
+			if int(got.Total) != len(c.want) || len(got.Jobs) != len(c.want) {
+				t.Errorf("%s: got %d of %d jobs, want %d", c.name, len(got.Jobs), got.Total, len(c.want))
+				continue
+			}
+			for i, job := range got.Jobs {
+				if job.ID != c.want[i].ID {
+					t.Errorf("%s: job %d is %q, want %q", c.name, i, job.Title, c.want[i].Title)
+				}
+			}
+		}
+
+		// Pages keep the total, the page size is capped
+		page, err := s.ListJobs(me, &dtos.JobListQuery{Page: 2, PageSize: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 3 || len(page.Jobs) != 1 || page.Jobs[0].ID != backend.ID {
+			t.Errorf("page 2: got %d of %d", len(page.Jobs), page.Total)
+		}
+		if page.Jobs[0].Company.Name != "Acme" || len(page.Jobs[0].Technologies) != 2 {
+			t.Errorf("associations not loaded: %+v", page.Jobs[0])
+		}
+		page, _ = s.ListJobs(me, &dtos.JobListQuery{PageSize: 1000})
+		if page.PageSize != maxPageSize {
+			t.Errorf("page size %d, want %d", page.PageSize, maxPageSize)
+		}
+	})
+}
+
+func TestActiveJobs(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		s := NewJobService(db)
+		me := createUser(t, db, "me@example.com")
+		other := createUser(t, db, "other@example.com")
+
+		applied := mustCreate(t, s, me, newJob("Acme", "Backend Engineer"))
+		req := newJob("Acme", "Frontend Engineer")
+		req.Status = "OFFER"
+		offer := mustCreate(t, s, me, req)
+		for _, to := range status.Terminal() {
+			job := mustCreate(t, s, me, newJob("Acme", "Ended as "+string(to)))
+			if err := db.Model(&models.Job{}).Where("id = ?", job.ID).Update("status", to).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		deleted := mustCreate(t, s, me, newJob("Acme", "Deleted"))
+		if err := s.DeleteJob(me, deleted.ID); err != nil {
+			t.Fatal(err)
+		}
+		mustCreate(t, s, me, newJob("Globex", "Backend Engineer"))
+		theirs := mustCreate(t, s, other, newJob("Acme", "Backend Engineer"))
+
+		jobs, err := s.ActiveJobs(me, applied.CompanyID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(jobs) != 2 || jobs[0].ID != applied.ID || jobs[1].ID != offer.ID {
+			t.Fatalf("got %+v, want the applied and the offered job", jobs)
+		}
+
+		// The other user's Acme is a company of its own
+		if jobs, _ := s.ActiveJobs(me, theirs.CompanyID); len(jobs) != 0 {
+			t.Errorf("got %d of the other user's jobs", len(jobs))
+		}
+	})
+}
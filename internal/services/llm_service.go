@@ -2,57 +2,58 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"os"
 	"strings"
-	"github.com/tmc/langchaingo/llms"
-	"github.com/tmc/langchaingo/llms/googleai"
-	// Import LangChainGo packages (you'll need to find the specific imports for Gemini)
-	// Hint: look for "github.com/tmc/langchaingo/llms/googleai"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/dtos"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/llm"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailbody"
 )
 
-type LLMService struct {
-	// You might want to hold the LLM client here so you don't recreate it every time
-	Client llms.Model
-}
+// ErrNoRoleMatch means the email doesn't say which of the candidate roles it is about
+var ErrNoRoleMatch = errors.New("email does not identify a specific role")
 
-// NewLLMService initializes the client
-func NewLLMService() *LLMService {
-	// 1. Initialize the Gemini client here using the API key from os.Getenv()
-	// 2. Return the service instance
-	ctx := context.Background()
+// Statuses the email analyzer is allowed to answer with
+var emailStatusEnum = []string{"REJECTED", "SCREEN", "INTERVIEW", "OFFER", "NO_CHANGE", "UNKNOWN"}
 
-	// Fail fast if the API key isn't available so we don't create an unauthenticated client.
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		log.Fatal("CRITICAL ERROR: GEMINI_API_KEY is empty. Did you load the .env file?")
-	}
+// defaultLLMAttempts is how often we re-ask the model when its reply doesn't match the schema
+const defaultLLMAttempts = 3
 
-	// Initialize the client with the Key and the Model
-	llm, err := googleai.New(ctx,
-		googleai.WithAPIKey(apiKey), // Explicitly pass the key
-		googleai.WithDefaultModel("gemini-2.5-flash"),
-	)
+type LLMService struct {
+	// Provider is whatever backend answers our prompts (Gemini, OpenAI-compatible, Ollama, rules, replay)
+	Provider llm.Provider
+	// MaxAttempts bounds the repair / re-ask loop for one task
+	MaxAttempts int
+}
 
-	if err != nil {
-		log.Fatal("Failed to create Gemini client:", err)
-	}
+// EmailAnalysis is the LLM's verdict on a recruiter email
+type EmailAnalysis struct {
+	Status     string  `json:"status"` // One of emailStatusEnum
+	Summary    string  `json:"summary"`
+	Confidence float64 `json:"confidence"`
+}
 
+// NewLLMService wraps an already configured provider (see llm.New)
+func NewLLMService(provider llm.Provider) *LLMService {
+	log.Printf("🤖 LLM provider: %s (%s)", provider.Name(), provider.Model())
 	return &LLMService{
-		Client: llm,
+		Provider:    provider,
+		MaxAttempts: defaultLLMAttempts,
 	}
 }
 
-// ExtractJobDetails takes raw HTML and returns a structured object
-func (s *LLMService) ExtractJobDetails(rawHTML string) (string, error) {
+// ModelVersion names the provider and model that answer, e.g. "gemini/gemini-2.5-flash"
+func (s *LLMService) ModelVersion() string {
+	return s.Provider.Name() + "/" + s.Provider.Model()
+}
 
-	ctx := context.Background()
-	if len(rawHTML) > 20000 {
-		rawHTML = rawHTML[:20000]
+// ExtractJobDetails takes raw HTML and returns a structured object
+func (s *LLMService) ExtractJobDetails(ctx context.Context, rawHTML string) (*dtos.JobExtractionResult, error) {
 
-	}
+	// Job pages are long, the posting is near the top
+	rawHTML, _ = mailbody.Truncate(rawHTML, 20000)
 	const JobExtractionPrompt = `
 You are an expert Job Data Extraction Agent. Your task is to analyze the provided raw HTML/Text from a job posting and extract structured data.
 
@@ -69,8 +70,7 @@ You are an expert Job Data Extraction Agent. Your task is to analyze the provide
     "location": "Job location or 'Remote'",
     "description": "A clean summary of the job. Focus on Responsibilities and Requirements. Remove HTML tags.",
     "tech_stack": ["Array", "of", "technologies", "mentioned", "e.g., Go, React, AWS"],
-    "salary_range": "The salary string if explicitly mentioned (e.g., '$100k - $150k'), otherwise null",
-    
+    "salary_range": "The salary string if explicitly mentioned (e.g., '$100k - $150k'), otherwise null"
 }
 
 ### CONSTRAINT:
@@ -80,16 +80,39 @@ If a piece of information is missing, set the value to null. Do not hallucinate
 %s
 `
 	prompt := fmt.Sprintf(JobExtractionPrompt, rawHTML)
-	resp, err := llms.GenerateFromSinglePrompt(ctx, s.Client, prompt)
+
+	// Missing fields come back as null, so everything except the array is nullable
+	schema := &llm.Schema{
+		Type:     "object",
+		Required: []string{"company_name", "role_title"},
+		Properties: map[string]*llm.Schema{
+			"company_name": {Type: "string", Nullable: true},
+			"role_title":   {Type: "string", Nullable: true},
+			"location":     {Type: "string", Nullable: true},
+			"description":  {Type: "string", Nullable: true},
+			"tech_stack":   {Type: "array", Nullable: true, Items: &llm.Schema{Type: "string"}},
+			"salary_range": {Type: "string", Nullable: true},
+		},
+	}
+
+	var result dtos.JobExtractionResult
+	err := llm.GenerateJSON(ctx, s.Provider, llm.Request{
+		Task:   llm.TaskExtractJob,
+		Prompt: prompt,
+		Inputs: map[string]string{"raw_html": rawHTML},
+	}, schema, &result, s.MaxAttempts)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if result.TechStack == nil {
+		result.TechStack = []string{}
 	}
-	return resp, nil
+	return &result, nil
 }
 
 // Function for identifying which specific role is being talked about here such that we can uniquely identified for which particular application we have recieved an update
-func (s *LLMService) IdentifyJobRole(titles []string, subject, body string) int {
-	ctx := context.Background()
+// Returns ErrNoRoleMatch when the email is generic, or an *llm.OutputError when the model keeps answering garbage.
+func (s *LLMService) IdentifyJobRole(ctx context.Context, titles []string, subject, body string) (int, error) {
 
 	// Create a numbered list string for the prompt
 	titlesList := ""
@@ -98,9 +121,7 @@ func (s *LLMService) IdentifyJobRole(titles []string, subject, body string) int
 	}
 
 	// Truncate body for cost saving
-	if len(body) > 1000 {
-		body = body[:1000]
-	}
+	body, _ = mailbody.Truncate(body, 1000)
 
 	prompt := fmt.Sprintf(`
     I have multiple job applications at this company. Based on the email, identify which role is being discussed.
@@ -117,90 +138,119 @@ func (s *LLMService) IdentifyJobRole(titles []string, subject, body string) int
     Example Output: {"index": 0} or {"index": -1}
     `, titlesList, subject, body)
 
-	// Call LLM
-	resp, err := llms.GenerateFromSinglePrompt(ctx, s.Client, prompt)
-	if err != nil {
-		return -1
+	// The index must point into our list (or be -1)
+	schema := &llm.Schema{
+		Type:     "object",
+		Required: []string{"index"},
+		Properties: map[string]*llm.Schema{
+			"index": {Type: "integer", Minimum: llm.Float(-1), Maximum: llm.Float(float64(len(titles) - 1))},
+		},
 	}
 
-	// Parse Response
+	// Call LLM
 	var result struct {
 		Index int `json:"index"`
 	}
-	if err := json.Unmarshal([]byte(resp), &result); err != nil {
-		return -1
+	err := llm.GenerateJSON(ctx, s.Provider, llm.Request{
+		Task:   llm.TaskIdentifyRole,
+		Prompt: prompt,
+		Inputs: map[string]string{
+			"titles":  strings.Join(titles, "\n"),
+			"subject": subject,
+			"body":    body,
+		},
+	}, schema, &result, s.MaxAttempts)
+	if err != nil {
+		return -1, err
 	}
-
-	// Validate index range
-	if result.Index >= 0 && result.Index < len(titles) {
-		return result.Index
+	if result.Index == -1 {
+		return -1, ErrNoRoleMatch
 	}
-
-	return -1
+	return result.Index, nil
 }
 
-// Analysing the Job status for the applied jobs (Ambigous) one
-func (s *LLMService) AnalyzeEmailStatus(company, subject, body string) (string, error) {
-	ctx := context.Background()
+// Analysing the Job status for the applied jobs (Ambigous) one.
+// thread is the earlier mail of the conversation, oldest first ("" for a new one).
+func (s *LLMService) AnalyzeEmailStatus(ctx context.Context, company, currentStatus, subject, body, thread string) (*EmailAnalysis, error) {
 
 	// 1. Safety Truncation
 	// Emails can be huge (chains of replies). We only need the latest context.
 	// 3000 characters is usually ~750 tokens, well within limits and enough context.
-	if len(body) > 3000 {
-		body = body[:3000] + "...(truncated)"
+	if truncated, cut := mailbody.Truncate(body, 3000); cut {
+		body = truncated + "...(truncated)"
 	}
 
 	// 2. The Prompt
 	// We use "Few-Shot" formatting instructions to ensure strict JSON.
+	// Earlier mail is only context, the status comes from the incoming email.
+	// (Without any the prompt stays as it was, recorded replays still match.)
+	threadSection := ""
+	if thread != "" {
+		threadSection = fmt.Sprintf(`
+
+		EARLIER IN THIS CONVERSATION (oldest first, for context only, judge the status from the incoming email):
+		%s`, thread)
+	}
 	prompt := fmt.Sprintf(`
 		You are an AI Job Application Tracker. Your goal is to keep the user's database up to date.
 		
 		CONTEXT:
 		The user applied to a job at "%s".
-		Current Status in DB: "APPLIED".
+		Current Status in DB: "%s".
 
 		INCOMING EMAIL:
 		Subject: %s
-		Body: %s
+		Body: %s%s
 
 		TASK:
 		Analyze the email and determine if the status of the application has changed.
 		
 		RULES:
 		1. If the email is a rejection (e.g., "unfortunately", "not moving forward"), status is "REJECTED".
-		2. If the email is an invite to chat, phone screen, or interview, status is "INTERVIEW".
-		3. If the email is an offer letter, status is "OFFER".
-		4. If the email is just an acknowledgement ("received"), a newsletter, or asking for login details, status is "NO_CHANGE".
-		5. If the email is totally unrelated (spam), status is "UNKNOWN".
+		2. If the email is an invite to a short recruiter call or phone screen, status is "SCREEN".
+		3. If the email is an invite to a technical, team or onsite interview (any round), status is "INTERVIEW".
+		4. If the email is an offer letter, status is "OFFER".
+		5. If the email is just an acknowledgement ("received"), a newsletter, or asking for login details, status is "NO_CHANGE".
+		6. If the email is totally unrelated (spam), status is "UNKNOWN".
 
 		OUTPUT FORMAT:
 		Return ONLY a valid JSON object. Do not write "Here is the JSON" or use Markdown blocks.
 		{
-			"status": "REJECTED" | "INTERVIEW" | "OFFER" | "NO_CHANGE" | "UNKNOWN",
-			"summary": "A very short, 10-word summary of the email content."
+			"status": "REJECTED" | "SCREEN" | "INTERVIEW" | "OFFER" | "NO_CHANGE" | "UNKNOWN",
+			"summary": "A very short, 10-word summary of the email content.",
+			"confidence": A number between 0 and 1, how sure you are about the status (1 = the email says it explicitly)
 		}
-	`, company, subject, body)
+	`, company, currentStatus, subject, body, threadSection)
+
+	// The status must be one of our enum values, anything else is re-asked
+	schema := &llm.Schema{
+		Type:     "object",
+		Required: []string{"status", "summary", "confidence"},
+		Properties: map[string]*llm.Schema{
+			"status":     {Type: "string", Enum: emailStatusEnum},
+			"summary":    {Type: "string"},
+			"confidence": {Type: "number", Minimum: llm.Float(0), Maximum: llm.Float(1)},
+		},
+	}
 
-	// 3. Call Gemini
+	// 3. Call the LLM
 	// We use a slightly lower temperature (0.1) to make it more deterministic and factual.
-	completion, err := llms.GenerateFromSinglePrompt(ctx, s.Client, prompt, llms.WithTemperature(0.1))
+	var result EmailAnalysis
+	err := llm.GenerateJSON(ctx, s.Provider, llm.Request{
+		Task:        llm.TaskAnalyzeEmail,
+		Prompt:      prompt,
+		Temperature: 0.1,
+		Inputs: map[string]string{
+			"company":        company,
+			"current_status": currentStatus,
+			"subject":        subject,
+			"body":           body,
+			"thread":         thread,
+		},
+	}, schema, &result, s.MaxAttempts)
 	if err != nil {
-		log.Printf("Error calling Gemini LLM: %v", err)
-		return "", err
+		log.Printf("Error calling %s LLM: %v", s.Provider.Name(), err)
+		return nil, err
 	}
-
-	// 4. Cleaning (Sanitization)
-	// Sometimes LLMs wrap JSON in ```json ... ```. We remove that to prevent parsing errors.
-	cleaned := cleanJSONOutput(completion)
-
-	return cleaned, nil
-}
-
-// Helper to strip Markdown formatting if the LLM adds it
-func cleanJSONOutput(input string) string {
-	input = strings.TrimSpace(input)
-	input = strings.TrimPrefix(input, "```json")
-	input = strings.TrimPrefix(input, "```")
-	input = strings.TrimSuffix(input, "```")
-	return strings.TrimSpace(input)
+	return &result, nil
 }
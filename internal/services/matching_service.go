@@ -20,8 +20,8 @@ func NewMatcherService(db *gorm.DB) *MatcherService {
 // Regex for filtering
 // Then put the potential mail to LLM to extract the relevant information regarding the process.
 
-// FindCompanyFromEmail tries to match an email to a tracked Company
-func (s *MatcherService) FindCompanyFromEmail(subject, rawSender string) *models.Company {
+// FindCompanyFromEmail tries to match an email to one of the user's tracked companies
+func (s *MatcherService) FindCompanyFromEmail(userID uint, subject, rawSender string) *models.Company {
 	// 1. Parse the sender header to get "Display Name" and "Address"
 	// e.g. "Stripe Recruiting <jobs@stripe.com>" -> name="Stripe Recruiting", addr="jobs@stripe.com"
 	parsedAddr, err := mail.ParseAddress(rawSender)
@@ -36,10 +36,10 @@ func (s *MatcherService) FindCompanyFromEmail(subject, rawSender string) *models
 
 	subjectLower := strings.ToLower(subject)
 
-	// 2. Fetch all companies
+	// 2. Fetch all of the user's companies
 	// TODO:(Optimization: Cache this map for O(1) lookups in future)
 	var companies []models.Company
-	s.DB.Find(&companies)
+	s.DB.Where("user_id = ?", userID).Find(&companies)
 	for _, company := range companies {
 		companyName := strings.ToLower(company.Name)
 		// SAFETY CHECK: Skip very short names to avoid false positives.
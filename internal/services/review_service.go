@@ -0,0 +1,213 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/dtos"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/status"
+	"gorm.io/gorm"
+)
+
+// Why an email ended up in the review queue instead of being applied
+const (
+	ReviewNoCompanyMatch    = "no_company_match"
+	ReviewAmbiguousRole     = "ambiguous_role"
+	ReviewParseError        = "parse_error"
+	ReviewLowConfidence     = "low_confidence"
+	ReviewRiskyStatus       = "risky_status"
+	ReviewIllegalTransition = "illegal_transition"
+)
+
+// SourceReview marks status changes a human approved from the review queue
+const SourceReview = "review"
+
+// Below this confidence the LLM's verdict is only a proposal
+const defaultConfidenceThreshold = 0.75
+
+var (
+	ErrReviewNotFound   = errors.New("review not found")
+	ErrReviewResolved   = errors.New("review was already approved or dismissed")
+	ErrReviewIncomplete = errors.New("review has no job or status to apply, pass job_id and status")
+)
+
+type ReviewService struct {
+	DB         *gorm.DB
+	JobService *JobService
+	// ConfidenceThreshold is the minimum confidence applied without a human
+	ConfidenceThreshold float64
+}
+
+func NewReviewService(db *gorm.DB, jobs *JobService) *ReviewService {
+	return &ReviewService{
+		DB:                  db,
+		JobService:          jobs,
+		ConfidenceThreshold: defaultConfidenceThreshold,
+	}
+}
+
+// NeedsReview decides whether an LLM verdict can be applied without a human.
+// Offers and rejections are too important to trust blindly, whatever the confidence.
+func (s *ReviewService) NeedsReview(proposed status.Status, confidence float64) (string, bool) {
+	if proposed == status.Offer || proposed == status.Rejected {
+		return ReviewRiskyStatus, true
+	}
+	if confidence < s.ConfidenceThreshold {
+		return ReviewLowConfidence, true
+	}
+	return "", false
+}
+
+// Queue stores a new pending review
+func (s *ReviewService) Queue(review *models.PendingReview) error {
+	review.State = models.ReviewStatePending
+	return s.DB.Create(review).Error
+}
+
+// List returns the user's reviews in the given state (PENDING when empty), newest first
+func (s *ReviewService) List(userID uint, state string) ([]models.PendingReview, error) {
+	if state == "" {
+		state = models.ReviewStatePending
+	}
+	reviews := []models.PendingReview{}
+	err := s.DB.Where("user_id = ? AND state = ?", userID, state).
+		Order("created_at DESC, id DESC").
+		Find(&reviews).Error
+	return reviews, err
+}
+
+// Approve applies the proposed status change. The override can point it at
+// another job and/or status when the LLM picked wrong.
+func (s *ReviewService) Approve(userID, id uint, override *dtos.ReviewApprovalRequest) (*models.PendingReview, error) {
+	review, err := s.getPending(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Work out what to apply: override first, then the proposal
+	jobID := review.ProposedJobID
+	proposed := review.ProposedStatus
+	if override != nil && override.JobID != nil {
+		jobID = override.JobID
+	}
+	if override != nil && override.Status != "" {
+		proposed = override.Status
+	}
+	if jobID == nil || proposed == "" {
+		return nil, ErrReviewIncomplete
+	}
+	to, err := status.Parse(proposed)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.JobService.GetJob(userID, *jobID)
+	if err != nil {
+		return nil, err
+	}
+	if to != job.Status {
+		if err := status.Transition(job.Status, to); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// 2. Claim the review first: of two approvals racing, one finds it resolved and nothing is applied twice
+		if err := resolve(tx, review, models.ReviewStateApproved, &job.ID, string(to)); err != nil {
+			return err
+		}
+
+		// 3. If the email was never tied to this job, put it on the job's timeline now
+		if review.ProposedJobID == nil || *review.ProposedJobID != job.ID {
+			err := recordEvent(tx, job, models.EventEmailReceived, SourceEmail, models.EmailReceivedPayload{
+				MessageID:      review.MessageID,
+				Subject:        review.Subject,
+				From:           review.Sender,
+				Summary:        review.Summary,
+				ProposedStatus: review.ProposedStatus,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// 4. Apply it through the state machine (approving the current status is a no-op)
+		if to != job.Status {
+			err := changeStatus(tx, job, StatusChange{
+				To:     to,
+				Source: SourceReview,
+				Note:   review.Summary,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// 5. Later mail in the conversation goes straight to the job the human picked
+		var email models.Email
+		err := tx.Select("thread_id").Where("user_id = ? AND message_id = ?", userID, review.MessageID).First(&email).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		return linkThread(tx, userID, email.ThreadID, job.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// Dismiss closes the review without touching any job
+func (s *ReviewService) Dismiss(userID, id uint) (*models.PendingReview, error) {
+	review, err := s.getPending(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := resolve(s.DB, review, models.ReviewStateDismissed, nil, ""); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (s *ReviewService) getPending(userID, id uint) (*models.PendingReview, error) {
+	var review models.PendingReview
+	err := s.DB.Where("user_id = ?", userID).First(&review, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if review.State != models.ReviewStatePending {
+		return nil, ErrReviewResolved
+	}
+	return &review, nil
+}
+
+// resolve moves the review out of PENDING, only if it still is. ErrReviewResolved means
+// someone else approved or dismissed it in the meantime.
+func resolve(tx *gorm.DB, review *models.PendingReview, state string, jobID *uint, resolvedStatus string) error {
+	now := time.Now()
+	res := tx.Model(&models.PendingReview{}).
+		Where("id = ? AND user_id = ? AND state = ?", review.ID, review.UserID, models.ReviewStatePending).
+		Updates(map[string]interface{}{
+			"state":           state,
+			"resolved_job_id": jobID,
+			"resolved_status": resolvedStatus,
+			"resolved_at":     now,
+			"updated_at":      now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrReviewResolved
+	}
+	review.State = state
+	review.ResolvedJobID = jobID
+	review.ResolvedStatus = resolvedStatus
+	review.ResolvedAt = &now
+	review.UpdatedAt = now
+	return nil
+}
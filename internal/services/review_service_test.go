@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/dtos"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/status"
+	"gorm.io/gorm"
+)
+
+func queueReview(t *testing.T, s *ReviewService, userID uint, job *models.Job, proposed status.Status) *models.PendingReview {
+	t.Helper()
+	review := &models.PendingReview{
+		UserID:         userID,
+		Reason:         ReviewLowConfidence,
+		MessageID:      "m-" + string(proposed),
+		Subject:        "Next steps",
+		ProposedJobID:  &job.ID,
+		ProposedStatus: string(proposed),
+		Summary:        "They want to talk",
+	}
+	if err := s.Queue(review); err != nil {
+		t.Fatal(err)
+	}
+	return review
+}
+
+func TestApproveReview(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		jobs := NewJobService(db)
+		s := NewReviewService(db, jobs)
+		me := createUser(t, db, "me@example.com")
+		job := mustCreate(t, jobs, me, newJob("Acme", "Backend Engineer"))
+
+		// 1. Approving applies the proposal once, then the review is closed
+		review := queueReview(t, s, me, job, status.Screen)
+		approved, err := s.Approve(me, review.ID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if approved.State != models.ReviewStateApproved || approved.ResolvedStatus != "SCREEN" || approved.ResolvedAt == nil {
+			t.Errorf("got %+v", approved)
+		}
+		if got, _ := jobs.GetJob(me, job.ID); got.Status != status.Screen {
+			t.Errorf("job is %s, want SCREEN", got.Status)
+		}
+		if _, err := s.Approve(me, review.ID, nil); !errors.Is(err, ErrReviewResolved) {
+			t.Errorf("approved twice: %v", err)
+		}
+		if _, err := s.Dismiss(me, review.ID); !errors.Is(err, ErrReviewResolved) {
+			t.Errorf("dismissed an approved review: %v", err)
+		}
+
+		// 2. An illegal move leaves the review pending
+		review = queueReview(t, s, me, job, status.Wishlist)
+		if _, err := s.Approve(me, review.ID, nil); !errors.Is(err, status.ErrIllegalTransition) {
+			t.Errorf("got %v, want an illegal transition", err)
+		}
+		if pending, _ := s.List(me, ""); len(pending) != 1 {
+			t.Errorf("%d pending reviews, want the one that failed", len(pending))
+		}
+		if _, err := s.Approve(me, review.ID, &dtos.ReviewApprovalRequest{Status: "interview"}); err != nil {
+			t.Fatal(err)
+		}
+
+		// 3. A review resolved since it was read isn't resolved again
+		review = queueReview(t, s, me, job, status.Offer)
+		stale := *review
+		if _, err := s.Dismiss(me, review.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := resolve(db, &stale, models.ReviewStateApproved, &job.ID, "OFFER"); !errors.Is(err, ErrReviewResolved) {
+			t.Errorf("got %v, want ErrReviewResolved", err)
+		}
+		if got, _ := jobs.GetJob(me, job.ID); got.Status != status.Interview {
+			t.Errorf("job is %s after a dismissal, want INTERVIEW", got.Status)
+		}
+	})
+}
+
+func TestApproveReviewRace(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		jobs := NewJobService(db)
+		s := NewReviewService(db, jobs)
+		me := createUser(t, db, "me@example.com")
+		job := mustCreate(t, jobs, me, newJob("Acme", "Backend Engineer"))
+		review := queueReview(t, s, me, job, status.Interview)
+
+		var wg sync.WaitGroup
+		errs := make([]error, 4)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, errs[i] = s.Approve(me, review.ID, nil)
+			}(i)
+		}
+		wg.Wait()
+
+		approved := 0
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				approved++
+			case !errors.Is(err, ErrReviewResolved) && !errors.Is(err, ErrStatusConflict) && !errors.Is(err, status.ErrIllegalTransition):
+				t.Errorf("unexpected error %v", err)
+			}
+		}
+		if approved != 1 {
+			t.Errorf("%d approvals went through, want 1", approved)
+		}
+		got, _ := jobs.GetJob(me, job.ID)
+		if got.Status != status.Interview || got.InterviewRound != 1 {
+			t.Errorf("job is %s round %d, want INTERVIEW round 1", got.Status, got.InterviewRound)
+		}
+		want := []models.EventKind{models.EventCreated, models.EventStatusChanged, models.EventInterviewScheduled}
+		if kinds := eventKinds(t, jobs, me, job.ID); !sameKinds(kinds, want...) {
+			t.Errorf("timeline %v", kinds)
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+)
+
+// Approach-
+// Salary strings come from job boards and from the LLM, so they are messy:
+// "$100k - $150k", "€45,000–€55,000 per year", "12-18 LPA", "£30/hr", "Up to 200K USD".
+// We pull out the currency, the (one or two) amounts and the pay period with simple rules.
+// Anything we can't understand is still kept in Salary.Raw.
+
+var (
+	// 120, 120.5, 120,000, 1.200.000 followed by an optional multiplier
+	salaryAmountRe = regexp.MustCompile(`(\d+(?:[.,]\d+)*)\s*(k|m|lpa|lakhs?|lacs?|crores?|cr)?\b`)
+
+	// A single dot followed by exactly three digits separates thousands: 45.000
+	thousandsDotRe = regexp.MustCompile(`^\d+\.\d{3}$`)
+
+	// Swiss style thousands separator: 120'000
+	apostropheThousandsRe = regexp.MustCompile(`(\d)'(\d)`)
+
+	currencyCodeRe = regexp.MustCompile(`\b(usd|eur|gbp|inr|rs|cad|aud|chf|jpy|sgd|nzd|sek|nok|dkk|pln)\b`)
+
+	salaryPeriodRules = []struct {
+		re     *regexp.Regexp
+		period string
+	}{
+		{regexp.MustCompile(`/\s*(h|hr|hour)\b|per\s+hour|an\s+hour|hourly`), models.SalaryPeriodHour},
+		{regexp.MustCompile(`/\s*day\b|per\s+day|a\s+day|daily`), models.SalaryPeriodDay},
+		{regexp.MustCompile(`/\s*(wk|week)\b|per\s+week|a\s+week|weekly`), models.SalaryPeriodWeek},
+		// Before MONTH: "€60k a year + a month of bonus" is a yearly salary
+		{regexp.MustCompile(`/\s*(yr|year|annum)\b|per\s+(year|annum)|a\s+year|annual|yearly|\bp\.?a\.?\b|\blpa\b|\bctc\b`), models.SalaryPeriodYear},
+		{regexp.MustCompile(`/\s*(mo|month)\b|per\s+month|a\s+month|monthly`), models.SalaryPeriodMonth},
+	}
+
+	// Order matters: the multi-character symbols must be checked before the bare "$"
+	currencySymbols = []struct {
+		symbol string
+		code   string
+	}{
+		{"us$", "USD"}, {"c$", "CAD"}, {"ca$", "CAD"}, {"a$", "AUD"}, {"au$", "AUD"}, {"s$", "SGD"},
+		{"$", "USD"}, {"€", "EUR"}, {"£", "GBP"}, {"₹", "INR"}, {"¥", "JPY"},
+	}
+
+	salaryMultipliers = map[string]float64{
+		"k": 1e3, "m": 1e6,
+		"lpa": 1e5, "lakh": 1e5, "lakhs": 1e5, "lac": 1e5, "lacs": 1e5,
+		"cr": 1e7, "crore": 1e7, "crores": 1e7,
+	}
+)
+
+// ParseSalary turns a free-text salary range into a normalized models.Salary
+func ParseSalary(raw string) models.Salary {
+	raw = strings.TrimSpace(raw)
+	salary := models.Salary{Raw: raw}
+	lower := strings.ToLower(raw)
+	if lower == "" || lower == "null" || lower == "n/a" {
+		return models.Salary{}
+	}
+
+	// 1. Currency
+	for _, c := range currencySymbols {
+		if strings.Contains(lower, c.symbol) {
+			salary.Currency = c.code
+			break
+		}
+	}
+	if salary.Currency == "" {
+		if m := currencyCodeRe.FindStringSubmatch(lower); m != nil {
+			salary.Currency = strings.ToUpper(m[1])
+			if salary.Currency == "RS" {
+				salary.Currency = "INR"
+			}
+		}
+	}
+
+	// 2. Amounts
+	lower = apostropheThousandsRe.ReplaceAllString(lower, "$1$2")
+	var amounts []float64
+	var multipliers []string
+	for _, m := range salaryAmountRe.FindAllStringSubmatch(lower, -1) {
+		v, ok := parseSalaryNumber(m[1])
+		if !ok {
+			continue
+		}
+		amounts = append(amounts, v)
+		multipliers = append(multipliers, m[2])
+		if len(amounts) == 2 {
+			break
+		}
+	}
+	// "$100-150k": the first amount borrows the multiplier of the second
+	if len(amounts) == 2 && multipliers[0] == "" && multipliers[1] != "" {
+		multipliers[0] = multipliers[1]
+	}
+	for i := range amounts {
+		if mult, ok := salaryMultipliers[multipliers[i]]; ok {
+			amounts[i] *= mult
+		}
+		if multipliers[i] == "lpa" || strings.HasPrefix(multipliers[i], "la") || strings.HasPrefix(multipliers[i], "cr") {
+			salary.Currency = "INR"
+		}
+	}
+
+	switch {
+	case len(amounts) == 0:
+		return salary
+	case len(amounts) == 1 && strings.Contains(lower, "up to"):
+		salary.Max = round(amounts[0])
+	case len(amounts) == 1 && (strings.Contains(lower, "from") || strings.Contains(lower, "+")):
+		salary.Min = round(amounts[0])
+	case len(amounts) == 1:
+		salary.Min, salary.Max = round(amounts[0]), round(amounts[0])
+	default:
+		salary.Min, salary.Max = round(amounts[0]), round(amounts[1])
+		if salary.Min > salary.Max {
+			salary.Min, salary.Max = salary.Max, salary.Min
+		}
+	}
+
+	// 3. Period
+	for _, rule := range salaryPeriodRules {
+		if rule.re.MatchString(lower) {
+			salary.Period = rule.period
+			break
+		}
+	}
+	if salary.Period == "" {
+		// Nobody quotes a yearly salary below 1000, so small numbers are hourly rates
+		top := salary.Max
+		if top == 0 {
+			top = salary.Min
+		}
+		if top < 1000 {
+			salary.Period = models.SalaryPeriodHour
+		} else {
+			salary.Period = models.SalaryPeriodYear
+		}
+	}
+
+	return salary
+}
+
+// ParseWorkMode guesses REMOTE / HYBRID / ONSITE from a location string
+func ParseWorkMode(location string) string {
+	lower := strings.ToLower(location)
+	switch {
+	case strings.TrimSpace(lower) == "":
+		return ""
+	case strings.Contains(lower, "hybrid"):
+		return models.WorkModeHybrid
+	case strings.Contains(lower, "remote") || strings.Contains(lower, "anywhere") || strings.Contains(lower, "wfh"):
+		return models.WorkModeRemote
+	default:
+		return models.WorkModeOnsite
+	}
+}
+
+// NormalizeWorkMode validates a user supplied work mode ("remote" -> "REMOTE")
+func NormalizeWorkMode(mode string) (string, bool) {
+	mode = strings.ToUpper(strings.TrimSpace(mode))
+	switch mode {
+	case "", models.WorkModeRemote, models.WorkModeHybrid, models.WorkModeOnsite:
+		return mode, true
+	}
+	return "", false
+}
+
+// parseSalaryNumber reads "120,000", "1.200.000", "45.000" and "120.5"
+func parseSalaryNumber(s string) (float64, bool) {
+	switch {
+	case strings.Count(s, ".") > 1 || thousandsDotRe.MatchString(s):
+		// European thousands separators: 1.200.000, and 45.000 (nobody writes three decimals)
+		s = strings.ReplaceAll(s, ".", "")
+	case strings.Contains(s, ".") && strings.Contains(s, ","):
+		// 1,200.50 or 1.200,50 -> whichever comes last is the decimal point
+		if strings.LastIndex(s, ",") > strings.LastIndex(s, ".") {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.ReplaceAll(s, ",", ".")
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	default:
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil
+}
+
+func round(v float64) int64 {
+	return int64(math.Round(v))
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+)
+
+func TestParseSalary(t *testing.T) {
+	tests := []struct {
+		raw      string
+		min, max int64
+		currency string
+		period   string
+	}{
+		{"$100k - $150k", 100000, 150000, "USD", models.SalaryPeriodYear},
+		{"$100-150k", 100000, 150000, "USD", models.SalaryPeriodYear},
+		{"€45,000–€55,000 per year", 45000, 55000, "EUR", models.SalaryPeriodYear},
+		{"€45.000 – €55.000", 45000, 55000, "EUR", models.SalaryPeriodYear},
+		{"1.200.000 - 1.500.000 INR", 1200000, 1500000, "INR", models.SalaryPeriodYear},
+		{"€4.500 per month", 4500, 4500, "EUR", models.SalaryPeriodMonth},
+		{"1.200,50 EUR/month", 1201, 1201, "EUR", models.SalaryPeriodMonth},
+		{"$1,200.50 a week", 1201, 1201, "USD", models.SalaryPeriodWeek},
+		{"€42.5k", 42500, 42500, "EUR", models.SalaryPeriodYear},
+		{"CHF 120'000", 120000, 120000, "CHF", models.SalaryPeriodYear},
+		{"12-18 LPA", 1200000, 1800000, "INR", models.SalaryPeriodYear},
+		{"£30/hr", 30, 30, "GBP", models.SalaryPeriodHour},
+		{"$45", 45, 45, "USD", models.SalaryPeriodHour},
+		{"Up to 200K USD", 0, 200000, "USD", models.SalaryPeriodYear},
+		{"From $90k", 90000, 0, "USD", models.SalaryPeriodYear},
+		{"€60k per year + 13th month", 60000, 0, "EUR", models.SalaryPeriodYear},
+		{"€60k a year plus a month of paid leave", 60000, 60000, "EUR", models.SalaryPeriodYear},
+		{"$500 a day", 500, 500, "USD", models.SalaryPeriodDay},
+		{"Competitive", 0, 0, "", ""},
+	}
+	for _, tt := range tests {
+		got := ParseSalary(tt.raw)
+		want := models.Salary{Min: tt.min, Max: tt.max, Currency: tt.currency, Period: tt.period, Raw: tt.raw}
+		if got != want {
+			t.Errorf("ParseSalary(%q) = %+v, want %+v", tt.raw, got, want)
+		}
+	}
+
+	for _, empty := range []string{"", "  ", "null", "N/A"} {
+		if got := ParseSalary(empty); got != (models.Salary{}) {
+			t.Errorf("ParseSalary(%q) = %+v, want nothing", empty, got)
+		}
+	}
+}
+
+func TestParseWorkMode(t *testing.T) {
+	tests := []struct {
+		location string
+		want     string
+	}{
+		{"", ""},
+		{"   ", ""},
+		{"Remote - US", models.WorkModeRemote},
+		{"Anywhere in Europe", models.WorkModeRemote},
+		{"WFH", models.WorkModeRemote},
+		{"Berlin (Hybrid)", models.WorkModeHybrid},
+		{"Hybrid remote, London", models.WorkModeHybrid},
+		{"Munich", models.WorkModeOnsite},
+	}
+	for _, tt := range tests {
+		if got := ParseWorkMode(tt.location); got != tt.want {
+			t.Errorf("ParseWorkMode(%q) = %q, want %q", tt.location, got, tt.want)
+		}
+	}
+
+	modes := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"remote", models.WorkModeRemote, true},
+		{" Hybrid ", models.WorkModeHybrid, true},
+		{"", "", true},
+		{"sometimes", "", false},
+	}
+	for _, tt := range modes {
+		if got, ok := NormalizeWorkMode(tt.in); got != tt.want || ok != tt.ok {
+			t.Errorf("NormalizeWorkMode(%q) = %q, %v", tt.in, got, ok)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// syncLockSpace is the first key of the Postgres advisory locks ("JT"), keeping them
+// apart from other applications' locks on the same database
+const syncLockSpace int32 = 0x4a54
+
+var ErrSyncRunning = errors.New("a sync of this user's mail is already running")
+
+// SyncLocker lets only one sync of a user's mail run at a time, so two never process
+// the same emails or move the same cursor
+type SyncLocker interface {
+	// TryLock takes the user's lock without waiting, ok is false when another sync holds it
+	TryLock(ctx context.Context, userID uint) (unlock func(), ok bool, err error)
+}
+
+// NewSyncLocker picks the lock for the database. Postgres may be shared by several
+// instances and gets an advisory lock. A SQLite file is shared by the processes on this
+// machine, the server and the CLI, and gets file locks next to it. An in-memory database
+// is private to this process and a local lock will do.
+func NewSyncLocker(db *gorm.DB) SyncLocker {
+	if db.Dialector.Name() == "postgres" {
+		return &AdvisoryLocker{DB: db}
+	}
+	var file string
+	if err := db.Raw("SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&file).Error; err != nil {
+		log.Printf("⚠️ Could not find the database file, syncs are only locked within this process: %v", err)
+	}
+	if file == "" {
+		return &LocalLocker{}
+	}
+	return newFileLocker(file + "-locks")
+}
+
+// LocalLocker locks users within this process
+type LocalLocker struct {
+	mu      sync.Mutex
+	syncing map[uint]bool
+}
+
+func (l *LocalLocker) TryLock(ctx context.Context, userID uint) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.syncing[userID] {
+		return nil, false, nil
+	}
+	if l.syncing == nil {
+		l.syncing = map[uint]bool{}
+	}
+	l.syncing[userID] = true
+	return func() {
+		l.mu.Lock()
+		delete(l.syncing, userID)
+		l.mu.Unlock()
+	}, true, nil
+}
+
+// AdvisoryLocker locks users across every instance sharing the Postgres database.
+// The lock belongs to a database session, so each holds one pooled connection until it's released.
+type AdvisoryLocker struct {
+	DB *gorm.DB
+}
+
+func (l *AdvisoryLocker) TryLock(ctx context.Context, userID uint) (func(), bool, error) {
+	sqlDB, err := l.DB.DB()
+	if err != nil {
+		return nil, false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var ok bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1, $2)", syncLockSpace, int32(userID)).Scan(&ok)
+	if err != nil || !ok {
+		conn.Close()
+		return nil, false, err
+	}
+	return func() {
+		// The sync's context may be cancelled by now, the lock must go anyway
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1, $2)", syncLockSpace, int32(userID))
+		if err != nil {
+			// Dropping the session releases its locks, instead of pooling a connection that holds one
+			log.Printf("⚠️ Could not release the sync lock of user %d: %v", userID, err)
+			_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, true, nil
+}
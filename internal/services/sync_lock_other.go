@@ -0,0 +1,9 @@
+//go:build !unix
+
+package services
+
+// newFileLocker has no file locks to offer here, syncs are only locked within this
+// process. Don't run the CLI against a server's SQLite file while it syncs.
+func newFileLocker(dir string) SyncLocker {
+	return &LocalLocker{}
+}
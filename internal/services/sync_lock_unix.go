@@ -0,0 +1,44 @@
+//go:build unix
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// FileLocker locks users across the processes on this machine with a flock on a file
+// per user in Dir. The OS releases the lock when its process dies, so a crashed sync
+// never leaves a user locked.
+type FileLocker struct {
+	Dir string
+}
+
+func newFileLocker(dir string) SyncLocker {
+	return &FileLocker{Dir: dir}
+}
+
+func (l *FileLocker) TryLock(ctx context.Context, userID uint) (func(), bool, error) {
+	if err := os.MkdirAll(l.Dir, 0o755); err != nil {
+		return nil, false, err
+	}
+	f, err := os.OpenFile(filepath.Join(l.Dir, fmt.Sprintf("user-%d.lock", userID)), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, false, err
+	}
+	// Each open file has a lock of its own, so this also keeps out syncs of this process
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	// Closing the file releases the lock
+	return func() { f.Close() }, true, nil
+}
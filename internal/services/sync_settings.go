@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/dtos"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailsource"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+)
+
+// maxSyncTerms bounds the keywords and labels, they all end up in one search query
+const maxSyncTerms = 50
+
+var ErrInvalidSyncSettings = errors.New("invalid sync settings")
+
+// SyncSettings returns the user's sync settings with the defaults filled in
+func (s *UserService) SyncSettings(user *models.User) *dtos.SyncSettings {
+	f := syncFilter(user)
+	keywords := f.Keywords
+	if len(keywords) == 0 {
+		keywords = mailsource.DefaultKeywords
+	}
+	labels := f.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+	return &dtos.SyncSettings{Keywords: keywords, Labels: labels, WindowDays: windowDays(user)}
+}
+
+// SetSyncSettings replaces the user's sync settings. They apply from the next first sync or backfill.
+func (s *UserService) SetSyncSettings(user *models.User, req *dtos.SyncSettings) (*dtos.SyncSettings, error) {
+	keywords, labels := cleanTerms(req.Keywords), cleanTerms(req.Labels)
+	if len(keywords) > maxSyncTerms || len(labels) > maxSyncTerms {
+		return nil, ErrInvalidSyncSettings
+	}
+	if req.WindowDays < 0 {
+		return nil, ErrInvalidSyncSettings
+	}
+
+	settings := models.SyncSettings{WindowDays: req.WindowDays}
+	var err error
+	if len(keywords) > 0 {
+		if settings.Keywords, err = models.NewJSON(keywords); err != nil {
+			return nil, err
+		}
+	}
+	if len(labels) > 0 {
+		if settings.Labels, err = models.NewJSON(labels); err != nil {
+			return nil, err
+		}
+	}
+
+	// Select the columns so empty values are written too
+	err = s.DB.Model(user).Select("sync_keywords", "sync_labels", "sync_window_days").Updates(&models.User{Sync: settings}).Error
+	if err != nil {
+		return nil, err
+	}
+	user.Sync = settings
+	return s.SyncSettings(user), nil
+}
+
+// cleanTerms trims the terms and drops blanks and repeats
+func cleanTerms(terms []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, t := range terms {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		seen[strings.ToLower(t)] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// syncFilter is the user's keywords and labels, without a date range
+func syncFilter(user *models.User) mailsource.Filter {
+	var f mailsource.Filter
+	// Unreadable settings fall back to the defaults rather than stopping the sync
+	_ = user.Sync.Keywords.Decode(&f.Keywords)
+	_ = user.Sync.Labels.Decode(&f.Labels)
+	return f
+}
+
+// bootstrapFilter is what the first sync of the user's mailboxes looks at
+func bootstrapFilter(user *models.User) mailsource.Filter {
+	f := syncFilter(user)
+	f.After = time.Now().AddDate(0, 0, -windowDays(user))
+	return f
+}
+
+func windowDays(user *models.User) int {
+	if user.Sync.WindowDays > 0 {
+		return user.Sync.WindowDays
+	}
+	return mailsource.DefaultWindowDays
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/database"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// postgresDSNEnv names the Postgres database the tests also run against, e.g.
+// TEST_POSTGRES_DSN="host=localhost user=postgres dbname=jobtracker_test sslmode=disable".
+// Every test gets a schema of its own there, dropped when it ends. Unset = SQLite only.
+const postgresDSNEnv = "TEST_POSTGRES_DSN"
+
+// forEachDB runs the test once per database engine, each time on a fresh, migrated database
+func forEachDB(t *testing.T, test func(t *testing.T, db *gorm.DB)) {
+	t.Run(database.DriverSQLite, func(t *testing.T) {
+		test(t, sqliteDB(t))
+	})
+	t.Run(database.DriverPostgres, func(t *testing.T) {
+		dsn := os.Getenv(postgresDSNEnv)
+		if dsn == "" {
+			t.Skipf("%s is not set", postgresDSNEnv)
+		}
+		test(t, postgresDB(t, dsn))
+	})
+}
+
+// sqliteDB opens a migrated SQLite file, like database.Connect with one connection
+func sqliteDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := openDB(t, database.DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	migrate(t, db)
+	return db
+}
+
+// postgresDB creates a schema for the test and opens a migrated database that works in it
+func postgresDB(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatal(err)
+	}
+	schema := "test_" + hex.EncodeToString(suffix)
+
+	admin := openDB(t, database.DriverPostgres, dsn)
+	if err := admin.Exec(fmt.Sprintf("CREATE SCHEMA %s", schema)).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Cleanups run last-in first-out: the test's connections are closed before the drop
+	t.Cleanup(func() {
+		if err := admin.Exec(fmt.Sprintf("DROP SCHEMA %s CASCADE", schema)).Error; err != nil {
+			t.Errorf("could not drop schema %s: %v", schema, err)
+		}
+	})
+
+	db := openDB(t, database.DriverPostgres, withSearchPath(dsn, schema))
+	migrate(t, db)
+	return db
+}
+
+// withSearchPath adds the search_path to a URL or a key=value DSN
+func withSearchPath(dsn, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			q := u.Query()
+			q.Set("search_path", schema)
+			u.RawQuery = q.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
+
+func openDB(t *testing.T, driver, dsn string) *gorm.DB {
+	t.Helper()
+	dialector, err := database.Dialector(driver, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func migrate(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// createUser adds a user and returns its ID
+func createUser(t *testing.T, db *gorm.DB, email string) uint {
+	t.Helper()
+	user := models.User{Email: email}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user.ID
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/llm"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/mailsource"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+// TestUniqueIndexes checks the engines reject the duplicates the services rely on them to catch
+func TestUniqueIndexes(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		me := createUser(t, db, "me@example.com")
+		other := createUser(t, db, "other@example.com")
+		job := mustCreate(t, NewJobService(db), me, newJob("Acme", "Backend Engineer"))
+		messageID := "<m1@acme.com>"
+
+		duplicates := []struct {
+			name        string
+			first, copy interface{}
+		}{
+			{"user email", &models.User{Email: "dup@example.com"}, &models.User{Email: "dup@example.com"}},
+			{"company name per user", &models.Company{UserID: me, Name: "Globex"}, &models.Company{UserID: me, Name: "Globex"}},
+			{"technology slug", &models.Technology{Name: "Go", Slug: "go"}, &models.Technology{Name: "go", Slug: "go"}},
+			{"processed email", &models.ProcessedEmail{UserID: me, MessageID: "m1"}, &models.ProcessedEmail{UserID: me, MessageID: "m1"}},
+			{"email per job timeline",
+				&models.JobEvent{UserID: me, JobID: job.ID, Kind: models.EventEmailReceived, MessageID: &messageID},
+				&models.JobEvent{UserID: me, JobID: job.ID, Kind: models.EventEmailReceived, MessageID: &messageID}},
+		}
+		for _, d := range duplicates {
+			if err := db.Create(d.first).Error; err != nil {
+				t.Fatalf("%s: %v", d.name, err)
+			}
+			// Each insert in a transaction of its own, a failed statement aborts a Postgres transaction
+			if err := db.Transaction(func(tx *gorm.DB) error { return tx.Create(d.copy).Error }); err == nil {
+				t.Errorf("%s: duplicate was inserted", d.name)
+			}
+		}
+
+		// The keys are scoped: another user's company and events without a message ID can repeat
+		if err := db.Create(&models.Company{UserID: other, Name: "Globex"}).Error; err != nil {
+			t.Errorf("another user's company: %v", err)
+		}
+		for range 2 {
+			if err := db.Create(&models.JobEvent{UserID: me, JobID: job.ID, Kind: models.EventNoteAdded}).Error; err != nil {
+				t.Errorf("note without a message ID: %v", err)
+			}
+		}
+	})
+}
+
+// TestUpserts checks the writes that meet those indexes on purpose don't fail on the second run
+func TestUpserts(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		jobs := NewJobService(db)
+		svc := NewEmailService(db, NewLLMService(llm.NewRules()), nil, NewUserService(db, nil), NewMatcherService(db), jobs, NewReviewService(db, jobs))
+		me := createUser(t, db, "me@example.com")
+		backend := mustCreate(t, jobs, me, newJob("Acme", "Backend Engineer"))
+		frontend := mustCreate(t, jobs, me, newJob("Acme", "Frontend Engineer"))
+		msg := &mailsource.Message{
+			ID:      "m1",
+			Headers: map[string]string{"Subject": "Your application", "From": "hr@acme.com", "Message-Id": "<m1@acme.com>"},
+			Body:    "Thanks for applying",
+		}
+
+		t.Run("queue keeps the first copy", func(t *testing.T) {
+			if err := svc.Queue.Enqueue(me, "imap", []*mailsource.Message{msg}); err != nil {
+				t.Fatal(err)
+			}
+			again := *msg
+			again.Headers = map[string]string{"Subject": "Changed"}
+			if err := svc.Queue.Enqueue(me, "imap", []*mailsource.Message{&again}); err != nil {
+				t.Fatal(err)
+			}
+			if err := svc.Queue.Enqueue(me, "gmail", []*mailsource.Message{msg}); err != nil {
+				t.Fatal(err)
+			}
+			var items []models.QueuedEmail
+			db.Order("id").Find(&items)
+			if len(items) != 2 || items[0].Subject != "Your application" {
+				t.Errorf("got %+v, want one item per mailbox", items)
+			}
+		})
+
+		t.Run("processed marks", func(t *testing.T) {
+			svc.markProcessed(me, msg)
+			svc.markProcessed(me, msg)
+			var count int64
+			db.Model(&models.ProcessedEmail{}).Where("user_id = ?", me).Count(&count)
+			if count != 2 || !svc.alreadyProcessed(me, msg) {
+				t.Errorf("got %d marks, want the ID and the Message-Id header", count)
+			}
+		})
+
+		t.Run("stored email takes the last verdict", func(t *testing.T) {
+			svc.saveEmail(me, msg, &EmailOutcome{Action: OutcomeQueued, Reason: ReviewNoCompanyMatch})
+			svc.saveEmail(me, msg, &EmailOutcome{Action: OutcomeStatusChanged, Company: "Acme", JobID: &backend.ID, ToStatus: "SCREEN"})
+			var emails []models.Email
+			db.Find(&emails)
+			if len(emails) != 1 || emails[0].Classification.Action != OutcomeStatusChanged || emails[0].JobID == nil || *emails[0].JobID != backend.ID {
+				t.Errorf("got %+v", emails)
+			}
+		})
+
+		t.Run("thread link is replaced", func(t *testing.T) {
+			if err := linkThread(db, me, "<m1@acme.com>", backend.ID); err != nil {
+				t.Fatal(err)
+			}
+			if err := linkThread(db, me, "<m1@acme.com>", frontend.ID); err != nil {
+				t.Fatal(err)
+			}
+			var links []models.EmailThread
+			db.Find(&links)
+			if len(links) != 1 || links[0].JobID != frontend.ID {
+				t.Errorf("got %+v", links)
+			}
+		})
+
+		t.Run("email lands on the timeline once", func(t *testing.T) {
+			payload := models.EmailReceivedPayload{MessageID: msg.ID, Subject: "Your application"}
+			first, err := jobs.RecordEvent(backend, models.EventEmailReceived, SourceEmail, payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := jobs.RecordEvent(backend, models.EventEmailReceived, SourceEmail, payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if first.ID == 0 || second.ID != 0 {
+				t.Errorf("event IDs %d and %d, want the second skipped", first.ID, second.ID)
+			}
+			if _, err := jobs.RecordEvent(frontend, models.EventEmailReceived, SourceEmail, payload); err != nil {
+				t.Errorf("same email on another job: %v", err)
+			}
+		})
+
+		t.Run("checkpoint is replaced", func(t *testing.T) {
+			if err := svc.saveCheckpoint(me, "imap", []string{"1", "2"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := svc.saveCheckpoint(me, "imap", []string{"3"}); err != nil {
+				t.Fatal(err)
+			}
+			ids, err := svc.loadCheckpoint(me, "imap")
+			if err != nil || len(ids) != 1 || ids[0] != "3" {
+				t.Errorf("got %v, %v", ids, err)
+			}
+		})
+	})
+}
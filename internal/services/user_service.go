@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/auth"
+	"github.com/justsurfingit/Agentic-Job-Tracker/internal/models"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrGmailNotConnected = errors.New("gmail is not connected for this user")
+)
+
+type UserService struct {
+	DB *gorm.DB
+	// Tokens holds the users' Gmail tokens (nil = Gmail disabled)
+	Tokens auth.TokenStore
+}
+
+func NewUserService(db *gorm.DB, tokens auth.TokenStore) *UserService {
+	return &UserService{DB: db, Tokens: tokens}
+}
+
+// Create adds a user, or returns the existing one with that email
+func (s *UserService) Create(email string) (*models.User, error) {
+	var user models.User
+	email = strings.ToLower(strings.TrimSpace(email))
+	err := s.DB.Where(models.User{Email: email}).FirstOrCreate(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Get loads a user by ID
+func (s *UserService) Get(id uint) (*models.User, error) {
+	var user models.User
+	err := s.DB.First(&user, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByEmail loads a user by their (case-insensitive) email
+func (s *UserService) GetByEmail(email string) (*models.User, error) {
+	var user models.User
+	err := s.DB.Where("email = ?", strings.ToLower(strings.TrimSpace(email))).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// List returns every user, oldest first
+func (s *UserService) List() ([]models.User, error) {
+	users := []models.User{}
+	err := s.DB.Order("id").Find(&users).Error
+	return users, err
+}
+
+// Connected returns the users whose mailbox the watcher should sync
+func (s *UserService) Connected(ctx context.Context) ([]models.User, error) {
+	users := []models.User{}
+	if s.Tokens == nil {
+		return users, nil
+	}
+	ids, err := s.Tokens.UserIDs(ctx)
+	if err != nil || len(ids) == 0 {
+		return users, err
+	}
+	err = s.DB.Where("id IN ?", ids).Order("id").Find(&users).Error
+	return users, err
+}
+
+// GmailConnected reports whether the user has a Gmail token stored
+func (s *UserService) GmailConnected(ctx context.Context, userID uint) bool {
+	_, err := s.Token(ctx, userID)
+	return err == nil
+}
+
+// SaveToken stores the user's Gmail OAuth token, connecting their mailbox
+func (s *UserService) SaveToken(ctx context.Context, userID uint, tok *oauth2.Token) error {
+	if s.Tokens == nil {
+		return ErrGmailDisabled
+	}
+	return s.Tokens.Save(ctx, userID, tok)
+}
+
+// Token loads the user's stored Gmail OAuth token
+func (s *UserService) Token(ctx context.Context, userID uint) (*oauth2.Token, error) {
+	if s.Tokens == nil {
+		return nil, ErrGmailNotConnected
+	}
+	tok, err := s.Tokens.Load(ctx, userID)
+	if errors.Is(err, auth.ErrNoToken) {
+		return nil, ErrGmailNotConnected
+	}
+	return tok, err
+}
+
+// DeleteToken forgets the user's token, push watch and sync position, disconnecting their mailbox
+func (s *UserService) DeleteToken(ctx context.Context, userID uint) error {
+	if s.Tokens != nil {
+		if err := s.Tokens.Delete(ctx, userID); err != nil {
+			return err
+		}
+	}
+	// Pushes for the mailbox are ignored from now on, the watch itself expires within 7 days
+	if err := s.DB.Where("user_id = ?", userID).Delete(&models.GmailWatch{}).Error; err != nil {
+		return err
+	}
+	return s.DB.Model(&models.User{}).Where("id = ?", userID).Update("last_history_id", 0).Error
+}
@@ -0,0 +1,119 @@
+package status
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Status is the stage an application is in.
+// The happy path is WISHLIST -> APPLIED -> SCREEN -> INTERVIEW (xN) -> OFFER -> ACCEPTED,
+// and any active stage can drop out to REJECTED / WITHDRAWN / GHOSTED.
+type Status string
+
+const (
+	Wishlist  Status = "WISHLIST"
+	Applied   Status = "APPLIED"
+	Screen    Status = "SCREEN"
+	Interview Status = "INTERVIEW"
+	Offer     Status = "OFFER"
+	Accepted  Status = "ACCEPTED"
+	Declined  Status = "DECLINED"
+	Rejected  Status = "REJECTED"
+	Withdrawn Status = "WITHDRAWN"
+	Ghosted   Status = "GHOSTED"
+)
+
+var (
+	// ErrUnknownStatus is returned by Parse for values outside the list above
+	ErrUnknownStatus = errors.New("unknown status")
+	// ErrIllegalTransition is wrapped by every *TransitionError so callers can use errors.Is
+	ErrIllegalTransition = errors.New("illegal status transition")
+)
+
+// transitions is the whole state machine: from -> allowed next states.
+// INTERVIEW -> INTERVIEW is allowed on purpose, every new round is a transition.
+// GHOSTED is not terminal because companies do come back after weeks of silence.
+var transitions = map[Status][]Status{
+	Wishlist:  {Applied, Withdrawn},
+	Applied:   {Screen, Interview, Offer, Rejected, Withdrawn, Ghosted},
+	Screen:    {Interview, Offer, Rejected, Withdrawn, Ghosted},
+	Interview: {Interview, Offer, Rejected, Withdrawn, Ghosted},
+	Offer:     {Accepted, Declined, Rejected, Withdrawn},
+	Ghosted:   {Screen, Interview, Offer, Rejected, Withdrawn},
+	Accepted:  {},
+	Declined:  {},
+	Rejected:  {},
+	Withdrawn: {},
+}
+
+// order is used for listing statuses in a stable, human friendly way
+var order = []Status{Wishlist, Applied, Screen, Interview, Offer, Accepted, Declined, Rejected, Withdrawn, Ghosted}
+
+// TransitionError describes a move the state machine does not allow
+type TransitionError struct {
+	From Status
+	To   Status
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot move from %s to %s", e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrIllegalTransition
+}
+
+// Parse normalizes user / LLM input ("interview " -> INTERVIEW) and rejects unknown values
+func Parse(s string) (Status, error) {
+	st := Status(strings.ToUpper(strings.TrimSpace(s)))
+	if _, ok := transitions[st]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownStatus, s)
+	}
+	return st, nil
+}
+
+// All returns every status in pipeline order
+func All() []Status {
+	return append([]Status(nil), order...)
+}
+
+// Terminal returns the statuses that can never change again
+func Terminal() []Status {
+	var res []Status
+	for _, st := range order {
+		if st.IsTerminal() {
+			res = append(res, st)
+		}
+	}
+	return res
+}
+
+// IsTerminal reports whether no transition leaves this status
+func (s Status) IsTerminal() bool {
+	next, ok := transitions[s]
+	return ok && len(next) == 0
+}
+
+// Next returns the statuses reachable from s in one step
+func (s Status) Next() []Status {
+	return append([]Status(nil), transitions[s]...)
+}
+
+// CanTransitionTo reports whether s -> to is an edge of the graph
+func (s Status) CanTransitionTo(to Status) bool {
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition validates s -> to and returns a *TransitionError when it is not allowed
+func Transition(from, to Status) error {
+	if !from.CanTransitionTo(to) {
+		return &TransitionError{From: from, To: to}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package status
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransition(t *testing.T) {
+	tests := []struct {
+		from, to Status
+		ok       bool
+	}{
+		// The happy path
+		{Wishlist, Applied, true},
+		{Applied, Screen, true},
+		{Screen, Interview, true},
+		{Interview, Offer, true},
+		{Offer, Accepted, true},
+		{Offer, Declined, true},
+
+		// Skipping stages and dropping out
+		{Applied, Interview, true},
+		{Applied, Offer, true},
+		{Applied, Ghosted, true},
+		{Screen, Rejected, true},
+		{Offer, Rejected, true},
+		{Wishlist, Withdrawn, true},
+
+		// Ghosted companies come back
+		{Ghosted, Interview, true},
+		{Ghosted, Rejected, true},
+
+		// Backwards
+		{Offer, Applied, false},
+		{Interview, Screen, false},
+		{Applied, Wishlist, false},
+		{Ghosted, Applied, false},
+
+		// Nothing happened yet that could reject or ghost a wishlist entry
+		{Wishlist, Rejected, false},
+		{Wishlist, Ghosted, false},
+		{Wishlist, Offer, false},
+
+		// Only another interview round moves to the same status
+		{Interview, Interview, true},
+		{Wishlist, Wishlist, false},
+		{Applied, Applied, false},
+		{Offer, Offer, false},
+		{Ghosted, Ghosted, false},
+		{Rejected, Rejected, false},
+
+		// Terminal statuses stay
+		{Accepted, Offer, false},
+		{Declined, Offer, false},
+		{Rejected, Interview, false},
+		{Withdrawn, Applied, false},
+		{Ghosted, Accepted, false},
+		{Offer, Ghosted, false},
+
+		// Unknown statuses have no edges
+		{"ARCHIVED", Applied, false},
+		{Applied, "ARCHIVED", false},
+	}
+	for _, tt := range tests {
+		err := Transition(tt.from, tt.to)
+		if tt.ok {
+			if err != nil {
+				t.Errorf("%s -> %s: %v, want allowed", tt.from, tt.to, err)
+			}
+			continue
+		}
+		var te *TransitionError
+		if !errors.As(err, &te) || te.From != tt.from || te.To != tt.to {
+			t.Errorf("%s -> %s: got %v, want a *TransitionError", tt.from, tt.to, err)
+		}
+		if !errors.Is(err, ErrIllegalTransition) {
+			t.Errorf("%s -> %s: %v doesn't wrap ErrIllegalTransition", tt.from, tt.to, err)
+		}
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	want := map[Status]bool{Accepted: true, Declined: true, Rejected: true, Withdrawn: true}
+	for _, st := range All() {
+		if st.IsTerminal() != want[st] {
+			t.Errorf("%s.IsTerminal() = %v, want %v", st, st.IsTerminal(), want[st])
+		}
+		for _, next := range st.Next() {
+			if _, ok := transitions[next]; !ok {
+				t.Errorf("%s leads to unknown status %s", st, next)
+			}
+		}
+	}
+	if len(Terminal()) != len(want) {
+		t.Errorf("Terminal() = %v", Terminal())
+	}
+	if Status("ARCHIVED").IsTerminal() {
+		t.Error("an unknown status counts as terminal")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Status
+	}{
+		{"interview ", Interview},
+		{"Offer", Offer},
+		{"GHOSTED", Ghosted},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "archived", "in progress"} {
+		if _, err := Parse(in); !errors.Is(err, ErrUnknownStatus) {
+			t.Errorf("Parse(%q): got %v, want ErrUnknownStatus", in, err)
+		}
+	}
+}